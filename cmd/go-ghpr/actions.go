@@ -0,0 +1,484 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AndreyBozhko/go-alfred-prs/pkg/ghpr"
+	aw "github.com/deanishe/awgo"
+	"github.com/google/go-github/v48/github"
+	"go.deanishe.net/env"
+)
+
+// selectedPR identifies the pull request chosen in Alfred,
+// which is passed to action commands via item variables.
+type selectedPR struct {
+	ID     int64  `env:"GH_PR_ID"`
+	Repo   string `env:"GH_PR_REPO"`
+	Number int    `env:"GH_PR_NUMBER"`
+}
+
+// getSelectedPR reads the selected pull request from environment variables.
+func getSelectedPR() (*selectedPR, error) {
+	var pr selectedPR
+	if err := env.Bind(&pr); err != nil {
+		return nil, err
+	}
+
+	if !strings.Contains(pr.Repo, "/") || pr.Number <= 0 {
+		return nil, errNoPRChosen
+	}
+
+	return &pr, nil
+}
+
+// Owner returns the owner of the repository.
+func (pr *selectedPR) Owner() string {
+	owner, _, _ := strings.Cut(pr.Repo, "/")
+	return owner
+}
+
+// Name returns the name of the repository.
+func (pr *selectedPR) Name() string {
+	_, name, _ := strings.Cut(pr.Repo, "/")
+	return name
+}
+
+func (pr *selectedPR) String() string {
+	return pr.Repo + "#" + strconv.Itoa(pr.Number)
+}
+
+// ApprovePR submits an approving review for the pull request selected in Alfred,
+// and refreshes the cached reviews of that pull request.
+func (wf *GithubWorkflow) ApprovePR() error {
+	ctx := context.Background()
+
+	pr, err := getSelectedPR()
+	if err != nil {
+		return err
+	}
+
+	client, err := wf.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	review := &github.PullRequestReviewRequest{Event: github.String("APPROVE")}
+	if _, _, err = client.PullRequests.CreateReview(ctx, pr.Owner(), pr.Name(), pr.Number, review); err != nil {
+		return err
+	}
+
+	// the pull request is approved already, so failing to refresh its reviews is only logged
+	reviews, err := ghpr.ListReviews(ctx, client, pr.Owner(), pr.Name(), pr.Number)
+	if err == nil {
+		err = wf.reviews.UpdateReviews(pr.ID, reviews)
+	}
+	if err != nil {
+		log.Printf("failed to update cached reviews of PR %d, error: %s", pr.ID, err)
+	}
+
+	wf.RunHooks(eventPRApproved, []*prView{{
+		ID:     pr.ID,
+		Repo:   pr.Repo,
+		Number: pr.Number,
+		URL:    fmt.Sprintf("%s/%s/pull/%d", wf.GetBaseWebUrl(), pr.Repo, pr.Number),
+	}})
+
+	wf.Notify("Pull request approved", pr.String())
+	return nil
+}
+
+// GraphQL mutations which change the draft state of a pull request.
+const (
+	mutationConvertToDraft = `mutation($id: ID!) { convertPullRequestToDraft(input: {pullRequestId: $id}) { clientMutationId } }`
+	mutationReadyForReview = `mutation($id: ID!) { markPullRequestReadyForReview(input: {pullRequestId: $id}) { clientMutationId } }`
+)
+
+// ToggleDraft switches the pull request selected in Alfred
+// between draft and ready for review states.
+func (wf *GithubWorkflow) ToggleDraft() error {
+	ctx := context.Background()
+
+	pr, err := getSelectedPR()
+	if err != nil {
+		return err
+	}
+
+	if err = wf.requireFeatures(featureGraphQL, featureDraftToggle); err != nil {
+		return err
+	}
+
+	client, err := wf.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	details, _, err := client.PullRequests.Get(ctx, pr.Owner(), pr.Name(), pr.Number)
+	if err != nil {
+		return err
+	}
+
+	mutation, message := mutationConvertToDraft, "Pull request converted to draft"
+	if details.GetDraft() {
+		mutation, message = mutationReadyForReview, "Pull request is ready for review"
+	}
+
+	vars := map[string]interface{}{"id": details.GetNodeID()}
+	if err = ghpr.DoGraphQL(ctx, client, mutation, vars, nil); err != nil {
+		return err
+	}
+
+	wf.Notify(message, pr.String())
+	return nil
+}
+
+// AssignMe adds the authenticated user to the assignees of the pull request
+// selected in Alfred, and updates the cached pull request accordingly.
+func (wf *GithubWorkflow) AssignMe() error {
+	ctx := context.Background()
+
+	pr, err := getSelectedPR()
+	if err != nil {
+		return err
+	}
+
+	client, err := wf.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	user, err := wf.loadOrFetchUser(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	issue, _, err := client.Issues.AddAssignees(ctx, pr.Owner(), pr.Name(), pr.Number, []string{user.GetLogin()})
+	if err != nil {
+		return err
+	}
+
+	// the assignment itself succeeded, so a stale cache is not an error
+	err = wf.prs.UpdatePR(pr.ID, func(cached *github.Issue) {
+		cached.Assignees = issue.Assignees
+	})
+	if err != nil {
+		log.Printf("failed to update cached PR %d, error: %s", pr.ID, err)
+	}
+
+	wf.Notify("Pull request assigned to you", pr.String())
+	return nil
+}
+
+// BroadenRoles enables all roles in the workflow configuration (merged into
+// the configured QUERY_BY_ROLES), and invalidates workflow cache, so that
+// pull requests are searched again.
+func (wf *GithubWorkflow) BroadenRoles() error {
+	if err := wf.Config.Set("QUERY_BY_ROLES", broadenRoleFilters(os.Getenv("QUERY_BY_ROLES")), false).Do(); err != nil {
+		return err
+	}
+
+	if err := wf.ClearCache(); err != nil {
+		return err
+	}
+
+	wf.Notify("Searching by all roles", "run ghpr to see the results")
+	return nil
+}
+
+// ChooseReviewers lists pull requests of the current user, so that one of them
+// can be selected to request reviews from the logins typed in Alfred.
+// Until logins are typed, the teammate with the fewest open review requests
+// is suggested, if QUERY_BY_TEAMS is configured.
+func (wf *GithubWorkflow) ChooseReviewers(input string) error {
+	prs, err := wf.loadPRViews(0)
+	if err != nil {
+		return err
+	}
+
+	logins := parseLogins(input)
+	workload := wf.loadWorkload()
+	for _, pr := range prs {
+		if !pr.Mine {
+			continue
+		}
+
+		reviewers := logins
+		subtitle := "type reviewer logins to request reviews on " + pr.String()
+		if len(logins) > 0 {
+			subtitle = fmt.Sprintf("request review from %s on %s", strings.Join(logins, ", "), pr)
+		} else if login, count := leastLoaded(workload, pr.Author); login != "" {
+			reviewers = []string{login}
+			subtitle = fmt.Sprintf("request review from %s (least loaded teammate, %d open review requests), or type reviewer logins", login, count)
+		}
+
+		wf.NewItem(pr.FullTitle()).
+			Subtitle(subtitle).
+			Arg(strings.Join(reviewers, ",")).
+			Valid(len(reviewers) > 0).
+			Var(fbActionKey, actionRequestReviewers).
+			Var(fbPullRequestIdKey, strconv.FormatInt(pr.ID, 10)).
+			Var(fbPullRequestRepoKey, pr.Repo).
+			Var(fbPullRequestNumberKey, strconv.Itoa(pr.Number))
+	}
+
+	if wf.IsEmpty() {
+		wf.NewItem("You have no open pull requests").
+			Subtitle("try running ghpr-update manually").
+			Valid(false).
+			Icon(aw.IconInfo)
+	}
+
+	return nil
+}
+
+// loadWorkload returns the cached review workload of teammates, and refreshes
+// it in the background, once it expires.
+func (wf *GithubWorkflow) loadWorkload() map[string]int {
+	if len(wf.teams()) == 0 {
+		return nil
+	}
+
+	if wf.state.WorkloadExpired(workloadMaxAge) {
+		if err := wf.LaunchBackgroundTask(taskUpdateWorkload); err != nil {
+			log.Println("failed to launch workload task:", err)
+		}
+	}
+	if wf.IsRunning(wf.jobName(taskUpdateWorkload)) {
+		wf.Rerun(rerunDelayDefault.Seconds())
+	}
+
+	workload, err := wf.state.LoadWorkload()
+	if err != nil {
+		log.Println("failed to load workload:", err)
+	}
+	return workload
+}
+
+// RequestReviewers requests reviews from the given logins
+// on the pull request selected in Alfred.
+func (wf *GithubWorkflow) RequestReviewers(input string) error {
+	ctx := context.Background()
+
+	pr, err := getSelectedPR()
+	if err != nil {
+		return err
+	}
+
+	logins := parseLogins(input)
+	if len(logins) == 0 {
+		return errNoLogins
+	}
+
+	client, err := wf.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = client.PullRequests.RequestReviewers(ctx, pr.Owner(), pr.Name(), pr.Number, github.ReviewersRequest{Reviewers: logins})
+	if err != nil {
+		return err
+	}
+
+	wf.Notify("Review requested from "+strings.Join(logins, ", "), pr.String())
+	return nil
+}
+
+// openUrls opens the URLs in the default browser.
+var openUrls = func(urls []string) error {
+	return exec.Command("open", urls...).Run()
+}
+
+// OpenAll opens all pull requests, given by their URLs (one per line).
+func (wf *GithubWorkflow) OpenAll(input string) error {
+	urls := strings.Fields(input)
+	if len(urls) == 0 {
+		return errNoPRChosen
+	}
+
+	if err := openUrls(urls); err != nil {
+		return err
+	}
+
+	wf.Notify(fmt.Sprintf("Opened %d pull requests", len(urls)), "")
+	return nil
+}
+
+// qrencodePaths are the locations of qrencode, which Alfred may not have on its PATH.
+var qrencodePaths = []string{"qrencode", "/opt/homebrew/bin/qrencode", "/usr/local/bin/qrencode"}
+
+// encodeQR writes the QR code of the text to a PNG file, if qrencode is installed.
+var encodeQR = func(text, file string) error {
+	for _, path := range qrencodePaths {
+		if bin, err := exec.LookPath(path); err == nil {
+			return exec.Command(bin, "-s", "10", "-o", file, text).Run()
+		}
+	}
+	return exec.ErrNotFound
+}
+
+// copyToClipboard puts the text on the clipboard, which Universal Clipboard
+// shares with the nearby Apple devices.
+var copyToClipboard = func(text string) error {
+	cmd := exec.Command("pbcopy")
+	cmd.Stdin = strings.NewReader(text)
+	return cmd.Run()
+}
+
+// Handoff helps to continue with the pull request on the phone: it shows
+// the QR code of its URL (which the GitHub app opens), if qrencode is
+// installed, or copies the URL to the clipboard otherwise.
+func (wf *GithubWorkflow) Handoff(htmlUrl string) error {
+	if _, _, _, err := parsePullRequestUrl(htmlUrl); err != nil {
+		return err
+	}
+
+	file := filepath.Join(wf.CacheDir(), "handoff.png")
+	if err := encodeQR(htmlUrl, file); err == nil {
+		if err = openUrls([]string{file}); err != nil {
+			return err
+		}
+		wf.Notify("Scan the QR code with your phone", htmlUrl)
+		return nil
+	} else if err != exec.ErrNotFound {
+		return err
+	}
+
+	if err := copyToClipboard(htmlUrl); err != nil {
+		return err
+	}
+	wf.Notify("Link copied, paste it on your phone", htmlUrl)
+	return nil
+}
+
+// ExpandGroup opens the search view in Alfred, listing the pull requests
+// which were grouped under the title.
+func (wf *GithubWorkflow) ExpandGroup(title string) error {
+	return wf.Alfred.Search(searchViewKeyword + " " + title)
+}
+
+// snooze hides a pull request until it expires, or the pull request is updated.
+type snooze struct {
+	Until     time.Time `json:"until"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// active reports whether the snooze still hides the pull request.
+func (s *snooze) active(pr *prView, now time.Time) bool {
+	return now.Before(s.Until) && !pr.UpdatedAt.After(s.UpdatedAt)
+}
+
+// Snooze hides the pull request selected in Alfred for a few days,
+// unless it is updated in the meantime. Expired snoozes are dropped.
+func (wf *GithubWorkflow) Snooze() error {
+	pr, err := getSelectedPR()
+	if err != nil {
+		return err
+	}
+
+	prs, err := wf.prs.LoadPRs(0)
+	if err != nil {
+		return err
+	}
+
+	// a pull request, which is not cached yet, is snoozed until it is updated after now
+	now := time.Now()
+	updatedAt := now
+	for _, cached := range prs {
+		if cached.GetID() == pr.ID {
+			updatedAt = cached.GetUpdatedAt()
+		}
+	}
+
+	snoozes, err := wf.snoozes.LoadSnoozes()
+	if err != nil {
+		return err
+	}
+
+	for id, s := range snoozes {
+		if !now.Before(s.Until) {
+			delete(snoozes, id)
+		}
+	}
+
+	days := wf.SnoozeDays
+	if days <= 0 {
+		days = defaultSnoozeDays
+	}
+	snoozes[pr.ID] = &snooze{Until: now.AddDate(0, 0, days), UpdatedAt: updatedAt}
+
+	if err = wf.snoozes.StoreSnoozes(snoozes); err != nil {
+		return err
+	}
+
+	wf.Notify(fmt.Sprintf("Snoozed for %d days", days), pr.String())
+	return nil
+}
+
+// withoutSnoozed filters out pull requests, which are currently snoozed.
+func (wf *GithubWorkflow) withoutSnoozed(prs []*prView) []*prView {
+	snoozes, err := wf.snoozes.LoadSnoozes()
+	if err != nil {
+		log.Println("failed to load snoozed pull requests, error:", err)
+		return prs
+	}
+
+	now := time.Now()
+	result := make([]*prView, 0, len(prs))
+	for _, pr := range prs {
+		if s, ok := snoozes[pr.ID]; !ok || !s.active(pr, now) {
+			result = append(result, pr)
+		}
+	}
+	return result
+}
+
+// displayLimit is the number of cached pull requests, which are read to list MAX_ITEMS
+// of them: the snoozed ones are not listed, so as many more are read, in case they
+// are among the first ones. All pull requests are read, if MAX_ITEMS is not set.
+func (wf *GithubWorkflow) displayLimit() int {
+	if wf.MaxItems <= 0 {
+		return 0
+	}
+
+	snoozes, err := wf.snoozes.LoadSnoozes()
+	if err != nil {
+		return wf.MaxItems
+	}
+	return wf.MaxItems + len(snoozes)
+}
+
+// Nudge posts a polite reminder on the pull request selected in Alfred,
+// mentioning the reviewers whose review is still requested.
+func (wf *GithubWorkflow) Nudge() error {
+	ctx := context.Background()
+
+	pr, err := getSelectedPR()
+	if err != nil {
+		return err
+	}
+
+	client, err := wf.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	reviewers, _, err := client.PullRequests.ListReviewers(ctx, pr.Owner(), pr.Name(), pr.Number, nil)
+	if err != nil {
+		return err
+	}
+
+	body := nudgeComment(reviewers, pr.Owner())
+	if _, _, err = client.Issues.CreateComment(ctx, pr.Owner(), pr.Name(), pr.Number, &github.IssueComment{Body: &body}); err != nil {
+		return err
+	}
+
+	wf.Notify("Reminder posted", pr.String())
+	return nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	aw "github.com/deanishe/awgo"
+	"github.com/google/go-github/v48/github"
+	"golang.org/x/sync/errgroup"
+)
+
+// avatarDir is the directory in workflow cache, where avatars are kept.
+const avatarDir = "avatars"
+
+// avatarSize is the width and height of cached avatars, in pixels.
+const avatarSize = 64
+
+// avatarFile returns the path of the cached avatar of the repository owner.
+func (wf *GithubWorkflow) avatarFile(owner string) string {
+	return filepath.Join(wf.CacheDir(), avatarDir, owner+".png")
+}
+
+// avatarIcon returns the cached avatar of the owner of the pull request's
+// repository, or nil if it was not downloaded yet.
+func (wf *GithubWorkflow) avatarIcon(pr *prView) *aw.Icon {
+	owner, _, _ := strings.Cut(pr.Repo, "/")
+	file := wf.avatarFile(owner)
+	if _, err := os.Stat(file); err != nil {
+		return nil
+	}
+	return &aw.Icon{Value: file}
+}
+
+// fetchAvatars downloads the avatars of the owners of the pull requests' repositories,
+// unless they are cached already. Avatars are refreshed once they expire, since owners
+// may change them.
+func (wf *GithubWorkflow) fetchAvatars(ctx context.Context, client *github.Client, prs []*github.Issue) error {
+	owners := make(map[string]bool)
+	for _, pr := range prs {
+		owner, _, _ := strings.Cut(parseRepoFromUrl(pr.GetHTMLURL()), "/")
+		if info, err := os.Stat(wf.avatarFile(owner)); err != nil || time.Since(info.ModTime()) > avatarMaxAge {
+			owners[owner] = true
+		}
+	}
+	if len(owners) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Join(wf.CacheDir(), avatarDir), 0700); err != nil {
+		return err
+	}
+
+	wg, ctx := errgroup.WithContext(ctx)
+	for owner := range owners {
+		owner := owner
+		wg.Go(func() error {
+			if err := wf.fetchAvatar(ctx, client, owner); err != nil {
+				// other avatars are still worth downloading
+				log.Printf("failed to fetch avatar of %s, error: %s", owner, err)
+			}
+			return nil
+		})
+	}
+	return wg.Wait()
+}
+
+// fetchAvatar downloads the avatar of the user or organization, and caches it as a png,
+// resized to avatarSize. Avatars are served by another host, so they are downloaded
+// without the API token. The png is written to a temporary file first, so that
+// a failed download never leaves a partial avatar behind.
+func (wf *GithubWorkflow) fetchAvatar(ctx context.Context, client *github.Client, owner string) error {
+	user, _, err := client.Users.Get(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, user.GetAvatarURL(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := (&http.Client{Transport: wf.base}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status of avatar: %s", resp.Status)
+	}
+
+	img, _, err := image.Decode(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(wf.avatarFile(owner)), owner+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err = png.Encode(tmp, resizeImage(img, avatarSize)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), wf.avatarFile(owner))
+}
+
+// resizeImage scales the image down to a square of the size, averaging the pixels
+// which fall into each of the resulting pixels. Smaller images are kept as is.
+func resizeImage(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	if bounds.Dx() <= size && bounds.Dy() <= size {
+		return img
+	}
+
+	result := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		y0, y1 := bounds.Min.Y+y*bounds.Dy()/size, bounds.Min.Y+(y+1)*bounds.Dy()/size
+		for x := 0; x < size; x++ {
+			x0, x1 := bounds.Min.X+x*bounds.Dx()/size, bounds.Min.X+(x+1)*bounds.Dx()/size
+
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1 || sy == y0; sy++ {
+				for sx := x0; sx < x1 || sx == x0; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, b, a, n = r+cr, g+cg, b+cb, a+ca, n+1
+				}
+			}
+			result.Set(x, y, color.RGBA64{uint16(r / n), uint16(g / n), uint16(b / n), uint16(a / n)})
+		}
+	}
+	return result
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AndreyBozhko/go-alfred-prs/pkg/ghpr"
+	aw "github.com/deanishe/awgo"
+	"github.com/google/go-github/v48/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResizeImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 128, 128))
+	for y := 0; y < 128; y++ {
+		for x := 0; x < 128; x++ {
+			// the left half is black, and the right half is white
+			img.Set(x, y, color.Gray{uint8(255 * (x / 64))})
+		}
+	}
+
+	resized := resizeImage(img, 64)
+	assert.Equal(t, image.Rect(0, 0, 64, 64), resized.Bounds())
+	assert.Equal(t, color.RGBA{0, 0, 0, 255}, resized.At(10, 10))
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, resized.At(50, 10))
+
+	small := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	assert.Equal(t, small, resizeImage(small, 64))
+}
+
+func TestFetchAvatars(t *testing.T) {
+	var avatar bytes.Buffer
+	assert.Nil(t, png.Encode(&avatar, image.NewRGBA(image.Rect(0, 0, 460, 460))))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/users/org", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"login": "org", "avatar_url": "http://` + r.Host + `/avatars/org"}`))
+	})
+	mux.HandleFunc("/avatars/org", func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"))
+		w.Write(avatar.Bytes())
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := ghpr.NewClient(context.Background(), server.URL, "token")
+	assert.Nil(t, err)
+	defer os.Remove(testWf.avatarFile("org"))
+
+	pr := &prView{Repo: "org/repo"}
+	assert.Nil(t, testWf.avatarIcon(pr))
+
+	prs := []*github.Issue{{HTMLURL: github.String("https://gh.com/org/repo/pull/78")}}
+	assert.Nil(t, testWf.fetchAvatars(context.Background(), client, prs))
+
+	assert.Equal(t, &aw.Icon{Value: testWf.avatarFile("org")}, testWf.avatarIcon(pr))
+
+	file, err := os.Open(testWf.avatarFile("org"))
+	assert.Nil(t, err)
+	defer file.Close()
+
+	cfg, err := png.DecodeConfig(file)
+	assert.Nil(t, err)
+	assert.Equal(t, avatarSize, cfg.Width)
+	assert.Equal(t, avatarSize, cfg.Height)
+
+	// no temporary files are left behind
+	entries, err := os.ReadDir(filepath.Dir(testWf.avatarFile("org")))
+	assert.Nil(t, err)
+	for _, entry := range entries {
+		assert.NotContains(t, entry.Name(), ".tmp")
+	}
+}
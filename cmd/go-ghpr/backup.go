@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// workflowBackup is the state of the workflow, which is curated by the user:
+// the configuration, snoozed pull requests, read tracking and usage stats.
+// Neither the API token, nor secret variables of the configuration (like HTTPS_PROXY,
+// which may hold a password), nor any cached pull requests are part of it.
+type workflowBackup struct {
+	Version    string            `json:"version"`
+	CreatedAt  time.Time         `json:"created_at"`
+	Config     map[string]string `json:"config"`
+	Snoozes    map[int64]*snooze `json:"snoozes"`
+	LastViewed *lastViewed       `json:"last_viewed"`
+	UsageStats map[string]int    `json:"usage_stats"`
+}
+
+// Backup archives the workflow state to the file given as the argument
+// (or to a timestamped file in workflow data), and writes the path of the file.
+func (wf *GithubWorkflow) Backup(w io.Writer, args []string) error {
+	backup := &workflowBackup{
+		Version:   wf.Version(),
+		CreatedAt: time.Now().UTC(),
+		Config:    lookupConfigEnv(),
+	}
+	for _, key := range secretConfigEnv() {
+		delete(backup.Config, key)
+	}
+
+	var err error
+	if backup.Snoozes, err = wf.snoozes.LoadSnoozes(); err != nil {
+		return err
+	}
+	if backup.LastViewed, err = wf.state.LoadLastViewed(); err != nil {
+		return err
+	}
+	if backup.UsageStats, err = wf.stats.LoadUsageStats(); err != nil {
+		return err
+	}
+
+	file := filepath.Join(wf.DataDir(), "backup-"+backup.CreatedAt.Format(snapshotNameLayout)+".json")
+	if len(args) > 0 {
+		file = args[0]
+	}
+
+	bts, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(file, bts, 0600); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, file)
+	return err
+}
+
+// Restore brings back the workflow state from the backup file given as the argument,
+// and writes the names of the restored configuration variables, as well as the skipped
+// ones. Variables executed by the workflow are only restored if commands are trusted.
+func (wf *GithubWorkflow) Restore(w io.Writer, args []string, commands bool) error {
+	if len(args) != 1 {
+		return &alfredError{"expected a backup to restore", "usage: --restore [--restore_commands] <file>"}
+	}
+
+	bts, err := os.ReadFile(args[0])
+	if err != nil {
+		return &alfredError{"cannot read backup " + args[0], err.Error()}
+	}
+
+	var backup workflowBackup
+	if err = json.Unmarshal(bts, &backup); err != nil {
+		return &alfredError{"invalid backup " + args[0], err.Error()}
+	}
+
+	if backup.Snoozes != nil {
+		if err = wf.snoozes.StoreSnoozes(backup.Snoozes); err != nil {
+			return err
+		}
+	}
+	if backup.LastViewed != nil {
+		if err = wf.state.StoreLastViewed(backup.LastViewed); err != nil {
+			return err
+		}
+	}
+	if backup.UsageStats != nil {
+		if err = wf.stats.StoreUsageStats(backup.UsageStats); err != nil {
+			return err
+		}
+	}
+
+	config, skipped := restorableConfig(backup.Config, commands)
+	for _, key := range sortedKeys(skipped) {
+		if _, err = fmt.Fprintln(w, "skipped", key+":", skipped[key]); err != nil {
+			return err
+		}
+	}
+	if len(config) == 0 {
+		return nil
+	}
+
+	for key, value := range config {
+		wf.Config.Set(key, value, false)
+	}
+	if err = wf.Config.Do(); err != nil {
+		return &alfredError{"cannot restore configuration", err.Error()}
+	}
+
+	for _, key := range sortedKeys(config) {
+		if _, err = fmt.Fprintln(w, "restored", key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restorableConfig splits the configuration of a backup into the variables to restore,
+// and the skipped ones, along with the reason. Only the variables of the workflow config
+// are restored, but neither the secret ones (which backups never have), nor the ones
+// executed by the workflow (like TOKEN_COMMAND), unless commands are trusted, since
+// the backup may come from anywhere.
+func restorableConfig(config map[string]string, commands bool) (map[string]string, map[string]string) {
+	known := make(map[string]bool)
+	typ := reflect.TypeOf(workflowConfig{})
+	for i := 0; i < typ.NumField(); i++ {
+		known[typ.Field(i).Tag.Get("env")] = true
+	}
+	delete(known, "-")
+
+	denied := make(map[string]string)
+	for _, key := range secretConfigEnv() {
+		denied[key] = "secret variable"
+	}
+	if !commands {
+		for _, key := range executableConfigEnv() {
+			denied[key] = "executed by the workflow, use --restore_commands to restore it"
+		}
+	}
+
+	result := make(map[string]string)
+	reasons := make(map[string]string)
+	for key, value := range config {
+		switch {
+		case !known[key] && !isSavedSearchEnv(key):
+			reasons[key] = "unknown variable"
+		case denied[key] != "":
+			reasons[key] = denied[key]
+		default:
+			result[key] = value
+		}
+	}
+	return result, reasons
+}
+
+// sortedKeys returns the keys of the map in alphabetical order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/AndreyBozhko/go-alfred-prs/pkg/ghpr"
+	aw "github.com/deanishe/awgo"
+	"github.com/google/go-github/v48/github"
+)
+
+// enterpriseFeature is a feature of the workflow, which GitHub Enterprise
+// instances support since the version.
+type enterpriseFeature struct {
+	name       string
+	minVersion string
+}
+
+// Features, which older GitHub Enterprise instances do not support.
+var (
+	featureGraphQL           = &enterpriseFeature{"GraphQL API", "2.10"}
+	featureDraftToggle       = &enterpriseFeature{"converting pull requests to drafts", "3.2"}
+	featureFineGrainedTokens = &enterpriseFeature{"fine-grained tokens", "3.10"}
+
+	enterpriseFeatures = []*enterpriseFeature{featureGraphQL, featureDraftToggle, featureFineGrainedTokens}
+)
+
+// instanceHost is the host of the GitHub instance, by which its version is cached.
+func (wf *GithubWorkflow) instanceHost() string {
+	u, err := url.Parse(wf.GetBaseWebUrl())
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// isEnterprise reports whether the workflow is configured for a GitHub Enterprise instance.
+func (wf *GithubWorkflow) isEnterprise() bool {
+	return wf.GetBaseWebUrl() != "https://github.com"
+}
+
+// fetchInstanceVersion gets the installed version of the GitHub Enterprise instance
+// from its meta endpoint, unless it is cached already. For github.com, which is
+// always up to date, and has no version, it returns an empty string.
+func (wf *GithubWorkflow) fetchInstanceVersion(ctx context.Context, client *github.Client) (string, error) {
+	if !wf.isEnterprise() {
+		return "", nil
+	}
+
+	return wf.state.LoadOrStoreInstanceVersion(wf.instanceHost(), instanceVersionMaxAge, func() (string, error) {
+		req, err := client.NewRequest("GET", "meta", nil)
+		if err != nil {
+			return "", err
+		}
+
+		var meta struct {
+			InstalledVersion string `json:"installed_version"`
+		}
+		if _, err = client.Do(ctx, req, &meta); err != nil {
+			return "", err
+		}
+		return meta.InstalledVersion, nil
+	})
+}
+
+// instanceVersion returns the cached version of the GitHub Enterprise instance,
+// or an empty string if it is not known (or the instance is github.com).
+func (wf *GithubWorkflow) instanceVersion() string {
+	if !wf.isEnterprise() {
+		return ""
+	}
+
+	version, err := wf.state.LoadInstanceVersion(wf.instanceHost())
+	if err != nil {
+		log.Println("failed to load instance version:", err)
+	}
+	return version
+}
+
+// supports reports whether the GitHub instance supports the feature. Since features
+// are only disabled for instances, which are known to be too old, the ones
+// with an unknown version are assumed to support everything.
+func (wf *GithubWorkflow) supports(feature *enterpriseFeature) bool {
+	version := wf.instanceVersion()
+	return version == "" || compareVersions(version, feature.minVersion) >= 0
+}
+
+// requireFeatures returns an error, if the GitHub instance does not support any of the features.
+func (wf *GithubWorkflow) requireFeatures(features ...*enterpriseFeature) error {
+	for _, feature := range features {
+		if !wf.supports(feature) {
+			return &alfredError{
+				"GitHub Enterprise " + wf.instanceVersion() + " does not support " + feature.name,
+				"it is supported since version " + feature.minVersion,
+			}
+		}
+	}
+	return nil
+}
+
+// showInstanceItem adds the version of the GitHub Enterprise instance to the diagnostics,
+// along with the features, which it does not support.
+func (wf *GithubWorkflow) showInstanceItem(ctx context.Context, client *github.Client) {
+	version, err := wf.fetchInstanceVersion(ctx, client)
+	if err != nil {
+		log.Println("failed to fetch instance version:", err)
+		return
+	}
+	if version == "" {
+		return
+	}
+
+	var unsupported []string
+	for _, feature := range enterpriseFeatures {
+		if compareVersions(version, feature.minVersion) < 0 {
+			unsupported = append(unsupported, fmt.Sprintf("%s (%s+)", feature.name, feature.minVersion))
+		}
+	}
+
+	subtitle := "all features of the workflow are supported"
+	icon := aw.IconInfo
+	if len(unsupported) > 0 {
+		subtitle, icon = "not supported: "+strings.Join(unsupported, ", "), aw.IconWarning
+	}
+
+	wf.NewItem("GitHub Enterprise " + version).
+		Subtitle(subtitle).
+		Valid(false).
+		Icon(icon)
+}
+
+// compareVersions compares dotted versions, like 3.9.2 and 3.10, number by number,
+// and returns -1, 0 or 1, like strings.Compare. Missing or invalid numbers count as 0.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// headBranches fetches head branches of the pull requests, if the GitHub instance supports GraphQL API.
+func (wf *GithubWorkflow) headBranches(ctx context.Context, client *github.Client, prs []*github.Issue) (map[int64]string, error) {
+	if err := wf.requireFeatures(featureGraphQL); err != nil {
+		return nil, err
+	}
+	return ghpr.HeadBranches(ctx, client, prs)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// storeInstanceVersion caches the version of GitHub Enterprise instance, as if it was fetched.
+func storeInstanceVersion(t *testing.T, wf *GithubWorkflow, host, version string) {
+	_, err := wf.state.LoadOrStoreInstanceVersion(host, time.Nanosecond, func() (string, error) {
+		return version, nil
+	})
+	assert.Nil(t, err)
+}
+
+func TestCompareVersions(t *testing.T) {
+	assert.Equal(t, 0, compareVersions("3.10", "3.10.0"))
+	assert.Equal(t, -1, compareVersions("3.9.4", "3.10"))
+	assert.Equal(t, 1, compareVersions("3.10.1", "3.10"))
+	assert.Equal(t, 1, compareVersions("4.0", "3.10"))
+	assert.Equal(t, -1, compareVersions("2.22.5", "3.2"))
+}
+
+func TestSupports(t *testing.T) {
+	wf := newTestWorkflow(t)
+
+	wf.GitApiUrl = "https://api.github.com"
+	assert.True(t, wf.supports(featureDraftToggle))
+
+	// the version is unknown yet
+	wf.GitApiUrl = "https://ghe.mycorp.com/api/v3/"
+	assert.True(t, wf.supports(featureDraftToggle))
+	assert.Nil(t, wf.requireFeatures(featureGraphQL, featureDraftToggle))
+
+	storeInstanceVersion(t, wf, "ghe.mycorp.com", "3.1.7")
+	assert.True(t, wf.supports(featureGraphQL))
+	assert.False(t, wf.supports(featureDraftToggle))
+	assert.Equal(t,
+		&alfredError{"GitHub Enterprise 3.1.7 does not support converting pull requests to drafts", "it is supported since version 3.2"},
+		wf.requireFeatures(featureGraphQL, featureDraftToggle))
+
+	_, err := wf.headBranches(context.Background(), nil, nil)
+	assert.Nil(t, err)
+
+	storeInstanceVersion(t, wf, "ghe.mycorp.com", "2.9.0")
+	_, err = wf.headBranches(context.Background(), nil, nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// apiProbeTimeout limits how long probing the API endpoint of a GitHub Enterprise host takes.
+const apiProbeTimeout = 5 * time.Second
+
+// resolveApiUrl returns the API url of the GitHub host. GitHub Enterprise instances
+// serve the API either from '/api/v3/' on the web host, or from an 'api.' subdomain,
+// so unless the url already tells which (like 'https://ghe.mycorp.com/api/v3'),
+// the instance is probed, and the result is cached, so that it is done only once.
+// If the API is not found (or the host cannot be reached), '/api/v3/' is cached
+// instead, so that the host is not probed on every action. Bare hosts were mapped
+// to their 'api.' subdomain before, so that is kept for users cached from there.
+func (wf *GithubWorkflow) resolveApiUrl(raw string, host *githubHost) string {
+	if !isBareEnterpriseHost(raw, host) {
+		return host.ApiUrl
+	}
+
+	web, _ := url.Parse(host.WebUrl)
+	apiUrl, err := wf.state.LoadOrStoreApiEndpoint(web.Host, apiEndpointMaxAge, func() (string, error) {
+		// bare hosts used to be mapped to the 'api.' subdomain, which is kept,
+		// if the cached user shows that it worked
+		legacyUrl := web.Scheme + "://api." + web.Host
+		if user, err := wf.state.LoadUser(); err == nil && strings.HasPrefix(user.GetURL(), legacyUrl+"/") {
+			return legacyUrl, nil
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), apiProbeTimeout)
+		defer cancel()
+
+		apiUrl, err := probeApiUrl(ctx, &http.Client{Transport: wf.base}, host)
+		if err != nil {
+			log.Println("failed to probe API url:", err)
+			apiUrl = host.ApiUrl
+		}
+		log.Printf("Using API url %s for %s", apiUrl, web.Host)
+		return apiUrl, nil
+	})
+	if err != nil {
+		log.Println("failed to cache API url:", err)
+		return host.ApiUrl
+	}
+	return apiUrl
+}
+
+// isBareEnterpriseHost reports whether the url is just the (web) host of a GitHub
+// Enterprise instance, like 'ghe.mycorp.com', which does not tell where the API is.
+func isBareEnterpriseHost(raw string, host *githubHost) bool {
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || strings.Trim(u.Path, "/") != "" {
+		return false
+	}
+
+	hostname := strings.ToLower(u.Hostname())
+	return host.WebUrl != "https://github.com" && !strings.HasPrefix(hostname, "api.") && net.ParseIP(hostname) == nil
+}
+
+// probeApiUrl finds out whether the GitHub Enterprise instance serves the API from
+// '/api/v3/', or from an 'api.' subdomain, by requesting its meta endpoint, which
+// needs no authentication (unless the instance is in private mode, which denies
+// the request, but still tells the API is there).
+func probeApiUrl(ctx context.Context, client *http.Client, host *githubHost) (string, error) {
+	web, err := url.Parse(host.WebUrl)
+	if err != nil {
+		return "", err
+	}
+
+	var lastErr error
+	for _, apiUrl := range []string{host.ApiUrl, web.Scheme + "://api." + web.Host} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(apiUrl, "/")+"/meta", nil)
+		if err != nil {
+			return "", err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+
+		switch resp.StatusCode {
+		case http.StatusOK, http.StatusUnauthorized, http.StatusForbidden:
+			log.Println("Found GitHub API at", apiUrl)
+			return apiUrl, nil
+		}
+	}
+
+	if lastErr != nil {
+		return "", lastErr
+	}
+	return "", &alfredError{"cannot find GitHub API at " + host.WebUrl, "set GIT_API_URL, like https://ghe.mycorp.com/api/v3"}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v48/github"
+	"github.com/stretchr/testify/assert"
+)
+
+// hostTransport answers requests with the status code by host, or fails them for unknown hosts.
+type hostTransport map[string]int
+
+func (t hostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	code, ok := t[req.URL.Host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return &http.Response{StatusCode: code, Body: http.NoBody, Request: req}, nil
+}
+
+func TestIsBareEnterpriseHost(t *testing.T) {
+	data := []struct {
+		input string
+		bare  bool
+	}{
+		{"ghe.mycorp.com", true},
+		{"https://ghe.mycorp.com/", true},
+		{"github.com", false},
+		{"api.ghe.mycorp.com", false},
+		{"https://ghe.mycorp.com/api/v3", false},
+		{"http://127.0.0.1:8080", false},
+	}
+
+	for _, testcase := range data {
+		host, err := parseGithubHost(testcase.input)
+		assert.Nil(t, err)
+		assert.Equal(t, testcase.bare, isBareEnterpriseHost(testcase.input, host), testcase.input)
+	}
+}
+
+func TestProbeApiUrl(t *testing.T) {
+	host, err := parseGithubHost("ghe.mycorp.com")
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	client := &http.Client{Transport: hostTransport{"ghe.mycorp.com": http.StatusOK}}
+	apiUrl, err := probeApiUrl(ctx, client, host)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://ghe.mycorp.com/api/v3/", apiUrl)
+
+	client.Transport = hostTransport{"ghe.mycorp.com": http.StatusNotFound, "api.ghe.mycorp.com": http.StatusOK}
+	apiUrl, err = probeApiUrl(ctx, client, host)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://api.ghe.mycorp.com", apiUrl)
+
+	// instances in private mode deny anonymous requests
+	client.Transport = hostTransport{"ghe.mycorp.com": http.StatusUnauthorized}
+	apiUrl, err = probeApiUrl(ctx, client, host)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://ghe.mycorp.com/api/v3/", apiUrl)
+
+	client.Transport = hostTransport{"ghe.mycorp.com": http.StatusNotFound, "api.ghe.mycorp.com": http.StatusNotFound}
+	_, err = probeApiUrl(ctx, client, host)
+	assert.Equal(t, &alfredError{"cannot find GitHub API at https://ghe.mycorp.com", "set GIT_API_URL, like https://ghe.mycorp.com/api/v3"}, err)
+}
+
+func TestResolveApiUrlCachesFallback(t *testing.T) {
+	wf := newTestWorkflow(t)
+	wf.base.once.Do(func() {})
+	wf.base.transport = hostTransport{}
+
+	host, err := parseGithubHost("ghe.mycorp.com")
+	assert.Nil(t, err)
+
+	// the host cannot be reached, so the fallback is cached
+	assert.Equal(t, "https://ghe.mycorp.com/api/v3/", wf.resolveApiUrl("ghe.mycorp.com", host))
+
+	wf.base.transport = hostTransport{"ghe.mycorp.com": http.StatusNotFound, "api.ghe.mycorp.com": http.StatusOK}
+	assert.Equal(t, "https://ghe.mycorp.com/api/v3/", wf.resolveApiUrl("ghe.mycorp.com", host))
+}
+
+func TestResolveApiUrlKeepsLegacySubdomain(t *testing.T) {
+	wf := newTestWorkflow(t)
+	wf.base.once.Do(func() {})
+	wf.base.transport = hostTransport{}
+
+	_, err := wf.state.LoadOrStoreUser(func() (*github.User, error) {
+		return &github.User{Login: github.String("testuser"), URL: github.String("https://api.ghe.mycorp.com/users/testuser")}, nil
+	})
+	assert.Nil(t, err)
+
+	host, err := parseGithubHost("ghe.mycorp.com")
+	assert.Nil(t, err)
+
+	// the user was fetched from the 'api.' subdomain, so it is not probed
+	assert.Equal(t, "https://api.ghe.mycorp.com", wf.resolveApiUrl("ghe.mycorp.com", host))
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	aw "github.com/deanishe/awgo"
+	kc "github.com/deanishe/awgo/keychain"
+)
+
+// AlfredMessage is a two-part message which can be
+// displayed by Alfred as title and subtitle.
+type AlfredMessage interface {
+	Parts() (title, subtitle string)
+}
+
+// alfredError stores the error message
+// as title and subtitle.
+type alfredError struct {
+	title, subtitle string
+}
+
+func (e *alfredError) Error() string {
+	return e.title + "\n" + e.subtitle
+}
+
+func (e *alfredError) Parts() (string, string) {
+	return e.title, e.subtitle
+}
+
+// makeAlfredError creates an alfredError from any error
+// by splitting its message into two parts.
+func makeAlfredError(e error) *alfredError {
+	msg := e.Error()
+	idx := strings.LastIndex(msg, ": ")
+
+	if len(msg) < 60 || idx < 0 {
+		return &alfredError{msg, ""}
+	}
+
+	return &alfredError{msg[idx+2:], msg[:idx]}
+}
+
+// toAlfredMessage converts any error to a two-part message. Requests rejected
+// by the secondary rate limit of GitHub get a friendly message.
+func toAlfredMessage(e error) AlfredMessage {
+	if retryAfter, ok := isSlowDown(e); ok {
+		return &alfredError{slowDownTitle, "too many requests, try again in " + formatRetryIn(retryAfter)}
+	}
+	if isSSORequired(e) != "" {
+		return &alfredError{ssoTitle, "an organization requires it, authorize the token in your GitHub settings"}
+	}
+
+	am, ok := e.(AlfredMessage)
+	if !ok {
+		am = makeAlfredError(e)
+	}
+	return am
+}
+
+// FatalError overrides the default workflow handling of errors.
+func (wf *GithubWorkflow) FatalError(e error) {
+	title, subtitle := toAlfredMessage(e).Parts()
+
+	wf.Feedback.Clear()
+	wf.NewItem(title).
+		Subtitle(subtitle).
+		Valid(false).
+		Icon(aw.IconError)
+	wf.SendFeedback()
+
+	log.Printf("[ERROR] %s", e.Error())
+}
+
+// ShowEmptyState explains why no pull requests were found: it lists
+// the active filters, and suggests to broaden the roles or to run
+// the searches in the browser (either one by one, or all at once,
+// by holding ⌘ on the header item).
+func (wf *GithubWorkflow) ShowEmptyState(keys keyBindings) {
+	header := wf.NewItem("No pull requests were found :(").
+		Subtitle("searched by " + describeFilters(wf.RoleFilters, wf.teams())).
+		Valid(false).
+		Icon(aw.IconInfo)
+
+	if len(wf.RoleFilters) < len(availableRoles) {
+		wf.NewItem("Search by all roles").
+			Subtitle("include pull requests where you have any role").
+			Valid(true).
+			Var(fbActionKey, actionBroadenRoles)
+	}
+
+	if user, err := wf.state.LoadUser(); err == nil {
+		if combined := wf.combinedSearchQuery(user); combined != "" {
+			keys.add(header, modOpenSearch).
+				Arg(searchWebUrl(wf.GetBaseWebUrl(), combined)).
+				Valid(true)
+		}
+
+		for _, query := range wf.searchQueries(user) {
+			wf.NewItem("Search on GitHub").
+				Subtitle(query).
+				Arg(searchWebUrl(wf.GetBaseWebUrl(), query)).
+				Valid(true).
+				Icon(aw.IconWeb)
+		}
+	}
+}
+
+// showOverflow adds the item to see all pull requests on GitHub, if more of them
+// were found than MAX_ITEMS allows to list. They are counted as GitHub finds them
+// (or as they are cached, if they could not be counted), less the shown ones,
+// since snoozed and filtered out pull requests are not listed either.
+func (wf *GithubWorkflow) showOverflow(shown int) {
+	count, err := wf.prs.CountPRs()
+	if err != nil || count <= wf.MaxItems {
+		return
+	}
+	if total, err := wf.prs.LoadSearchTotal(); err == nil {
+		count = total
+	}
+	if count <= shown {
+		return
+	}
+
+	user, err := wf.state.LoadUser()
+	if err != nil {
+		return
+	}
+
+	combined := wf.combinedSearchQuery(user)
+	if combined == "" {
+		return
+	}
+	wf.NewItem(fmt.Sprintf("Show all %d on GitHub…", count)).
+		Subtitle(pluralize(count-shown, "more pull request is not listed", "more pull requests are not listed")).
+		Arg(searchWebUrl(wf.GetBaseWebUrl(), combined)).
+		Valid(true).
+		Icon(aw.IconWeb)
+}
+
+// Notify sets the message which is passed to the next workflow element
+// (such as a notification) instead of feedback items.
+func (wf *GithubWorkflow) Notify(title, subtitle string) {
+	wf.notification = aw.NewArgVars().
+		Arg(subtitle).
+		Var(fbNotifyTitleKey, title)
+}
+
+// SendResult sends the notification to Alfred, if it is set,
+// or the feedback items otherwise. Nothing is sent when running from
+// the command line (like exporting), since the output is written directly.
+func (wf *GithubWorkflow) SendResult() {
+	if isCommandLine() {
+		return
+	}
+
+	if wf.notification == nil {
+		wf.SendFeedback()
+		return
+	}
+
+	if err := wf.notification.Send(); err != nil {
+		log.Printf("[ERROR] %s", err.Error())
+	}
+}
+
+// HandleError converts workflow errors to Alfred feedback items,
+// or to a notification if an action command has failed.
+func (wf *GithubWorkflow) HandleError(e error) {
+	if isCommandLine() {
+		fmt.Fprintln(os.Stderr, "error:", e.Error())
+		return
+	}
+
+	if isAction() {
+		title, subtitle := toAlfredMessage(e).Parts()
+		wf.Notify(title, subtitle)
+
+		log.Printf("[ERROR] %s", e.Error())
+		return
+	}
+
+	wf.Var(fbErrorOccurredKey, "true")
+
+	switch e {
+	case kc.ErrNotFound:
+		wf.HandleMissingToken()
+	default:
+		wf.FatalError(e)
+	}
+}
+
+// AddModifiers sets alternative actions for a pull request item,
+// which can be triggered by holding modifier keys (unless remapped
+// by the bindings). Every action must be registered in keyMap.
+func (wf *GithubWorkflow) AddModifiers(item *aw.Item, pr *prView, keys keyBindings) {
+	htmlUrl := pr.URL
+
+	keys.add(item, modCopyURL).
+		Arg(htmlUrl).
+		Var(fbActionKey, actionCopy)
+
+	keys.add(item, modOpenFiles).
+		Arg(htmlUrl + "/files")
+
+	keys.add(item, modOpenChecks).
+		Arg(htmlUrl + "/checks")
+
+	if pr.Branch != "" {
+		keys.add(item, modCopyBranch).
+			Subtitle(modCopyBranch.description+": "+pr.Branch).
+			Arg(pr.Branch).
+			Var(fbActionKey, actionCopy)
+	}
+
+	keys.add(item, modCopyCheckout).
+		Arg(checkoutCommand(pr.URL, pr.Repo, pr.Number)).
+		Var(fbActionKey, actionCopy)
+
+	// GitHub does not let authors approve their own pull requests
+	if !pr.Mine {
+		newActionModifier(item, pr, keys, modApprove, actionApprove)
+	}
+
+	if pr.Mine {
+		newActionModifier(item, pr, keys, modToggleDraft, actionToggleDraft)
+	}
+
+	if pr.NagBadge != "" {
+		newActionModifier(item, pr, keys, modNudge, actionNudge)
+	}
+
+	keys.add(item, modHandoff).
+		Arg(htmlUrl).
+		Var(fbActionKey, actionHandoff)
+
+	newActionModifier(item, pr, keys, modSnooze, actionSnooze)
+
+	if !pr.AssignedToMe {
+		newActionModifier(item, pr, keys, modAssignMe, actionAssignMe)
+	}
+}
+
+// newActionModifier creates a modifier which runs an action command
+// for the pull request, passing the pull request info as variables.
+func newActionModifier(item *aw.Item, pr *prView, keys keyBindings, mod *modifierAction, action string) *aw.Modifier {
+	return keys.add(item, mod).
+		Arg(pr.URL).
+		Var(fbActionKey, action).
+		Var(fbPullRequestIdKey, strconv.FormatInt(pr.ID, 10)).
+		Var(fbPullRequestRepoKey, pr.Repo).
+		Var(fbPullRequestNumberKey, strconv.Itoa(pr.Number))
+}
+
+// HandleMissingToken indicates to user that the API token is not set.
+func (wf *GithubWorkflow) HandleMissingToken() {
+	wf.NewWarningItem("No API key configured", "Please use ghpr-auth to set your GitHub personal token")
+
+	tokenUrl := wf.GetBaseWebUrl() + "/settings/tokens/new"
+	wf.NewItem("Generate new token on GitHub").
+		Subtitle(tokenUrl).
+		Arg(tokenUrl + "?description=go-ghpr&scopes=" + strings.Join(wf.requiredScopes(), ",")).
+		Valid(true).
+		Icon(aw.IconWeb)
+
+	if wf.supportsFineGrainedTokens() {
+		tokenUrl = wf.GetBaseWebUrl() + "/settings/personal-access-tokens/new"
+		wf.NewItem("Generate new fine-grained token on GitHub").
+			Subtitle("with read and write access to pull requests of your repositories").
+			Arg(tokenUrl).
+			Valid(true).
+			Icon(aw.IconWeb)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v48/github"
+)
+
+// avatarCacheMaxSize is the size of cached avatars, above which the least recently
+// downloaded ones are removed, in bytes.
+const avatarCacheMaxSize = 4 << 20
+
+// gcReport sums up the files removed from workflow cache.
+type gcReport struct {
+	Files int
+	Bytes int64
+}
+
+func (r *gcReport) String() string {
+	return fmt.Sprintf("reclaimed %s in %d files", formatSize(r.Bytes), r.Files)
+}
+
+// CollectGarbage compacts workflow cache, and writes how much space was reclaimed.
+func (wf *GithubWorkflow) CollectGarbage(w io.Writer) error {
+	prs, err := wf.prs.LoadPRs(0)
+	if err != nil {
+		return &alfredError{"cannot load cached pull requests", "run ghpr-update first, so that they are known"}
+	}
+
+	report, err := wf.collectGarbage(prs)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, strings.ToUpper(report.String()[:1])+report.String()[1:])
+	return err
+}
+
+// collectGarbage removes everything from workflow cache, which is no longer of use:
+// reviews and details of pull requests, which are no longer found, expired repository
+// languages and branch protections, unused responses of GitHub API, and avatars
+// of owners without pull requests (or the oldest ones, if there are too many).
+func (wf *GithubWorkflow) collectGarbage(prs []*github.Issue) (*gcReport, error) {
+	dir := wf.Cache.Dir
+
+	files, bytes, err := dirUsage(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	wf.pruneStatus(prs)
+
+	if err = wf.state.PruneExpired(wfRepoLanguageKey, repoLanguageMaxAge); err != nil {
+		log.Println("failed to prune repository languages:", err)
+	}
+	if err = wf.state.PruneExpired(wfApprovalsKey, branchProtectionMaxAge); err != nil {
+		log.Println("failed to prune branch protections:", err)
+	}
+	if err = newHTTPCache(wf.Workflow, nil).PruneCache(httpCacheMaxAge); err != nil {
+		log.Println("failed to prune http cache:", err)
+	}
+	// avatars are shared by all searches, so they are pruned by the main one only
+	if wf.savedSearch == "" {
+		if err = wf.pruneAvatars(prs); err != nil {
+			log.Println("failed to prune avatars:", err)
+		}
+	}
+
+	filesAfter, bytesAfter, err := dirUsage(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &gcReport{Files: files - filesAfter, Bytes: bytes - bytesAfter}, nil
+}
+
+// pruneAvatars removes the avatars of owners, whose repositories no longer have
+// pull requests, and then the least recently downloaded ones, while there are more
+// than avatarCacheMaxSize of them.
+func (wf *GithubWorkflow) pruneAvatars(prs []*github.Issue) error {
+	owners := make(map[string]bool)
+	for _, pr := range prs {
+		owner, _, _ := strings.Cut(parseRepoFromUrl(pr.GetHTMLURL()), "/")
+		owners[owner] = true
+	}
+
+	entries, err := os.ReadDir(filepath.Join(wf.Cache.Dir, avatarDir))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var kept []fs.FileInfo
+	var size int64
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if owners[strings.TrimSuffix(entry.Name(), ".png")] {
+			kept = append(kept, info)
+			size += info.Size()
+			continue
+		}
+		if err = os.Remove(filepath.Join(wf.Cache.Dir, avatarDir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	sort.Slice(kept, func(i, j int) bool { return kept[i].ModTime().Before(kept[j].ModTime()) })
+	for _, info := range kept {
+		if size <= avatarCacheMaxSize {
+			break
+		}
+		if err = os.Remove(filepath.Join(wf.Cache.Dir, avatarDir, info.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		size -= info.Size()
+	}
+	return nil
+}
+
+// dirUsage counts the files in the directory (and its subdirectories), and their size.
+func dirUsage(dir string) (files int, bytes int64, err error) {
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// removed meanwhile
+			return nil
+		}
+		files++
+		bytes += info.Size()
+		return nil
+	})
+	return files, bytes, err
+}
+
+// formatSize describes the size, in bytes, like 1.5 MB.
+func formatSize(bytes int64) string {
+	switch {
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(bytes)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
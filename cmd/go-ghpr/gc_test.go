@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v48/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "512 B", formatSize(512))
+	assert.Equal(t, "1.5 KB", formatSize(1536))
+	assert.Equal(t, "4.0 MB", formatSize(4<<20))
+}
+
+func TestCollectGarbage(t *testing.T) {
+	wf := newTestWorkflow(t)
+	prs := []*github.Issue{
+		{ID: github.Int64(1), HTMLURL: github.String("https://github.com/kept/repo/pull/1")},
+	}
+
+	dir := filepath.Join(wf.Cache.Dir, avatarDir)
+	assert.Nil(t, os.MkdirAll(dir, 0700))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "kept.png"), []byte("avatar"), 0600))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "gone.png"), []byte("avatar"), 0600))
+
+	assert.Nil(t, wf.Cache.Store(wfRepoLanguageKey+"kept%2Frepo", []byte(`"Go"`)))
+	expired := filepath.Join(wf.Cache.Dir, wfRepoLanguageKey+"gone%2Frepo")
+	assert.Nil(t, os.WriteFile(expired, []byte(`"Rust"`), 0600))
+	old := time.Now().Add(-2 * repoLanguageMaxAge)
+	assert.Nil(t, os.Chtimes(expired, old, old))
+
+	report, err := wf.collectGarbage(prs)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, report.Files)
+	assert.Equal(t, "reclaimed 12 B in 2 files", report.String())
+
+	assert.FileExists(t, filepath.Join(dir, "kept.png"))
+	assert.NoFileExists(t, filepath.Join(dir, "gone.png"))
+	assert.True(t, wf.Cache.Exists(wfRepoLanguageKey+"kept%2Frepo"))
+	assert.False(t, wf.Cache.Exists(wfRepoLanguageKey+"gone%2Frepo"))
+}
+
+func TestPruneAvatarsBySize(t *testing.T) {
+	wf := newTestWorkflow(t)
+	prs := []*github.Issue{
+		{HTMLURL: github.String("https://github.com/old/repo/pull/1")},
+		{HTMLURL: github.String("https://github.com/new/repo/pull/2")},
+	}
+
+	dir := filepath.Join(wf.Cache.Dir, avatarDir)
+	assert.Nil(t, os.MkdirAll(dir, 0700))
+	large := make([]byte, avatarCacheMaxSize/2+1)
+	for _, owner := range []string{"old", "new"} {
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, owner+".png"), large, 0600))
+	}
+	old := time.Now().Add(-time.Hour)
+	assert.Nil(t, os.Chtimes(filepath.Join(dir, "old.png"), old, old))
+
+	assert.Nil(t, wf.pruneAvatars(prs))
+	assert.NoFileExists(t, filepath.Join(dir, "old.png"))
+	assert.FileExists(t, filepath.Join(dir, "new.png"))
+}
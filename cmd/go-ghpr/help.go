@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+
+	aw "github.com/deanishe/awgo"
+)
+
+// paletteCommand is a workflow command, listed in the command palette.
+type paletteCommand struct {
+	keyword     string
+	description string
+}
+
+// paletteCommands are the Alfred keywords of the workflow, in the order of the README.
+var paletteCommands = []paletteCommand{
+	{"ghpr", "display your pull requests, most recently updated first"},
+	{searchViewKeyword, "search your pull requests, ranked by Alfred based on your past selections"},
+	{"ghpr-review", "request reviews on your pull request"},
+	{"ghpr-inspect", "show the state, reviews and checks of any pull request by its URL"},
+	{"ghpr-update", "manually refresh the list of pull requests"},
+	{"ghpr-keys", "list the actions available by holding modifier keys"},
+	{doctorKeyword, "check the API token and the connection to GitHub"},
+	{"ghpr-ratelimit", "show the remaining quota of GitHub API, and when it is reset"},
+	{"ghpr-whoami", "show the user and scopes of your API token, and the API endpoint"},
+	{"ghpr-recent", "show your pull requests merged or closed in the last days"},
+	{"ghpr-host", "set a custom GitHub URL"},
+	{"ghpr-auth", "set your GitHub API token"},
+}
+
+// ShowHelp lists the workflow commands with their descriptions, so that
+// the selected one is opened in Alfred. The command to export pull requests
+// from the terminal is copied instead, the configuration of the workflow
+// is opened in Alfred Preferences, and logging out is run right away.
+func (wf *GithubWorkflow) ShowHelp() error {
+	for _, command := range paletteCommands {
+		wf.NewItem(command.keyword).
+			Subtitle(command.description).
+			Arg(command.keyword).
+			Match(command.keyword+" "+command.description).
+			Valid(true).
+			Icon(aw.IconInfo).
+			Var(fbActionKey, actionOpenCommand)
+	}
+
+	wf.NewItem("--export").
+		Subtitle("copy the command to export pull requests from the terminal").
+		Arg(fmt.Sprintf("cd %q && ./go-ghpr --export --format=markdown", wf.Dir())).
+		Match("export pull requests terminal").
+		Valid(true).
+		Icon(aw.IconInfo).
+		Var(fbActionKey, actionCopy)
+
+	wf.NewItem("configure").
+		Subtitle("open the configuration of the workflow in Alfred Preferences").
+		Arg("alfredpreferences://navigateto/workflows>workflow>"+wf.BundleID()+">userconfig").
+		Match("configure settings preferences").
+		Valid(true).
+		Icon(aw.IconInfo).
+		Var(fbActionKey, "")
+
+	wf.NewItem("logout").
+		Subtitle("remove your API token from keychain, and reset all workflow data").
+		Match("logout log out reset token").
+		Valid(true).
+		Icon(aw.IconInfo).
+		Var(fbActionKey, actionLogout)
+
+	return nil
+}
+
+// OpenCommand opens the workflow command, given by its keyword, in Alfred.
+func (wf *GithubWorkflow) OpenCommand(keyword string) error {
+	for _, command := range paletteCommands {
+		if command.keyword == keyword {
+			return wf.Alfred.Search(keyword + " ")
+		}
+	}
+	return &alfredError{"unknown command: " + keyword, "use ghpr-help to list available commands"}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShowHelp(t *testing.T) {
+	// given
+	defer testWf.Feedback.Clear()
+	testWf.Feedback.Clear()
+
+	// when
+	assert.Nil(t, testWf.ShowHelp())
+
+	// then
+	items := testWf.Feedback.Items
+	assert.Equal(t, len(paletteCommands)+3, len(items))
+	assert.Equal(t, `{"title":"ghpr-doctor","subtitle":"check the API token and the connection to GitHub","arg":"ghpr-doctor","valid":true}`, marshalWithoutMods(t, items[6]))
+
+	bts, err := items[len(items)-3].MarshalJSON()
+	assert.Nil(t, err)
+	assert.Contains(t, string(bts), `./go-ghpr --export --format=markdown","valid":true`)
+	assert.Contains(t, string(bts), `"variables":{"GH_ACTION":"copy"}`)
+
+	bts, err = items[len(items)-2].MarshalJSON()
+	assert.Nil(t, err)
+	assert.Contains(t, string(bts), `"arg":"alfredpreferences://navigateto/workflows\u003eworkflow\u003e`+testWf.BundleID()+`\u003euserconfig"`)
+
+	bts, err = items[len(items)-1].MarshalJSON()
+	assert.Nil(t, err)
+	assert.Contains(t, string(bts), `"title":"logout"`)
+	assert.Contains(t, string(bts), `"variables":{"GH_ACTION":"logout"}`)
+}
+
+func TestOpenCommand(t *testing.T) {
+	assert.Error(t, testWf.OpenCommand("ghpr-unknown"))
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v48/github"
+	"golang.org/x/sync/errgroup"
+)
+
+// Events which trigger user-defined hooks.
+const (
+	eventCIFailed         = "ci_failed"
+	eventNewPRs           = "new_prs"
+	eventPRApproved       = "pr_approved"
+	eventRefreshCompleted = "refresh_completed"
+)
+
+// Hook parameters.
+const (
+	hookEventEnvKey = "GH_EVENT"
+	hookTimeout     = 10 * time.Second
+)
+
+// hookPayload is passed to the hooks as JSON on stdin.
+type hookPayload struct {
+	Event        string    `json:"event"`
+	PullRequests []*prView `json:"pull_requests"`
+}
+
+// RunHooks invokes user-defined executables for the event. Hooks are run
+// one by one, and their failures are logged instead of being returned,
+// so that a broken hook does not interrupt the workflow.
+func (wf *GithubWorkflow) RunHooks(event string, prs []*prView) {
+	if len(wf.Hooks) == 0 {
+		return
+	}
+
+	if prs == nil {
+		prs = []*prView{}
+	}
+
+	payload, err := json.Marshal(hookPayload{event, prs})
+	if err != nil {
+		log.Println("failed to prepare hook payload:", err)
+		return
+	}
+
+	for _, hook := range wf.Hooks {
+		if err := runHook(hook, event, payload); err != nil {
+			log.Printf("hook '%s' failed on event '%s': %s", hook, event, err)
+		}
+	}
+}
+
+// runHook executes a single hook with the payload on stdin.
+func runHook(hook, event string, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, hook)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(), hookEventEnvKey+"="+event)
+
+	out, err := cmd.CombinedOutput()
+	if len(out) > 0 {
+		log.Printf("hook '%s' output: %s", hook, out)
+	}
+	return err
+}
+
+// reportFailedChecks runs the hooks for pull requests, whose check runs failed on
+// their latest commit. A failure is reported once per commit, so that the hooks
+// are not run again on every status update.
+func (wf *GithubWorkflow) reportFailedChecks(ctx context.Context, client *github.Client, prs []*github.Issue, concurrency int) error {
+	reported, err := wf.state.LoadFailedChecks()
+	if err != nil {
+		return err
+	}
+	// the latest commits are known from the details of the pull requests
+	allDetails, err := wf.details.LoadDetails()
+	if err != nil {
+		return err
+	}
+
+	var mu sync.Mutex
+	commits := make(map[int64]string)
+	failed := make(map[int64]bool)
+
+	wg, ctx := errgroup.WithContext(ctx)
+	wg.SetLimit(concurrency)
+
+	for _, pr := range prs {
+		pr := pr
+		details := allDetails[pr.GetID()]
+		if details == nil || details.HeadSHA == "" {
+			continue
+		}
+		if reported[pr.GetID()] == details.HeadSHA {
+			commits[pr.GetID()] = details.HeadSHA
+			continue
+		}
+
+		wg.Go(func() error {
+			owner, repo, _ := strings.Cut(parseRepoFromUrl(pr.GetHTMLURL()), "/")
+			checks, _, err := client.Checks.ListCheckRunsForRef(ctx, owner, repo, details.HeadSHA, nil)
+			if err != nil {
+				// checks of other pull requests are still worth fetching
+				log.Printf("failed to fetch checks of PR %d, error: %s", pr.GetID(), err)
+				return nil
+			}
+			if !checksFailed(checks.CheckRuns) {
+				return nil
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			commits[pr.GetID()] = details.HeadSHA
+			failed[pr.GetID()] = true
+			return nil
+		})
+	}
+	if err = wg.Wait(); err != nil {
+		return err
+	}
+
+	// only the pull requests which are still open are remembered
+	if err = wf.state.StoreFailedChecks(commits); err != nil {
+		return err
+	}
+
+	var views []*prView
+	for _, view := range toPRViews(prs) {
+		if failed[view.ID] {
+			views = append(views, view)
+		}
+	}
+	if len(views) > 0 {
+		wf.RunHooks(eventCIFailed, views)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	kc "github.com/deanishe/awgo/keychain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunHooks(t *testing.T) {
+	// given
+	dir := t.TempDir()
+	output := filepath.Join(dir, "output")
+
+	hook := filepath.Join(dir, "hook.sh")
+	script := "#!/bin/sh\necho $GH_EVENT > " + output + "\ncat >> " + output + "\n"
+	assert.Nil(t, os.WriteFile(hook, []byte(script), 0755))
+
+	original := testWf.Hooks
+	defer func() {
+		testWf.Hooks = original
+	}()
+	testWf.Hooks = []string{filepath.Join(dir, "missing.sh"), hook}
+
+	// when
+	testWf.RunHooks(eventPRApproved, []*prView{{ID: 2, Repo: "org/repo", Number: 67}})
+
+	// then
+	actual, err := os.ReadFile(output)
+	assert.Nil(t, err)
+	assert.Equal(t, "pr_approved\n"+
+		`{"event":"pr_approved","pull_requests":[{"id":2,"title":"","repo":"org/repo","number":67,"author":"","url":"","created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}]}`,
+		string(actual))
+}
+
+func TestReportFailedChecks(t *testing.T) {
+	// given
+	url, teardown := setupFakeGitHub()
+	defer teardown()
+
+	kc.ErrNotFound = nil // effectively disable using keychain
+	defer func() {
+		kc.ErrNotFound = kcErr
+	}()
+
+	dir := t.TempDir()
+	output := filepath.Join(dir, "output")
+
+	hook := filepath.Join(dir, "hook.sh")
+	script := "#!/bin/sh\necho $GH_EVENT >> " + output + "\n"
+	assert.Nil(t, os.WriteFile(hook, []byte(script), 0755))
+
+	wf := newTestWorkflow(t)
+	wf.GitApiUrl = url
+	wf.RoleFilters = []string{"involves"}
+	assert.Nil(t, wf.FetchPRs())
+	wf.Hooks = []string{hook}
+
+	// when
+	assert.Nil(t, wf.FetchPRStatus())
+	assert.Nil(t, wf.FetchPRStatus())
+
+	// then
+	actual, err := os.ReadFile(output)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{eventCIFailed}, strings.Fields(string(actual)))
+
+	commits, err := wf.state.LoadFailedChecks()
+	assert.Nil(t, err)
+	assert.Equal(t, map[int64]string{2: "sha67"}, commits)
+}
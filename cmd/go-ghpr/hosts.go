@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/url"
+	"time"
+)
+
+// apiHost returns the host of the GitHub API url (without its 'api.' subdomain),
+// or an empty string if the url is not valid.
+func apiHost(apiUrl string) string {
+	host, err := parseGithubHost(apiUrl)
+	if err != nil {
+		return ""
+	}
+
+	u, err := url.Parse(host.WebUrl)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// hostNamespace returns the prefix of the cache keys of the GitHub host, so that pull
+// requests and state of several hosts are cached apart, and switching GIT_BASE_URL
+// back and forth does not wipe the cache of the other host. github.com keeps the keys
+// unprefixed, as they were before.
+func hostNamespace(apiUrl string) string {
+	host := apiHost(apiUrl)
+	if host == "" || host == "github.com" {
+		return ""
+	}
+	return "host-" + host + "-"
+}
+
+// useHostNamespace switches the workflow to the cache namespace of the configured host.
+func (wf *GithubWorkflow) useHostNamespace() {
+	namespace := hostNamespace(wf.GitApiUrl)
+	if namespace == wf.namespace {
+		return
+	}
+
+	store := newWorkflowStore(wf.Workflow, wf.workflowConfig, namespace)
+	wf.prs, wf.reviews, wf.details, wf.branches = store, store, store, store
+	wf.state = &hostState{StateStore: store, shared: wf.state}
+	wf.namespace = namespace
+}
+
+// hostState keeps the state of a GitHub host separately, while the state of the workflow
+// itself (like its config snapshot, or the version of its cache) is shared by all hosts.
+// API endpoints and instance versions are keyed by host already, so they are shared too.
+type hostState struct {
+	StateStore
+	shared StateStore
+}
+
+func (s *hostState) LoadConfigSnapshot() (*configSnapshot, error) {
+	return s.shared.LoadConfigSnapshot()
+}
+
+func (s *hostState) StoreConfigSnapshot(snapshot *configSnapshot) error {
+	return s.shared.StoreConfigSnapshot(snapshot)
+}
+
+func (s *hostState) LoadOrStoreApiEndpoint(host string, maxAge time.Duration, reload func() (string, error)) (string, error) {
+	return s.shared.LoadOrStoreApiEndpoint(host, maxAge, reload)
+}
+
+func (s *hostState) LoadInstanceVersion(host string) (string, error) {
+	return s.shared.LoadInstanceVersion(host)
+}
+
+func (s *hostState) LoadOrStoreInstanceVersion(host string, maxAge time.Duration, reload func() (string, error)) (string, error) {
+	return s.shared.LoadOrStoreInstanceVersion(host, maxAge, reload)
+}
+
+func (s *hostState) LoadCacheVersion() (int, error) {
+	return s.shared.LoadCacheVersion()
+}
+
+func (s *hostState) StoreCacheVersion(version int) error {
+	return s.shared.StoreCacheVersion(version)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v48/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostNamespace(t *testing.T) {
+	assert.Equal(t, "", hostNamespace("https://api.github.com"))
+	assert.Equal(t, "", hostNamespace("github.com"))
+	assert.Equal(t, "host-ghe.mycorp.com-", hostNamespace("https://ghe.mycorp.com/api/v3/"))
+	assert.Equal(t, "host-ghe.mycorp.com-", hostNamespace("https://api.ghe.mycorp.com"))
+	assert.Equal(t, "", hostNamespace("not a url"))
+}
+
+func TestUseHostNamespace(t *testing.T) {
+	wf := newTestWorkflow(t)
+	assert.Nil(t, wf.prs.StorePRs([]*github.Issue{{ID: github.Int64(1)}}))
+	assert.Nil(t, wf.state.StoreConfigSnapshot(&configSnapshot{}))
+
+	// when
+	wf.GitApiUrl = "https://ghe.mycorp.com/api/v3/"
+	wf.useHostNamespace()
+
+	// then the pull requests of the host are cached apart
+	_, err := wf.prs.CountPRs()
+	assert.Error(t, err)
+	assert.Nil(t, wf.prs.StorePRs([]*github.Issue{{ID: github.Int64(2)}, {ID: github.Int64(3)}}))
+	assert.True(t, wf.Cache.Exists("host-ghe.mycorp.com-"+wfPullRequestsKey))
+
+	// while the config snapshot is shared
+	_, err = wf.state.LoadConfigSnapshot()
+	assert.Nil(t, err)
+
+	// and saved searches are cached within the namespace of the host
+	wf.SavedSearches = map[string]string{"frontend": "org:acme label:frontend"}
+	assert.Nil(t, wf.useSavedSearch("frontend"))
+	assert.Nil(t, wf.prs.StorePRs([]*github.Issue{{ID: github.Int64(4)}}))
+	assert.True(t, wf.Cache.Exists("host-ghe.mycorp.com-saved-frontend-"+wfPullRequestsKey))
+
+	// the pull requests of github.com are kept
+	count, err := newWorkflowStore(wf.Workflow, wf.workflowConfig, "").CountPRs()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+}
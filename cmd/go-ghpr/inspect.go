@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/AndreyBozhko/go-alfred-prs/pkg/ghpr"
+	aw "github.com/deanishe/awgo"
+	"github.com/google/go-github/v48/github"
+	"golang.org/x/sync/errgroup"
+)
+
+// Inspect shows the details of any pull request given by its URL
+// (for example, passed to the workflow as Alfred Universal Action):
+// its title and state, the latest reviews and the status of checks.
+func (wf *GithubWorkflow) Inspect(rawUrl string) error {
+	ctx := context.Background()
+
+	owner, repo, number, err := parsePullRequestUrl(rawUrl)
+	if err != nil {
+		return err
+	}
+
+	client, err := wf.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	pr, _, err := client.PullRequests.Get(ctx, owner, repo, number)
+	if err != nil {
+		return err
+	}
+
+	var reviews []*github.PullRequestReview
+	var checks *github.ListCheckRunsResults
+
+	wg, groupCtx := errgroup.WithContext(ctx)
+	wg.Go(func() error {
+		var err error
+		reviews, err = ghpr.ListReviews(groupCtx, client, owner, repo, number)
+		return err
+	})
+	wg.Go(func() error {
+		var err error
+		checks, _, err = client.Checks.ListCheckRunsForRef(groupCtx, owner, repo, pr.GetHead().GetSHA(), nil)
+		return err
+	})
+	if err = wg.Wait(); err != nil {
+		return err
+	}
+
+	htmlUrl := pr.GetHTMLURL()
+	ref := fmt.Sprintf("%s/%s#%d", owner, repo, number)
+
+	wf.NewItem(pr.GetTitle()).
+		Subtitle(fmt.Sprintf("%s by %s, %s", ref, pr.GetUser().GetLogin(), pullRequestState(pr))).
+		Arg(htmlUrl).
+		Copytext(markdownLink(ref, pr.GetTitle(), htmlUrl)).
+		Valid(true)
+
+	reviewState := ghpr.ReviewState(reviews)
+	if reviewState == "" {
+		reviewState = "no reviews yet"
+	}
+	wf.NewItem("Reviews: " + reviewState).
+		Subtitle(strings.Join(reviewers(reviews), ", ")).
+		Arg(htmlUrl).
+		Valid(true)
+
+	wf.NewItem("Checks: " + summarizeChecks(checks.CheckRuns)).
+		Subtitle("open checks tab").
+		Arg(htmlUrl + "/checks").
+		Valid(true).
+		Icon(aw.IconWeb)
+
+	return nil
+}
+
+// pullRequestState describes the state of the pull request in a word.
+func pullRequestState(pr *github.PullRequest) string {
+	switch {
+	case pr.GetMerged():
+		return "merged"
+	case pr.GetDraft():
+		return "draft"
+	}
+	return pr.GetState()
+}
+
+// reviewers returns logins of users who reviewed the pull request, in order of their first review.
+func reviewers(reviews []*github.PullRequestReview) []string {
+	result := make([]string, 0)
+
+	seen := make(map[string]bool)
+	for _, review := range reviews {
+		login := review.GetUser().GetLogin()
+		if !seen[login] {
+			seen[login] = true
+			result = append(result, login)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	aw "github.com/deanishe/awgo"
+)
+
+// modifierAction is an alternative action of a feedback item,
+// which is triggered by holding the modifier keys.
+type modifierAction struct {
+	name        string
+	keys        []string
+	description string
+	// condition tells when the action is available, if not always
+	condition string
+}
+
+// keyBindings maps names of modifier actions to the keys which trigger them,
+// overriding the default keys of the actions.
+type keyBindings map[string][]string
+
+// keysOf returns the keys which trigger the action.
+func (b keyBindings) keysOf(a *modifierAction) []string {
+	if keys, ok := b[a.name]; ok {
+		return keys
+	}
+	return a.keys
+}
+
+// add sets the modifier action for the item.
+func (b keyBindings) add(item *aw.Item, a *modifierAction) *aw.Modifier {
+	return item.NewModifier(b.keysOf(a)...).Subtitle(a.description)
+}
+
+// validate checks that no two actions of the same item type are bound to the same keys.
+func (b keyBindings) validate(view string) error {
+	for _, group := range keyMap {
+		seen := make(map[string]string)
+		for _, action := range group.actions {
+			keys := append([]string{}, b.keysOf(action)...)
+			sort.Strings(keys)
+			combo := strings.Join(keys, "+")
+
+			if other, ok := seen[combo]; ok {
+				return &alfredError{
+					"conflicting key bindings: " + other + " and " + action.name,
+					fmt.Sprintf("both are bound to %s on %s in the %s view", shortcut(keys), group.itemType, view),
+				}
+			}
+			seen[combo] = action.name
+		}
+	}
+	return nil
+}
+
+// shortcut returns the modifier keys as symbols, like ⌘⇧.
+func shortcut(keys []string) string {
+	symbols := make([]string, len(keys))
+	for i, key := range keys {
+		symbols[i] = keySymbols[key]
+	}
+	return strings.Join(symbols, "")
+}
+
+// keySymbols are the symbols of modifier keys, as shown on the keyboard.
+var keySymbols = map[string]string{
+	aw.ModCmd:   "⌘",
+	aw.ModAlt:   "⌥",
+	aw.ModCtrl:  "⌃",
+	aw.ModShift: "⇧",
+	aw.ModFn:    "fn",
+}
+
+// Modifier actions of feedback items, which are listed in keyMap.
+var (
+	modCopyURL      = &modifierAction{"copy_url", []string{aw.ModCmd}, "Copy URL to clipboard", ""}
+	modOpenFiles    = &modifierAction{"open_files", []string{aw.ModAlt}, "Open files tab", ""}
+	modOpenChecks   = &modifierAction{"open_checks", []string{aw.ModCtrl}, "Open checks tab", ""}
+	modCopyBranch   = &modifierAction{"copy_branch", []string{aw.ModCmd, aw.ModAlt}, "Copy branch name", "if its head branch is known"}
+	modCopyCheckout = &modifierAction{"copy_checkout", []string{aw.ModCmd, aw.ModCtrl}, "Copy gh pr checkout command", ""}
+	modApprove      = &modifierAction{"approve", []string{aw.ModShift}, "Approve pull request", "unless the pull request is yours"}
+	modToggleDraft  = &modifierAction{"toggle_draft", []string{aw.ModFn}, "Toggle draft / ready for review", "if the pull request is yours"}
+	modNudge        = &modifierAction{"nudge", []string{aw.ModCtrl, aw.ModShift}, "Post a polite reminder for the reviewers", "if your pull request has a nag badge"}
+	modHandoff      = &modifierAction{"handoff", []string{aw.ModAlt, aw.ModCtrl}, "Continue on your phone", ""}
+	modSnooze       = &modifierAction{"snooze", []string{aw.ModAlt, aw.ModShift}, "Snooze pull request for a few days", ""}
+	modAssignMe     = &modifierAction{"assign_me", []string{aw.ModCmd, aw.ModShift}, "Assign pull request to yourself", "unless it is assigned to you"}
+	modExpandGroup  = &modifierAction{"expand_group", []string{aw.ModAlt}, "Show the pull requests", ""}
+	modOpenSearch   = &modifierAction{"open_search", []string{aw.ModCmd}, "Open the same search on GitHub", ""}
+	modOpenLog      = &modifierAction{"open_log", []string{aw.ModCmd}, "Open workflow log", ""}
+	modRunDoctor    = &modifierAction{"run_doctor", []string{aw.ModAlt}, "Run diagnostics", ""}
+)
+
+// keyMap is the registry of modifier actions, by the type of item they are set for.
+var keyMap = []struct {
+	itemType string
+	actions  []*modifierAction
+}{
+	{"pull requests", []*modifierAction{
+		modCopyURL, modOpenFiles, modOpenChecks, modCopyBranch, modCopyCheckout, modApprove,
+		modToggleDraft, modNudge, modHandoff, modSnooze, modAssignMe,
+	}},
+	{"groups of dependency updates", []*modifierAction{modExpandGroup}},
+	{"the header, if no pull requests were found", []*modifierAction{modOpenSearch}},
+	{"the status row", []*modifierAction{modOpenLog, modRunDoctor}},
+}
+
+// findModifierAction returns the registered modifier action with the name, or nil.
+func findModifierAction(name string) *modifierAction {
+	for _, group := range keyMap {
+		for _, action := range group.actions {
+			if action.name == name {
+				return action
+			}
+		}
+	}
+	return nil
+}
+
+// parseActionMap parses user-defined key bindings, given as '[view:]action=keys'
+// (like 'snooze=cmd+shift' or 'search:copy_branch=alt'), into the key bindings
+// of every view. Bindings without a view apply to all views, and later bindings
+// of an action override the earlier ones.
+func parseActionMap(entries []string) (map[string]keyBindings, error) {
+	result := map[string]keyBindings{viewSorted: {}, viewSearch: {}}
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, combo, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, &alfredError{"invalid key binding: " + entry, "expected [view:]action=keys, like snooze=cmd+shift"}
+		}
+
+		views := []string{viewSorted, viewSearch}
+		if view, action, found := strings.Cut(name, ":"); found {
+			if _, ok := result[view]; !ok {
+				return nil, &alfredError{"invalid view in key binding: " + entry, "expected one of: sorted,search"}
+			}
+			views, name = []string{view}, action
+		}
+
+		if findModifierAction(name) == nil {
+			return nil, &alfredError{"unknown action in key binding: " + entry, "use ghpr-keys to list available actions"}
+		}
+
+		keys := strings.Split(combo, "+")
+		for _, key := range keys {
+			if _, ok := keySymbols[key]; !ok {
+				return nil, &alfredError{"invalid keys in key binding: " + entry, "expected keys joined by +, out of: cmd,alt,ctrl,shift,fn"}
+			}
+		}
+
+		for _, view := range views {
+			result[view][name] = keys
+		}
+	}
+
+	for view, bindings := range result {
+		if err := bindings.validate(view); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// HelpKeys lists the modifier actions of every item type in the named view,
+// so that they are easy to discover.
+func (wf *GithubWorkflow) HelpKeys(viewName string) error {
+	view, err := wf.newFeedbackView(viewName)
+	if err != nil {
+		return err
+	}
+
+	for _, group := range keyMap {
+		for _, action := range group.actions {
+			subtitle := action.name + " on " + group.itemType
+			if action.condition != "" {
+				subtitle += ", " + action.condition
+			}
+
+			wf.NewItem(shortcut(view.Keys.keysOf(action)) + "  " + action.description).
+				Subtitle(subtitle).
+				Valid(false).
+				Icon(aw.IconInfo)
+		}
+	}
+	return nil
+}
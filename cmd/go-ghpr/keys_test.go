@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShortcut(t *testing.T) {
+	assert.Equal(t, "⌘", shortcut(modCopyURL.keys))
+	assert.Equal(t, "⌃⇧", shortcut(modNudge.keys))
+	assert.Equal(t, "fn", shortcut(modToggleDraft.keys))
+}
+
+func TestParseActionMap(t *testing.T) {
+	bindings, err := parseActionMap([]string{"snooze=cmd+alt+ctrl", " search:copy_branch=cmd+shift", "search:assign_me=alt+cmd+shift "})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]keyBindings{
+		viewSorted: {"snooze": {"cmd", "alt", "ctrl"}},
+		viewSearch: {"snooze": {"cmd", "alt", "ctrl"}, "copy_branch": {"cmd", "shift"}, "assign_me": {"alt", "cmd", "shift"}},
+	}, bindings)
+
+	bindings, err = parseActionMap(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]keyBindings{viewSorted: {}, viewSearch: {}}, bindings)
+
+	// toggling draft and opening the search are set for different items
+	_, err = parseActionMap([]string{"toggle_draft=cmd", "copy_url=fn", "open_search=fn"})
+	assert.Nil(t, err)
+
+	for _, entries := range [][]string{
+		{"snooze"},
+		{"merge=cmd"},
+		{"inbox:snooze=cmd"},
+		{"snooze=cmd+space"},
+		{"snooze=cmd"},
+		{"sorted:open_files=ctrl"},
+	} {
+		_, err = parseActionMap(entries)
+		assert.Error(t, err, entries)
+	}
+}
+
+func TestAddModifiersWithBindings(t *testing.T) {
+	pr := &prView{ID: 1, Repo: "org/repo", Number: 78, URL: "https://gh.com/org/repo/pull/78"}
+
+	item := testWf.NewItem("Title 1")
+	defer testWf.Feedback.Clear()
+
+	testWf.AddModifiers(item, pr, keyBindings{"approve": {"fn"}, "copy_url": {"cmd", "shift"}, "assign_me": {"cmd"}})
+
+	bts, err := item.MarshalJSON()
+	assert.Nil(t, err)
+
+	var actual struct {
+		Mods map[string]json.RawMessage `json:"mods"`
+	}
+	assert.Nil(t, json.Unmarshal(bts, &actual))
+
+	mods := rawToStrings(actual.Mods)
+	assert.NotContains(t, mods, "shift")
+	assert.Contains(t, mods["fn"], `"subtitle":"Approve pull request"`)
+	assert.Contains(t, mods["cmd+shift"], `"subtitle":"Copy URL to clipboard"`)
+	assert.Contains(t, mods["cmd"], `"subtitle":"Assign pull request to yourself"`)
+}
+
+func TestHelpKeys(t *testing.T) {
+	// given
+	defer func() {
+		testWf.ActionMap = nil
+		testWf.Feedback.Clear()
+	}()
+
+	testWf.Feedback.Clear()
+	testWf.ActionMap = []string{"search:run_doctor=ctrl"}
+
+	count := 0
+	for _, group := range keyMap {
+		count += len(group.actions)
+	}
+
+	// when
+	assert.Nil(t, testWf.HelpKeys(viewSorted))
+	assert.Nil(t, testWf.HelpKeys(viewSearch))
+
+	// then
+	items := testWf.Feedback.Items
+	assert.Equal(t, 2*count, len(items))
+	assert.Equal(t, `{"title":"⌘  Copy URL to clipboard","subtitle":"copy_url on pull requests","arg":"","valid":false}`, marshalWithoutMods(t, items[0]))
+	assert.Equal(t, `{"title":"fn  Toggle draft / ready for review","subtitle":"toggle_draft on pull requests, if the pull request is yours","arg":"","valid":false}`, marshalWithoutMods(t, items[6]))
+	assert.Equal(t, `{"title":"⌥  Run diagnostics","subtitle":"run_doctor on the status row","arg":"","valid":false}`, marshalWithoutMods(t, items[count-1]))
+	assert.Equal(t, `{"title":"⌃  Run diagnostics","subtitle":"run_doctor on the status row","arg":"","valid":false}`, marshalWithoutMods(t, items[2*count-1]))
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	kc "github.com/deanishe/awgo/keychain"
+)
+
+// Logout removes the API token (and the minted one) from user's keychain, along
+// with all cached and stored data, so that the workflow can be set up from scratch.
+// It runs before the configuration is loaded, so the token of the host is looked up
+// by GIT_BASE_URL (or GIT_API_URL) at run time.
+func (wf *GithubWorkflow) Logout() error {
+	keys := []string{wf.tokenKey(), wfAuthTokenKey, wfMintedTokenKey}
+	for _, name := range []string{"GIT_BASE_URL", "GIT_API_URL"} {
+		if host := apiHost(os.Getenv(name)); host != "" {
+			keys = append(keys, wfAuthTokenKey+"@"+host)
+		}
+	}
+
+	for _, key := range keys {
+		if err := wf.Keychain.Delete(key); err != nil && err != kc.ErrNotFound {
+			return err
+		}
+	}
+	log.Println("Removed API tokens from keychain")
+
+	if err := wf.Reset(); err != nil {
+		return err
+	}
+
+	wf.Notify("Logged out", "run ghpr-auth to set a new token")
+	return nil
+}
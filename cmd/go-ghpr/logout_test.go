@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+
+	kc "github.com/deanishe/awgo/keychain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogout(t *testing.T) {
+	// given
+	t.Setenv("alfred_workflow_cache", t.TempDir())
+	t.Setenv("alfred_workflow_data", t.TempDir())
+	t.Setenv("GIT_BASE_URL", "ghe.mycorp.com")
+
+	wf := newTestWorkflow(t)
+	key := wfAuthTokenKey + "@ghe.mycorp.com"
+	defer wf.Keychain.Delete(key)
+
+	assert.Nil(t, wf.Keychain.Set(key, "ghp_host"))
+
+	// when
+	assert.Nil(t, wf.Logout())
+
+	// then
+	_, err := wf.Keychain.Get(key)
+	assert.Equal(t, kc.ErrNotFound, err)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// cacheVersion is the version of the structures of cached data. Whenever they change
+// incompatibly, the version is bumped, and a migration from the previous one is added.
+const cacheVersion = 3
+
+// cacheMigrations upgrade cached data from the version (the index of the migration)
+// to the next one. Caches without a version were written before versioning.
+// The API token in user's keychain is moved under the key of its host on first use
+// (see migrateLegacyToken), and the minted one (gh-minted-token) needs no migration.
+var cacheMigrations = []func(dir string) error{
+	// 0 → 1: reviews of all pull requests are kept in a single entry,
+	// instead of one file per pull request, named by its ID
+	removeLegacyReviews,
+	// 1 → 2: details of pull requests hold their diff size, and the organizations
+	// of requested teams, so the ones cached without them are fetched again
+	removeDetails,
+	// 2 → 3: details of all pull requests are kept in a single entry,
+	// instead of one file per pull request, so they are fetched again
+	removeDetails,
+}
+
+// migrateCache upgrades cached data written by a previous version of the workflow.
+// Data of an unknown (newer) version, or data which cannot be migrated, are cleared,
+// since all of them are fetched again from GitHub anyway.
+func (wf *GithubWorkflow) migrateCache() {
+	version, err := wf.state.LoadCacheVersion()
+	if err != nil {
+		log.Println("failed to load cache version:", err)
+		version = cacheVersion + 1
+	}
+	if version == cacheVersion {
+		return
+	}
+
+	for ; version < cacheVersion; version++ {
+		log.Printf("Migrating cache from version %d...", version)
+		if err = cacheMigrations[version](wf.Cache.Dir); err != nil {
+			log.Printf("failed to migrate cache from version %d, error: %s", version, err)
+			break
+		}
+	}
+
+	if version != cacheVersion {
+		log.Println("Clearing cache of unknown version...")
+		if err = clearDir(wf.Cache.Dir); err != nil {
+			log.Println("failed to clear cache:", err)
+			return
+		}
+	}
+
+	if err = wf.state.StoreCacheVersion(cacheVersion); err != nil {
+		log.Println("failed to store cache version:", err)
+	}
+}
+
+// removeLegacyReviews removes the reviews cached per pull request.
+func removeLegacyReviews(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if _, err := strconv.ParseInt(entry.Name(), 10, 64); err != nil {
+			continue
+		}
+		if err = os.Remove(filepath.Join(dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeDetails removes the cached details of pull requests (of all the saved searches, too).
+func removeDetails(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !strings.Contains(entry.Name(), wfDetailsKey) {
+			continue
+		}
+		if err = os.Remove(filepath.Join(dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// clearDir removes everything in the directory, but not the directory itself.
+func clearDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err = os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
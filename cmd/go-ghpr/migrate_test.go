@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v48/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateCache(t *testing.T) {
+	wf := newTestWorkflow(t)
+
+	// reviews cached per pull request, before versioning
+	assert.Nil(t, wf.Cache.StoreJSON("12", []*github.PullRequestReview{}))
+	assert.Nil(t, wf.branches.StoreBranches(map[int64]string{12: "feature"}))
+	assert.Nil(t, wf.Cache.StoreJSON("gh-details-12", &prDetails{BaseBranch: "main"}))
+	assert.Nil(t, wf.Cache.StoreJSON("saved-frontend-gh-details-12", &prDetails{BaseBranch: "main"}))
+
+	wf.migrateCache()
+
+	version, err := wf.state.LoadCacheVersion()
+	assert.Nil(t, err)
+	assert.Equal(t, cacheVersion, version)
+	assert.False(t, wf.Cache.Exists("12"))
+	assert.False(t, wf.Cache.Exists("gh-details-12"))
+	assert.False(t, wf.Cache.Exists("saved-frontend-gh-details-12"))
+	assert.True(t, wf.Cache.Exists(wfBranchesKey))
+
+	// nothing is migrated again
+	assert.Nil(t, wf.Cache.StoreJSON("12", []*github.PullRequestReview{}))
+	wf.migrateCache()
+	assert.True(t, wf.Cache.Exists("12"))
+}
+
+func TestMigrateCacheDetails(t *testing.T) {
+	wf := newTestWorkflow(t)
+
+	// details cached per pull request, before version 3
+	assert.Nil(t, wf.state.StoreCacheVersion(2))
+	assert.Nil(t, wf.Cache.StoreJSON("gh-details-12", &prDetails{BaseBranch: "main"}))
+	assert.Nil(t, wf.reviews.UpdateReviews(12, nil))
+
+	wf.migrateCache()
+
+	assert.False(t, wf.Cache.Exists("gh-details-12"))
+	assert.True(t, wf.Cache.Exists(wfReviewsKey))
+
+	details, err := wf.details.LoadDetails()
+	assert.Nil(t, err)
+	assert.Empty(t, details)
+}
+
+func TestMigrateCacheUnknownVersion(t *testing.T) {
+	wf := newTestWorkflow(t)
+
+	assert.Nil(t, wf.state.StoreCacheVersion(cacheVersion+1))
+	assert.Nil(t, wf.branches.StoreBranches(map[int64]string{12: "feature"}))
+
+	wf.migrateCache()
+
+	version, err := wf.state.LoadCacheVersion()
+	assert.Nil(t, err)
+	assert.Equal(t, cacheVersion, version)
+	assert.False(t, wf.Cache.Exists(wfBranchesKey))
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	aw "github.com/deanishe/awgo"
+	"github.com/google/go-github/v48/github"
+)
+
+// rateLimitReserve is the number of API calls, which are left for the refresh of
+// pull requests and for actions, once the rate limit gets low.
+const rateLimitReserve = 100
+
+// statusCallsPerPR is the (rough) number of API calls, which the update of the status
+// of a pull request takes: its details, reviews, and maybe branch protection or activity.
+const statusCallsPerPR = 3
+
+// rateLimit is the state of the core rate limit of GitHub API, as of the last response.
+type rateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+// low reports whether the rate limit does not allow for the calls (and the reserve)
+// until it is reset.
+func (r *rateLimit) low(calls int) bool {
+	return r != nil && time.Now().Before(r.Reset) && r.Remaining < calls+rateLimitReserve
+}
+
+func (r *rateLimit) String() string {
+	return fmt.Sprintf("%d of %d API calls left, reset in %dm", r.Remaining, r.Limit, int(time.Until(r.Reset).Minutes())+1)
+}
+
+// rateLimitTransport observes the rate limit headers of GitHub API responses,
+// and keeps the latest state of the core rate limit.
+type rateLimitTransport struct {
+	base   http.RoundTripper
+	mu     sync.Mutex
+	latest *rateLimit
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	// search has a separate (per-minute) rate limit, which is not tracked
+	if resource := resp.Header.Get("X-RateLimit-Resource"); resource != "" && resource != "core" {
+		return resp, nil
+	}
+
+	if rate := parseRateLimit(resp.Header); rate != nil {
+		t.mu.Lock()
+		t.latest = rate
+		t.mu.Unlock()
+	}
+	return resp, nil
+}
+
+// observed returns the latest state of the rate limit, or nil if none was observed.
+func (t *rateLimitTransport) observed() *rateLimit {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.latest
+}
+
+// parseRateLimit reads the rate limit from the response headers,
+// or returns nil if they are missing (rate limiting is disabled on some GitHub Enterprise instances).
+func parseRateLimit(header http.Header) *rateLimit {
+	limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return nil
+	}
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return nil
+	}
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &rateLimit{Limit: limit, Remaining: remaining, Reset: time.Unix(reset, 0)}
+}
+
+// storeRateLimit remembers the latest observed rate limit, so that other runs of the
+// workflow know whether it is low, and logs it.
+func (wf *GithubWorkflow) storeRateLimit() {
+	rate := wf.rates.observed()
+	if rate == nil {
+		return
+	}
+
+	log.Println("Rate limit:", rate)
+	if err := wf.state.StoreRateLimit(rate); err != nil {
+		log.Println("failed to store rate limit:", err)
+	}
+}
+
+// lowRateLimit returns the last observed rate limit (by this run of the workflow,
+// or by a previous one), if it does not allow for the calls until it is reset,
+// or nil otherwise.
+func (wf *GithubWorkflow) lowRateLimit(calls int) *rateLimit {
+	rate := wf.rates.observed()
+	if rate == nil {
+		var err error
+		if rate, err = wf.state.LoadRateLimit(); err != nil {
+			log.Println("failed to load rate limit:", err)
+			return nil
+		}
+	}
+	if !rate.low(calls) {
+		return nil
+	}
+	return rate
+}
+
+// ShowRateLimits lists the remaining quota of GitHub API for the API token,
+// and when it is reset, by resource: core API (used by actions and status updates),
+// search and GraphQL. Checking the quota does not count against it.
+func (wf *GithubWorkflow) ShowRateLimits() error {
+	ctx := context.Background()
+
+	client, err := wf.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	limits, _, err := client.RateLimits(ctx)
+	if err != nil {
+		var errResp *github.ErrorResponse
+		if errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == http.StatusNotFound {
+			return &alfredError{"Rate limiting is disabled", "this GitHub Enterprise instance does not limit API calls"}
+		}
+		return err
+	}
+
+	resources := []struct {
+		name string
+		rate *github.Rate
+	}{
+		{"Core", limits.GetCore()},
+		{"Search", limits.GetSearch()},
+		{"GraphQL", limits.GetGraphQL()},
+	}
+
+	for _, resource := range resources {
+		if resource.rate == nil {
+			continue
+		}
+
+		rate := &rateLimit{Limit: resource.rate.Limit, Remaining: resource.rate.Remaining, Reset: resource.rate.Reset.Time}
+		icon := aw.IconInfo
+		if rate.Remaining == 0 {
+			icon = aw.IconWarning
+		}
+
+		wf.NewItem(fmt.Sprintf("%s: %d of %d calls left", resource.name, rate.Remaining, rate.Limit)).
+			Subtitle(fmt.Sprintf("reset at %s (in %s)", rate.Reset.In(wf.location()).Format("15:04:05"), formatRetryIn(time.Until(rate.Reset)))).
+			Valid(false).
+			Icon(icon)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	kc "github.com/deanishe/awgo/keychain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	reset := time.Now().Add(30 * time.Minute).Truncate(time.Second)
+
+	header := http.Header{}
+	header.Set("X-RateLimit-Limit", "5000")
+	header.Set("X-RateLimit-Remaining", "42")
+	header.Set("X-RateLimit-Reset", fmt.Sprint(reset.Unix()))
+
+	rate := parseRateLimit(header)
+	assert.Equal(t, &rateLimit{Limit: 5000, Remaining: 42, Reset: reset}, rate)
+	assert.Equal(t, "42 of 5000 API calls left, reset in 30m", rate.String())
+
+	header.Del("X-RateLimit-Reset")
+	assert.Nil(t, parseRateLimit(header))
+	assert.Nil(t, parseRateLimit(http.Header{}))
+}
+
+func TestRateLimitLow(t *testing.T) {
+	soon := time.Now().Add(time.Minute)
+
+	assert.True(t, (&rateLimit{Remaining: 50, Reset: soon}).low(0))
+	assert.True(t, (&rateLimit{Remaining: 150, Reset: soon}).low(60))
+	assert.False(t, (&rateLimit{Remaining: 150, Reset: soon}).low(0))
+	// the limit is reset already
+	assert.False(t, (&rateLimit{Remaining: 0, Reset: time.Now().Add(-time.Minute)}).low(0))
+	assert.False(t, (*rateLimit)(nil).low(0))
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/search" {
+			w.Header().Set("X-RateLimit-Resource", "search")
+			w.Header().Set("X-RateLimit-Remaining", "29")
+		} else {
+			w.Header().Set("X-RateLimit-Resource", "core")
+			w.Header().Set("X-RateLimit-Remaining", "4999")
+		}
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(time.Now().Add(time.Hour).Unix()))
+	}))
+	defer server.Close()
+
+	transport := &rateLimitTransport{}
+	client := &http.Client{Transport: transport}
+	assert.Nil(t, transport.observed())
+
+	for _, path := range []string{"/user", "/search"} {
+		resp, err := client.Get(server.URL + path)
+		assert.Nil(t, err)
+		resp.Body.Close()
+	}
+
+	assert.Equal(t, 4999, transport.observed().Remaining)
+}
+
+func TestLowRateLimit(t *testing.T) {
+	defer func() {
+		testWf.Cache.Store(wfRateLimitKey, nil)
+	}()
+
+	assert.Nil(t, testWf.lowRateLimit(0))
+
+	low := &rateLimit{Limit: 5000, Remaining: 10, Reset: time.Now().Add(time.Hour)}
+	assert.Nil(t, testWf.state.StoreRateLimit(low))
+	assert.NotNil(t, testWf.lowRateLimit(0))
+
+	low.Remaining = 1000
+	assert.Nil(t, testWf.state.StoreRateLimit(low))
+	assert.Nil(t, testWf.lowRateLimit(0))
+	assert.NotNil(t, testWf.lowRateLimit(1000))
+}
+
+func TestShowRateLimits(t *testing.T) {
+	// given
+	url, teardown := setupFakeGitHub()
+	defer teardown()
+
+	testWf.GitApiUrl = url
+
+	kc.ErrNotFound = nil // effectively disable using keychain
+	defer func() {
+		kc.ErrNotFound = kcErr
+		testWf.Feedback.Clear()
+	}()
+
+	// when
+	testWf.Feedback.Clear()
+	assert.Nil(t, testWf.ShowRateLimits())
+
+	// then
+	items := testWf.Feedback.Items
+	assert.Equal(t, 2, len(items))
+	assert.Contains(t, marshalWithoutMods(t, items[0]), `"title":"Core: 4990 of 5000 calls left","subtitle":"reset at `)
+	assert.Contains(t, marshalWithoutMods(t, items[0]), ` (in 30m)","arg":"","valid":false}`)
+	assert.Contains(t, marshalWithoutMods(t, items[1]), `"title":"Search: 0 of 30 calls left"`)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	aw "github.com/deanishe/awgo"
+	"github.com/google/go-github/v48/github"
+)
+
+// defaultRecentDays is the number of days, for which recently merged and closed
+// pull requests are listed, unless configured otherwise.
+const defaultRecentDays = 7
+
+// recentPR is a pull request of the user, which was merged or closed recently.
+type recentPR struct {
+	*github.Issue
+	merged bool
+}
+
+// recentQueries create the search queries for pull requests of the user,
+// which were merged, or closed without merging, since the date.
+func recentQueries(login string, since time.Time) (merged, closed string) {
+	date := since.UTC().Format("2006-01-02")
+	return "type:pr author:" + login + " is:merged merged:>=" + date,
+		"type:pr author:" + login + " is:unmerged is:closed closed:>=" + date
+}
+
+// ShowRecent lists the pull requests of the user, which were merged or closed
+// in the last RECENT_DAYS days, most recently closed first, along with the dates
+// they were merged or closed on - handy when writing status updates or changelogs.
+func (wf *GithubWorkflow) ShowRecent() error {
+	ctx := context.Background()
+
+	client, err := wf.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	user, err := wf.loadOrFetchUser(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	days := wf.RecentDays
+	if days <= 0 {
+		days = defaultRecentDays
+	}
+
+	var prs []*recentPR
+	mergedQuery, closedQuery := recentQueries(wf.targetLogin(user), time.Now().AddDate(0, 0, -days))
+	for _, query := range []string{mergedQuery, closedQuery} {
+		result, _, err := client.Search.Issues(ctx, query, &github.SearchOptions{ListOptions: github.ListOptions{PerPage: 100}})
+		if err != nil {
+			return err
+		}
+		for _, pr := range result.Issues {
+			prs = append(prs, &recentPR{pr, query == mergedQuery})
+		}
+	}
+
+	if len(prs) == 0 {
+		wf.NewItem(fmt.Sprintf("No pull requests merged or closed in the last %d days", days)).
+			Subtitle("set RECENT_DAYS to look further back").
+			Valid(false).
+			Icon(aw.IconInfo)
+		return nil
+	}
+
+	sort.SliceStable(prs, func(i, j int) bool {
+		return prs[i].GetClosedAt().After(prs[j].GetClosedAt())
+	})
+
+	now := time.Now()
+	for _, pr := range prs {
+		state := "closed"
+		if pr.merged {
+			state = "merged"
+		}
+
+		ref := fmt.Sprintf("%s#%d", parseRepoFromUrl(pr.GetHTMLURL()), pr.GetNumber())
+		wf.NewItem(pr.GetTitle()).
+			Subtitle(fmt.Sprintf("%s %s · %s", state, formatDate(pr.GetClosedAt(), wf.DateStyle, wf.DateFormat, wf.location(), now), ref)).
+			Arg(pr.GetHTMLURL()).
+			Quicklook(pr.GetHTMLURL()).
+			Valid(true).
+			Icon(aw.IconWeb)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	kc "github.com/deanishe/awgo/keychain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecentQueries(t *testing.T) {
+	since := time.Date(2023, 1, 15, 1, 30, 0, 0, time.FixedZone("UTC+2", 2*60*60))
+
+	merged, closed := recentQueries("octocat", since)
+	assert.Equal(t, "type:pr author:octocat is:merged merged:>=2023-01-14", merged)
+	assert.Equal(t, "type:pr author:octocat is:unmerged is:closed closed:>=2023-01-14", closed)
+}
+
+func TestShowRecent(t *testing.T) {
+	// given
+	url, teardown := setupFakeGitHub()
+	defer teardown()
+
+	testWf.GitApiUrl = url
+
+	kc.ErrNotFound = nil // effectively disable using keychain
+	defer func() {
+		kc.ErrNotFound = kcErr
+		testWf.Feedback.Clear()
+		testWf.DateStyle, testWf.DateFormat = "", ""
+	}()
+
+	testWf.DateStyle, testWf.DateFormat = dateStyleAbsolute, "2006-01-02"
+
+	// when
+	testWf.Feedback.Clear()
+	assert.Nil(t, testWf.ShowRecent())
+
+	// then
+	actual := make([]string, 0)
+	for _, itm := range testWf.Feedback.Items {
+		actual = append(actual, marshalWithoutMods(t, itm))
+	}
+
+	assert.Equal(t, []string{
+		`{"title":"Merged 2","subtitle":"merged 2021-11-13 · org/repo#14","arg":"https://gh.com/org/repo/pull/14","valid":true}`,
+		`{"title":"Closed","subtitle":"closed 2021-11-12 · org/repo#13","arg":"https://gh.com/org/repo/pull/13","valid":true}`,
+		`{"title":"Merged 1","subtitle":"merged 2021-11-11 · org/repo#12","arg":"https://gh.com/org/repo/pull/12","valid":true}`,
+	}, actual)
+}
@@ -0,0 +1,708 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/AndreyBozhko/go-alfred-prs/pkg/ghpr"
+	aw "github.com/deanishe/awgo"
+	"github.com/google/go-github/v48/github"
+)
+
+// prView is a display model of a pull request, shared by all renderers.
+type prView struct {
+	ID                int64       `json:"id"`
+	Title             string      `json:"title"`
+	Repo              string      `json:"repo"`
+	Number            int         `json:"number"`
+	Author            string      `json:"author"`
+	URL               string      `json:"url"`
+	CreatedAt         time.Time   `json:"created_at"`
+	UpdatedAt         time.Time   `json:"updated_at"`
+	ReviewState       string      `json:"review_state,omitempty"`
+	Approvals         int         `json:"approvals,omitempty"`
+	RequiredApprovals int         `json:"required_approvals,omitempty"`
+	MyReview          string      `json:"my_review,omitempty"`
+	Assignees         []string    `json:"assignees,omitempty"`
+	Branch            string      `json:"branch,omitempty"`
+	BaseBranch        string      `json:"base_branch,omitempty"`
+	Diff              *diffSize   `json:"diff,omitempty"`
+	Labels            []string    `json:"labels,omitempty"`
+	Comments          int         `json:"comments,omitempty"`
+	Reviewers         []string    `json:"requested_reviewers,omitempty"`
+	Activity          []time.Time `json:"activity,omitempty"`
+	PoorDesc          bool        `json:"poor_description,omitempty"`
+	NagBadge          string      `json:"nag_badge,omitempty"`
+	MyTurn            bool        `json:"my_turn,omitempty"`
+
+	Mine            bool `json:"-"`
+	AssignedToMe    bool `json:"-"`
+	ReviewRequested bool `json:"-"`
+	Unread          bool `json:"-"`
+}
+
+// newPRView creates a display model from a pull request and its reviews.
+func newPRView(pr *github.Issue, reviews []*github.PullRequestReview) *prView {
+	var assignees []string
+	for _, user := range pr.Assignees {
+		assignees = append(assignees, user.GetLogin())
+	}
+
+	var labels []string
+	for _, label := range pr.Labels {
+		labels = append(labels, label.GetName())
+	}
+
+	return &prView{
+		ID:          pr.GetID(),
+		Title:       pr.GetTitle(),
+		Repo:        parseRepoFromUrl(pr.GetHTMLURL()),
+		Number:      pr.GetNumber(),
+		Author:      pr.GetUser().GetLogin(),
+		URL:         pr.GetHTMLURL(),
+		CreatedAt:   pr.GetCreatedAt(),
+		UpdatedAt:   pr.GetUpdatedAt(),
+		ReviewState: ghpr.ReviewState(reviews),
+		Approvals:   ghpr.Approvals(reviews),
+		Assignees:   assignees,
+		Labels:      labels,
+		Comments:    pr.GetComments(),
+	}
+}
+
+// toPRViews creates display models for pull requests, without their reviews.
+func toPRViews(prs []*github.Issue) []*prView {
+	result := make([]*prView, len(prs))
+	for i, pr := range prs {
+		result[i] = newPRView(pr, nil)
+	}
+	return result
+}
+
+// timestamp returns the creation time, or the update time of the pull request, by the date field.
+func (pr *prView) timestamp(field string) time.Time {
+	if field == dateFieldCreated {
+		return pr.CreatedAt
+	}
+	return pr.UpdatedAt
+}
+
+// String returns a short reference to the pull request, like 'org/repo#123'.
+func (pr *prView) String() string {
+	return fmt.Sprintf("%s#%d", pr.Repo, pr.Number)
+}
+
+// FullTitle returns the title of the pull request, followed by its review state
+// (as approval progress, like '1/2 approvals', if the target branch requires approvals),
+// and badges if it has been awaiting review for long, or its description needs attention.
+// Pull requests updated since they were last viewed are prefixed with a dot.
+func (pr *prView) FullTitle() string {
+	return pr.fullTitle(true)
+}
+
+// fullTitle is like FullTitle, but only shows the review state if enabled,
+// i.e. unless the review state is shown as the icon of the item instead.
+func (pr *prView) fullTitle(withReviews bool) string {
+	parts := []string{pr.Title}
+	if pr.Unread {
+		parts = append([]string{unreadBadge}, parts...)
+	}
+	reviewState := pr.ReviewState
+	if !withReviews {
+		reviewState = ""
+	}
+	if pr.RequiredApprovals > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d approvals", pr.Approvals, pr.RequiredApprovals))
+		reviewState = strings.ReplaceAll(reviewState, "✅", "")
+	}
+	if reviewState != "" {
+		parts = append(parts, reviewState)
+	}
+	if pr.NagBadge != "" {
+		parts = append(parts, pr.NagBadge)
+	}
+	if pr.PoorDesc {
+		parts = append(parts, poorDescriptionBadge)
+	}
+	return strings.TrimSpace(strings.Join(parts, " "))
+}
+
+// Icons of review states, shipped with the workflow.
+var (
+	iconApproved = &aw.Icon{Value: "icons/approved.png"}
+	iconChanges  = &aw.Icon{Value: "icons/changes.png"}
+	iconPending  = &aw.Icon{Value: "icons/pending.png"}
+)
+
+// reviewIcon returns the icon of the review state: changes requested if any
+// reviewer requested changes, approved if any reviewer approved, or pending.
+func (pr *prView) reviewIcon() *aw.Icon {
+	switch {
+	case strings.Contains(pr.ReviewState, "❌"):
+		return iconChanges
+	case strings.Contains(pr.ReviewState, "✅"):
+		return iconApproved
+	}
+	return iconPending
+}
+
+// myReviewLabels describe the states of the user's own review.
+var myReviewLabels = map[string]string{
+	"APPROVED":          "you approved",
+	"CHANGES_REQUESTED": "you requested changes",
+	"COMMENTED":         "you commented",
+}
+
+// poorDescriptionBadge marks pull requests with empty or incomplete descriptions.
+const poorDescriptionBadge = "📄⚠️"
+
+// diffSize is the size of the changes in a pull request.
+type diffSize struct {
+	Additions    int `json:"additions"`
+	Deletions    int `json:"deletions"`
+	ChangedFiles int `json:"changed_files"`
+}
+
+// String formats the diff size like '+120 −45'.
+func (d *diffSize) String() string {
+	return fmt.Sprintf("+%d −%d", d.Additions, d.Deletions)
+}
+
+// unreadBadge marks pull requests which were updated since they were last viewed.
+const unreadBadge = "•"
+
+// lastViewed remembers when pull requests were displayed in the current
+// and in the previous Alfred session (reruns and typing keep the session).
+type lastViewed struct {
+	SessionID string    `json:"session_id"`
+	Previous  time.Time `json:"previous"`
+	Current   time.Time `json:"current"`
+}
+
+// markUnread flags pull requests which were updated since they were displayed
+// in the previous Alfred session. Nothing is flagged the very first time.
+func (wf *GithubWorkflow) markUnread(prs []*prView) {
+	seen, err := wf.state.LoadLastViewed()
+	if err != nil {
+		log.Println("failed to load last viewed time:", err)
+		return
+	}
+
+	if session := wf.SessionID(); seen.SessionID != session {
+		seen = &lastViewed{SessionID: session, Previous: seen.Current, Current: time.Now()}
+		if err = wf.state.StoreLastViewed(seen); err != nil {
+			log.Println("failed to store last viewed time:", err)
+		}
+	}
+
+	if seen.Previous.IsZero() {
+		return
+	}
+	for _, pr := range prs {
+		pr.Unread = pr.UpdatedAt.After(seen.Previous)
+	}
+}
+
+// loadPRViews reads up to limit cached pull requests (or all of them, if limit is 0)
+// and their reviews,
+// marks pull requests authored by (or assigned to) the current user,
+// and adds the head and target branches, if they are known, as well as
+// the reviewers which are still requested on the user's own pull requests,
+// and the state of the user's own review on the others.
+func (wf *GithubWorkflow) loadPRViews(limit int) ([]*prView, error) {
+	prs, err := wf.prs.LoadPRs(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var login string
+	if user, err := wf.state.LoadUser(); err == nil {
+		login = wf.targetLogin(user)
+	}
+
+	branches, err := wf.branches.LoadBranches()
+	if err != nil {
+		log.Println("failed to load branches, error:", err)
+	}
+
+	var hints map[int64]bool
+	if wf.CheckDescriptions {
+		if hints, err = wf.state.LoadDescriptionHints(); err != nil {
+			log.Println("failed to load description hints, error:", err)
+		}
+	}
+
+	summaries, err := wf.reviews.LoadReviews()
+	if err != nil {
+		log.Println("failed to load reviews, error:", err)
+	}
+
+	details, err := wf.details.LoadDetails()
+	if err != nil {
+		log.Println("failed to load details, error:", err)
+	}
+
+	thresholds, err := parseNagThresholds(wf.NagThresholds)
+	if err != nil {
+		log.Println(err)
+	}
+
+	teams := wf.teams()
+
+	now := time.Now()
+	result := make([]*prView, 0, len(prs))
+	for _, pr := range prs {
+		var reviews []*github.PullRequestReview
+		if summary := summaries[pr.GetID()]; summary != nil {
+			reviews = summary.Reviews
+		}
+
+		view := newPRView(pr, reviews)
+		view.Mine = login != "" && view.Author == login
+		view.AssignedToMe = login != "" && containsString(view.Assignees, login)
+		view.Branch = branches[view.ID]
+		known := details[view.ID]
+		if known != nil {
+			view.BaseBranch = known.BaseBranch
+			view.Diff = &known.diffSize
+			view.RequiredApprovals = known.RequiredApprovals
+			view.Activity = known.Activity
+			if view.Mine {
+				view.Reviewers = known.RequestedReviewers
+			}
+			view.ReviewRequested = login != "" && !view.Mine && reviewRequested(known, login, teams)
+		}
+		view.PoorDesc = hints[view.ID]
+		if login != "" && !view.Mine {
+			view.MyReview = ghpr.UserReviewState(reviews, login)
+		}
+		if view.Mine && awaitingReview(view.Author, reviews) {
+			view.NagBadge = nagBadge(now.Sub(view.CreatedAt), thresholds)
+		}
+		if login != "" {
+			view.MyTurn = isMyTurn(view, login, known, teams)
+		}
+
+		result = append(result, view)
+	}
+
+	return result, nil
+}
+
+// Renderer presents a list of pull requests in some format.
+type Renderer interface {
+	Render(prs []*prView) error
+}
+
+// newRenderer creates a renderer for one of the export formats.
+func newRenderer(w io.Writer, format string) (Renderer, error) {
+	switch format {
+	case "json":
+		return &JSONRenderer{w}, nil
+	case "markdown":
+		return &MarkdownRenderer{w}, nil
+	case "count":
+		return &CountRenderer{w}, nil
+	}
+
+	return nil, &alfredError{"invalid format: " + format, "expected one of: json,markdown,count"}
+}
+
+// Views in which pull requests can be displayed in Alfred.
+const (
+	viewSorted = "sorted"
+	viewSearch = "search"
+)
+
+// searchViewKeyword is the Alfred keyword of the search view.
+const searchViewKeyword = "ghprs"
+
+// feedbackView configures how items are presented in a particular view.
+// Items with UIDs let Alfred learn from usage and re-rank them on its own,
+// and autocomplete lets the user refine the search with Tab.
+type feedbackView struct {
+	UIDs         bool
+	Autocomplete bool
+	Groups       bool
+	GroupBy      string
+	Keys         keyBindings
+}
+
+// newFeedbackView returns the configuration of the named view.
+// The sorted view keeps the workflow ordering, unless item UIDs are
+// explicitly enabled, and may group dependency updates or list pull requests
+// under the headers of their repositories or of their recency, while the search view
+// always lets Alfred learn, and lists all pull requests one by one.
+// Both views use the key bindings of the action map.
+func (wf *GithubWorkflow) newFeedbackView(name string) (*feedbackView, error) {
+	bindings, err := parseActionMap(wf.ActionMap)
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case viewSorted:
+		return &feedbackView{UIDs: wf.ItemUIDs, Groups: wf.GroupUpdates, GroupBy: wf.GroupBy, Keys: bindings[name]}, nil
+	case viewSearch:
+		return &feedbackView{UIDs: true, Autocomplete: true, Keys: bindings[name]}, nil
+	}
+
+	return nil, &alfredError{"invalid view: " + name, "expected one of: sorted,search"}
+}
+
+// AlfredRenderer adds pull requests to the workflow feedback,
+// skipping duplicates and presenting them according to the view.
+type AlfredRenderer struct {
+	wf   *GithubWorkflow
+	view *feedbackView
+}
+
+func (r *AlfredRenderer) Render(prs []*prView) error {
+	zone := r.wf.location()
+
+	templates, err := parseItemTemplates(r.wf.ItemTitleTemplate, r.wf.ItemSubtitleTemplate)
+	if err != nil {
+		return err
+	}
+
+	var sectionOf func(pr *prView) string
+	switch r.view.GroupBy {
+	case groupByRepo:
+		prs = sortByRepo(prs)
+		sectionOf = func(pr *prView) string { return pr.Repo }
+	case groupByTurn:
+		prs = sortByTurn(prs)
+		sectionOf = func(pr *prView) string {
+			if pr.MyTurn {
+				return sectionMyTurn
+			}
+			return sectionTheirsTurn
+		}
+	case groupByDate:
+		// pull requests are already sorted by their shown timestamp
+		labels, now := dateGroupLabels(r.wf.DateGroupLabels), time.Now()
+		sectionOf = func(pr *prView) string { return dateGroup(pr.timestamp(r.wf.DateField), now, zone, labels) }
+	}
+
+	var counts map[string]int
+	if sectionOf != nil {
+		counts = countSections(prs, sectionOf)
+	}
+
+	// groups are found once the pull requests are sorted, within each of the sections
+	var groups map[int64][]*prView
+	if r.view.Groups {
+		groups = groupDependencyUpdates(prs, sectionOf)
+	}
+
+	seen := make(map[int64]bool)
+	section := ""
+	for _, pr := range prs {
+		if seen[pr.ID] {
+			continue
+		}
+		seen[pr.ID] = true
+
+		if sectionOf != nil && sectionOf(pr) != section {
+			section = sectionOf(pr)
+			r.renderHeader(section, counts[section])
+		}
+
+		if group, ok := groups[pr.ID]; ok {
+			r.renderGroup(group)
+			for _, member := range group {
+				seen[member.ID] = true
+			}
+			continue
+		}
+
+		icons := r.wf.ReviewStyle == reviewStyleIcons
+		title, subtitle := pr.fullTitle(!icons), r.subtitle(pr, zone)
+		// the default layout is kept for the pull requests, which a template fails on
+		if templates.title != nil {
+			if text, err := executeItemTemplate(templates.title, pr); err != nil {
+				log.Println(err)
+			} else {
+				title = text
+			}
+		}
+		if templates.subtitle != nil {
+			if text, err := executeItemTemplate(templates.subtitle, pr); err != nil {
+				log.Println(err)
+			} else {
+				subtitle = text
+			}
+		}
+
+		item := r.wf.NewItem(title).
+			Subtitle(subtitle).
+			Arg(pr.URL).
+			Copytext(markdownLink(pr.String(), pr.Title, pr.URL)).
+			Largetype(pr.FullTitle()).
+			Valid(true)
+
+		switch {
+		case icons:
+			item.Icon(pr.reviewIcon())
+		case r.wf.ShowAvatars:
+			if icon := r.wf.avatarIcon(pr); icon != nil {
+				item.Icon(icon)
+			}
+		}
+
+		if r.view.UIDs {
+			item.UID(strconv.FormatInt(pr.ID, 10))
+		}
+		if r.view.Autocomplete {
+			item.Autocomplete(pr.Title)
+		}
+
+		r.wf.AddModifiers(item, pr, r.view.Keys)
+	}
+
+	return nil
+}
+
+// renderHeader adds the header of a section of pull requests (like
+// a repository), which is not actionable.
+func (r *AlfredRenderer) renderHeader(section string, count int) {
+	r.wf.NewItem(section).
+		Subtitle(pluralize(count, "pull request", "pull requests")).
+		Valid(false).
+		Icon(aw.IconInfo)
+}
+
+// itemTemplates are the user-defined templates of the title and subtitle
+// of pull request items, which replace the default layout if set.
+type itemTemplates struct {
+	title    *template.Template
+	subtitle *template.Template
+}
+
+// parseItemTemplates parses the templates of pull request items, which get
+// the same fields as the json export (like {{.Repo}}#{{.Number}} by {{.Author}}).
+// Unknown fields of pull requests are reported before any pull requests are rendered.
+func parseItemTemplates(title, subtitle string) (*itemTemplates, error) {
+	var templates itemTemplates
+	for _, t := range []struct {
+		name string
+		text string
+		tmpl **template.Template
+	}{
+		{"ITEM_TITLE_TEMPLATE", title, &templates.title},
+		{"ITEM_SUBTITLE_TEMPLATE", subtitle, &templates.subtitle},
+	} {
+		if t.text == "" {
+			continue
+		}
+
+		tmpl, err := template.New(t.name).Funcs(templateFuncs).Parse(t.text)
+		if err == nil {
+			err = checkTemplateFields(tmpl.Root)
+		}
+		if err != nil {
+			return nil, &alfredError{"invalid template in " + t.name, err.Error()}
+		}
+		*t.tmpl = tmpl
+	}
+	return &templates, nil
+}
+
+// checkTemplateFields checks that the fields used by the template (like {{.Repo}}) are
+// the fields or methods of pull requests. The ones inside range and with blocks are
+// skipped, since their dot is something else.
+func checkTemplateFields(node parse.Node) error {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		for _, n := range node.Nodes {
+			if err := checkTemplateFields(n); err != nil {
+				return err
+			}
+		}
+	case *parse.ActionNode:
+		return checkTemplateFields(node.Pipe)
+	case *parse.PipeNode:
+		for _, cmd := range node.Cmds {
+			for _, arg := range cmd.Args {
+				if err := checkTemplateFields(arg); err != nil {
+					return err
+				}
+			}
+		}
+	case *parse.IfNode:
+		if err := checkTemplateFields(node.Pipe); err != nil {
+			return err
+		}
+		if err := checkTemplateFields(node.List); err != nil {
+			return err
+		}
+		if node.ElseList != nil {
+			return checkTemplateFields(node.ElseList)
+		}
+	case *parse.RangeNode:
+		return checkTemplateFields(node.Pipe)
+	case *parse.WithNode:
+		return checkTemplateFields(node.Pipe)
+	case *parse.FieldNode:
+		view := reflect.TypeOf(&prView{})
+		if _, ok := view.Elem().FieldByName(node.Ident[0]); !ok {
+			if _, ok = view.MethodByName(node.Ident[0]); !ok {
+				return fmt.Errorf("%s is not a field of pull requests", node)
+			}
+		}
+	}
+	return nil
+}
+
+// executeItemTemplate renders the template of pull request items.
+func executeItemTemplate(tmpl *template.Template, pr *prView) (string, error) {
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, pr); err != nil {
+		return "", &alfredError{"cannot render " + pr.String() + " with " + tmpl.Name(), err.Error()}
+	}
+	return sb.String(), nil
+}
+
+// subtitle describes the pull request: its reference, author and last update,
+// followed by its target branch and diff size (if enabled), the number of comments,
+// how long it has been awaiting review, the requested reviewers (if enabled),
+// the state of the user's own review, the recent activity and the labels (if enabled).
+// The compact level of detail only keeps the reference and the author, while
+// the verbose one shows everything that is known, including the head branch.
+func (r *AlfredRenderer) subtitle(pr *prView, zone *time.Location) string {
+	subtitle := fmt.Sprintf("%s by %s", pr, pr.Author)
+	if r.wf.DetailLevel == detailCompact {
+		return subtitle
+	}
+
+	verbose := r.wf.DetailLevel == detailVerbose
+	subtitle += ", " + formatDate(pr.timestamp(r.wf.DateField), r.wf.DateStyle, r.wf.DateFormat, zone, time.Now())
+	if verbose && pr.Branch != "" {
+		subtitle += " · " + pr.Branch
+	}
+	if (verbose || r.wf.ShowTargetBranch) && pr.BaseBranch != "" {
+		subtitle += " → " + pr.BaseBranch
+	}
+	if (verbose || r.wf.ShowDiffSize) && pr.Diff != nil {
+		subtitle += " " + pr.Diff.String()
+	}
+	if pr.Comments > 0 {
+		subtitle += fmt.Sprintf(" 💬 %d", pr.Comments)
+	}
+	if pr.NagBadge != "" {
+		subtitle += ", awaiting review for " + formatWaiting(time.Since(pr.CreatedAt))
+	}
+	if (verbose || r.wf.ShowReviewers) && len(pr.Reviewers) > 0 {
+		subtitle += " · waiting on " + strings.Join(pr.Reviewers, ", ")
+	}
+	if label, ok := myReviewLabels[pr.MyReview]; ok {
+		subtitle += " · " + label
+	}
+	if (verbose || r.wf.ShowActivity) && len(pr.Activity) > 0 {
+		subtitle += " " + sparkline(pr.Activity, time.Now())
+	}
+	if (verbose || r.wf.ShowLabels) && len(pr.Labels) > 0 {
+		subtitle += " · " + strings.Join(pr.Labels, ", ")
+	}
+	return subtitle
+}
+
+// renderGroup adds a single item for a group of identical dependency updates,
+// which opens all of them at once, or lists them in the search view.
+func (r *AlfredRenderer) renderGroup(group []*prView) {
+	repos := make([]string, len(group))
+	urls := make([]string, len(group))
+	for i, pr := range group {
+		repos[i] = pr.Repo
+		urls[i] = pr.URL
+	}
+
+	title := group[0].Title
+	item := r.wf.NewItem(fmt.Sprintf("%s — %d repos", title, len(group))).
+		Subtitle(strings.Join(repos, ", ")).
+		Arg(strings.Join(urls, "\n")).
+		Valid(true).
+		Var(fbActionKey, actionOpenAll)
+
+	r.view.Keys.add(item, modExpandGroup).
+		Subtitle(fmt.Sprintf("Show the %d pull requests", len(group))).
+		Arg(title).
+		Var(fbActionKey, actionExpandGroup)
+}
+
+// JSONRenderer writes pull requests as a JSON array.
+type JSONRenderer struct {
+	w io.Writer
+}
+
+func (r *JSONRenderer) Render(prs []*prView) error {
+	enc := json.NewEncoder(r.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(prs)
+}
+
+// MarkdownRenderer writes pull requests as a list of Markdown links.
+type MarkdownRenderer struct {
+	w io.Writer
+}
+
+func (r *MarkdownRenderer) Render(prs []*prView) error {
+	for _, pr := range prs {
+		if _, err := fmt.Fprintf(r.w, "- %s by @%s\n", markdownLink(pr.String(), pr.FullTitle(), pr.URL), pr.Author); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TemplateRenderer executes a user-provided text/template with the pull requests,
+// so that any output format (a standup note, an HTML page, etc.) can be produced.
+type TemplateRenderer struct {
+	w    io.Writer
+	tmpl *template.Template
+}
+
+// templateData is passed to the export templates.
+type templateData struct {
+	PRs         []*prView
+	GeneratedAt time.Time
+}
+
+// templateFuncs are available in the export templates, in addition to the builtin ones.
+var templateFuncs = template.FuncMap{
+	"ago":          func(t time.Time) string { return formatAge(time.Since(t)) },
+	"join":         strings.Join,
+	"markdownLink": markdownLink,
+}
+
+// newTemplateRenderer creates a renderer from the template file.
+func newTemplateRenderer(w io.Writer, file string) (*TemplateRenderer, error) {
+	tmpl, err := template.New(filepath.Base(file)).Funcs(templateFuncs).ParseFiles(file)
+	if err != nil {
+		return nil, &alfredError{"invalid template: " + file, err.Error()}
+	}
+	return &TemplateRenderer{w, tmpl}, nil
+}
+
+func (r *TemplateRenderer) Render(prs []*prView) error {
+	return r.tmpl.Execute(r.w, &templateData{PRs: prs, GeneratedAt: time.Now()})
+}
+
+// CountRenderer writes the number of pull requests.
+type CountRenderer struct {
+	w io.Writer
+}
+
+func (r *CountRenderer) Render(prs []*prView) error {
+	_, err := fmt.Fprintln(r.w, len(prs))
+	return err
+}
@@ -0,0 +1,462 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v48/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func testPRViews() []*prView {
+	return []*prView{
+		{ID: 1, Title: "Title 1", Repo: "org/repo", Number: 78, Author: "aaa", URL: "https://gh.com/org/repo/pull/78", CreatedAt: time.UnixMilli(500).UTC(), UpdatedAt: time.UnixMilli(1000).UTC(), ReviewState: "✅"},
+		{ID: 2, Title: "Title 2", Repo: "org/repo", Number: 67, Author: "bbb", URL: "https://gh.com/org/repo/pull/67", CreatedAt: time.UnixMilli(500).UTC(), UpdatedAt: time.UnixMilli(2000).UTC()},
+	}
+}
+
+func TestNewPRView(t *testing.T) {
+	id, number, title, login, htmlUrl := int64(1), 78, "Title 1", "aaa", "https://gh.com/org/repo/pull/78"
+	upd := time.UnixMilli(1000)
+	state := "APPROVED"
+
+	pr := &github.Issue{ID: &id, Number: &number, Title: &title, HTMLURL: &htmlUrl, UpdatedAt: &upd, User: &github.User{Login: &login}}
+	reviews := []*github.PullRequestReview{{State: &state, User: &github.User{Login: &login}, SubmittedAt: &upd}}
+
+	assert.Equal(t, &prView{
+		ID:          1,
+		Title:       "Title 1",
+		Repo:        "org/repo",
+		Number:      78,
+		Author:      "aaa",
+		URL:         "https://gh.com/org/repo/pull/78",
+		UpdatedAt:   upd,
+		ReviewState: "✅",
+		Approvals:   1,
+	}, newPRView(pr, reviews))
+}
+
+func TestRenderers(t *testing.T) {
+	data := []struct {
+		format, expected string
+	}{
+		{"count", "2\n"},
+		{"markdown", "- [org/repo#78: Title 1 ✅](https://gh.com/org/repo/pull/78) by @aaa\n" +
+			"- [org/repo#67: Title 2](https://gh.com/org/repo/pull/67) by @bbb\n"},
+		{"json", `[
+  {
+    "id": 1,
+    "title": "Title 1",
+    "repo": "org/repo",
+    "number": 78,
+    "author": "aaa",
+    "url": "https://gh.com/org/repo/pull/78",
+    "created_at": "1970-01-01T00:00:00.5Z",
+    "updated_at": "1970-01-01T00:00:01Z",
+    "review_state": "✅"
+  },
+  {
+    "id": 2,
+    "title": "Title 2",
+    "repo": "org/repo",
+    "number": 67,
+    "author": "bbb",
+    "url": "https://gh.com/org/repo/pull/67",
+    "created_at": "1970-01-01T00:00:00.5Z",
+    "updated_at": "1970-01-01T00:00:02Z"
+  }
+]
+`},
+	}
+
+	for _, testcase := range data {
+		var buf bytes.Buffer
+
+		renderer, err := newRenderer(&buf, testcase.format)
+		assert.Nil(t, err)
+		assert.Nil(t, renderer.Render(testPRViews()))
+
+		assert.Equal(t, testcase.expected, buf.String())
+	}
+
+	_, err := newRenderer(&bytes.Buffer{}, "yaml")
+	assert.Error(t, err)
+}
+
+func TestAlfredRenderer(t *testing.T) {
+	defer testWf.Feedback.Clear()
+
+	prs := testPRViews()
+	prs = append(prs, prs[0])
+
+	assert.Nil(t, (&AlfredRenderer{testWf, &feedbackView{UIDs: true}}).Render(prs))
+	assert.Equal(t, 2, len(testWf.Feedback.Items))
+
+	for idx, uid := range []string{`"uid":"1"`, `"uid":"2"`} {
+		bts, err := testWf.Feedback.Items[idx].MarshalJSON()
+		assert.Nil(t, err)
+		assert.Contains(t, string(bts), uid)
+	}
+}
+
+func TestNewFeedbackView(t *testing.T) {
+	defer func() {
+		testWf.ItemUIDs = false
+	}()
+
+	view, err := testWf.newFeedbackView(viewSorted)
+	assert.Nil(t, err)
+	assert.Equal(t, &feedbackView{Keys: keyBindings{}}, view)
+
+	testWf.ItemUIDs = true
+	view, err = testWf.newFeedbackView(viewSorted)
+	assert.Nil(t, err)
+	assert.Equal(t, &feedbackView{UIDs: true, Keys: keyBindings{}}, view)
+
+	view, err = testWf.newFeedbackView(viewSearch)
+	assert.Nil(t, err)
+	assert.Equal(t, &feedbackView{UIDs: true, Autocomplete: true, Keys: keyBindings{}}, view)
+
+	_, err = testWf.newFeedbackView("unknown")
+	assert.Error(t, err)
+}
+
+func TestFullTitle(t *testing.T) {
+	pr := &prView{Title: "Title"}
+	assert.Equal(t, "Title", pr.FullTitle())
+
+	pr.PoorDesc = true
+	assert.Equal(t, "Title 📄⚠️", pr.FullTitle())
+
+	pr.ReviewState = "✅"
+	assert.Equal(t, "Title ✅ 📄⚠️", pr.FullTitle())
+
+	pr.NagBadge = "🔥"
+	assert.Equal(t, "Title ✅ 🔥 📄⚠️", pr.FullTitle())
+
+	pr.Unread = true
+	assert.Equal(t, "• Title ✅ 🔥 📄⚠️", pr.FullTitle())
+
+	pr.ReviewState, pr.Approvals, pr.RequiredApprovals = "✅❌", 1, 2
+	assert.Equal(t, "• Title 1/2 approvals ❌ 🔥 📄⚠️", pr.FullTitle())
+}
+
+func TestMarkUnread(t *testing.T) {
+	// given
+	seen := time.UnixMilli(1500).UTC()
+	assert.Nil(t, testWf.state.StoreLastViewed(&lastViewed{SessionID: "previous", Current: seen}))
+	defer testWf.Cache.Store(wfLastViewedKey, nil)
+
+	// when
+	prs := testPRViews()
+	testWf.markUnread(prs)
+
+	// then
+	assert.False(t, prs[0].Unread)
+	assert.True(t, prs[1].Unread)
+
+	stored, err := testWf.state.LoadLastViewed()
+	assert.Nil(t, err)
+	assert.Equal(t, testWf.SessionID(), stored.SessionID)
+	assert.Equal(t, seen, stored.Previous.UTC())
+
+	// the same session keeps the badges
+	prs = testPRViews()
+	testWf.markUnread(prs)
+	assert.True(t, prs[1].Unread)
+}
+
+func TestAlfredRendererDetails(t *testing.T) {
+	defer func() {
+		testWf.ShowDiffSize = false
+		testWf.ShowReviewers = false
+		testWf.ShowTargetBranch = false
+		testWf.Feedback.Clear()
+	}()
+
+	prs := testPRViews()[:1]
+	prs[0].BaseBranch = "release-1.4"
+	prs[0].Diff = &diffSize{Additions: 120, Deletions: 45, ChangedFiles: 3}
+	prs[0].Comments = 5
+	prs[0].Reviewers = []string{"alice", "org/core"}
+
+	testWf.Feedback.Clear()
+	testWf.ShowTargetBranch = true
+	assert.Nil(t, (&AlfredRenderer{testWf, &feedbackView{}}).Render(prs))
+	testWf.ShowDiffSize = true
+	assert.Nil(t, (&AlfredRenderer{testWf, &feedbackView{}}).Render(prs))
+	testWf.ShowReviewers = true
+	assert.Nil(t, (&AlfredRenderer{testWf, &feedbackView{}}).Render(prs))
+
+	assert.Contains(t, marshalWithoutMods(t, testWf.Feedback.Items[0]), ` → release-1.4 💬 5","arg"`)
+	assert.Contains(t, marshalWithoutMods(t, testWf.Feedback.Items[1]), ` → release-1.4 +120 −45 💬 5","arg"`)
+	assert.Contains(t, marshalWithoutMods(t, testWf.Feedback.Items[2]), ` +120 −45 💬 5 · waiting on alice, org/core","arg"`)
+}
+
+func TestAlfredRendererMyReview(t *testing.T) {
+	defer testWf.Feedback.Clear()
+
+	prs := testPRViews()
+	prs[0].MyReview = "CHANGES_REQUESTED"
+	prs[1].MyReview = "DISMISSED"
+
+	testWf.Feedback.Clear()
+	assert.Nil(t, (&AlfredRenderer{testWf, &feedbackView{}}).Render(prs))
+
+	assert.Contains(t, marshalWithoutMods(t, testWf.Feedback.Items[0]), ` · you requested changes","arg"`)
+	assert.NotContains(t, marshalWithoutMods(t, testWf.Feedback.Items[1]), ` · you`)
+}
+
+func TestAlfredRendererDetailLevel(t *testing.T) {
+	defer func() {
+		testWf.DetailLevel = ""
+		testWf.Feedback.Clear()
+	}()
+
+	prs := testPRViews()[:1]
+	prs[0].Branch = "feature"
+	prs[0].BaseBranch = "main"
+	prs[0].Diff = &diffSize{Additions: 120, Deletions: 45, ChangedFiles: 3}
+	prs[0].Labels = []string{"bug"}
+
+	testWf.Feedback.Clear()
+	for _, level := range []string{detailCompact, detailNormal, detailVerbose} {
+		testWf.DetailLevel = level
+		assert.Nil(t, (&AlfredRenderer{testWf, &feedbackView{}}).Render(prs))
+	}
+
+	assert.Contains(t, marshalWithoutMods(t, testWf.Feedback.Items[0]), `"subtitle":"org/repo#78 by aaa","arg"`)
+	assert.NotContains(t, marshalWithoutMods(t, testWf.Feedback.Items[1]), `main`)
+	assert.Contains(t, marshalWithoutMods(t, testWf.Feedback.Items[2]), ` · feature → main +120 −45 · bug","arg"`)
+}
+
+func TestAlfredRendererLabels(t *testing.T) {
+	defer func() {
+		testWf.ShowLabels = false
+		testWf.Feedback.Clear()
+	}()
+
+	prs := testPRViews()[:1]
+	prs[0].Labels = []string{"bug", "release"}
+
+	testWf.Feedback.Clear()
+	assert.Nil(t, (&AlfredRenderer{testWf, &feedbackView{}}).Render(prs))
+	testWf.ShowLabels = true
+	assert.Nil(t, (&AlfredRenderer{testWf, &feedbackView{}}).Render(prs))
+
+	assert.NotContains(t, marshalWithoutMods(t, testWf.Feedback.Items[0]), "bug")
+	assert.Contains(t, marshalWithoutMods(t, testWf.Feedback.Items[1]), `· bug, release","arg"`)
+}
+
+func TestAlfredRendererActivity(t *testing.T) {
+	defer func() {
+		testWf.ShowActivity = false
+		testWf.Feedback.Clear()
+	}()
+
+	prs := testPRViews()[:1]
+	prs[0].Activity = []time.Time{time.Now().Add(-time.Hour)}
+
+	testWf.ShowActivity = true
+	testWf.Feedback.Clear()
+	assert.Nil(t, (&AlfredRenderer{testWf, &feedbackView{}}).Render(prs))
+	assert.Contains(t, marshalWithoutMods(t, testWf.Feedback.Items[0]), ` ▁▁▁▁▁▁█","arg"`)
+}
+
+func TestAlfredRendererReviewIcons(t *testing.T) {
+	defer func() {
+		testWf.ReviewStyle = ""
+		testWf.Feedback.Clear()
+	}()
+
+	prs := testPRViews()
+	prs[1].ReviewState = "✅❌"
+
+	testWf.ReviewStyle = reviewStyleIcons
+	testWf.Feedback.Clear()
+	assert.Nil(t, (&AlfredRenderer{testWf, &feedbackView{}}).Render(prs))
+
+	assert.Contains(t, marshalWithoutMods(t, testWf.Feedback.Items[0]), `"title":"Title 1","subtitle"`)
+	for i, icon := range []string{"icons/approved.png", "icons/changes.png"} {
+		bts, err := testWf.Feedback.Items[i].MarshalJSON()
+		assert.Nil(t, err)
+		assert.Contains(t, string(bts), `"icon":{"path":"`+icon+`"}`)
+	}
+}
+
+func TestReviewIcon(t *testing.T) {
+	assert.Equal(t, iconPending, (&prView{}).reviewIcon())
+	assert.Equal(t, iconPending, (&prView{ReviewState: "+"}).reviewIcon())
+	assert.Equal(t, iconApproved, (&prView{ReviewState: "✅✅"}).reviewIcon())
+	assert.Equal(t, iconChanges, (&prView{ReviewState: "✅❌"}).reviewIcon())
+}
+
+func TestAlfredRendererTemplates(t *testing.T) {
+	defer func() {
+		testWf.ItemTitleTemplate, testWf.ItemSubtitleTemplate = "", ""
+		testWf.Feedback.Clear()
+	}()
+
+	testWf.ItemTitleTemplate = "{{.Number}}: {{.Title}} {{.ReviewState}}"
+	testWf.ItemSubtitleTemplate = `{{.Repo}} · @{{.Author}} · {{.UpdatedAt.Format "2006-01-02"}}`
+
+	testWf.Feedback.Clear()
+	assert.Nil(t, (&AlfredRenderer{testWf, &feedbackView{}}).Render(testPRViews()[:1]))
+	assert.Contains(t, marshalWithoutMods(t, testWf.Feedback.Items[0]), `"title":"78: Title 1 ✅","subtitle":"org/repo · @aaa · 1970-01-01","arg"`)
+
+	// the diff size is unknown, so the default title is shown instead
+	testWf.ItemTitleTemplate = "{{.Title}} +{{.Diff.Additions}}"
+
+	testWf.Feedback.Clear()
+	assert.Nil(t, (&AlfredRenderer{testWf, &feedbackView{}}).Render(testPRViews()[:1]))
+	assert.Contains(t, marshalWithoutMods(t, testWf.Feedback.Items[0]), `"title":"Title 1 ✅","subtitle":"org/repo · @aaa · 1970-01-01","arg"`)
+}
+
+func TestParseItemTemplates(t *testing.T) {
+	templates, err := parseItemTemplates("", "")
+	assert.Nil(t, err)
+	assert.Nil(t, templates.title)
+	assert.Nil(t, templates.subtitle)
+
+	templates, err = parseItemTemplates("{{.FullTitle}}", "by {{.Author}} {{ago .UpdatedAt}}")
+	assert.Nil(t, err)
+	assert.NotNil(t, templates.title)
+	assert.NotNil(t, templates.subtitle)
+
+	_, err = parseItemTemplates("{{.Diff.Additions}}", `{{range .Labels}}{{.}}{{end}} {{if .Mine}}{{.FullTitle}}{{end}}`)
+	assert.Nil(t, err)
+
+	_, err = parseItemTemplates("{{.Title", "")
+	assert.ErrorContains(t, err, "invalid template in ITEM_TITLE_TEMPLATE")
+
+	_, err = parseItemTemplates("", "{{.Owner}}")
+	assert.ErrorContains(t, err, "invalid template in ITEM_SUBTITLE_TEMPLATE")
+
+	_, err = parseItemTemplates("", "{{if .Mine}}{{.Onwer}}{{end}}")
+	assert.ErrorContains(t, err, "invalid template in ITEM_SUBTITLE_TEMPLATE")
+}
+
+func TestAlfredRendererGroups(t *testing.T) {
+	defer testWf.Feedback.Clear()
+
+	bump := "Bump golang.org/x/oauth2 to 0.4.0"
+	prs := []*prView{
+		{ID: 1, Title: bump, Repo: "org/a", Author: "dependabot[bot]", URL: "https://gh.com/org/a/pull/1"},
+		{ID: 2, Title: "Title 2", Repo: "org/a", Author: "bbb", URL: "https://gh.com/org/a/pull/2"},
+		{ID: 3, Title: bump, Repo: "org/b", Author: "dependabot[bot]", URL: "https://gh.com/org/b/pull/3"},
+		{ID: 4, Title: "Bump x to 1.0", Repo: "org/b", Author: "dependabot[bot]", URL: "https://gh.com/org/b/pull/4"},
+	}
+
+	assert.Nil(t, (&AlfredRenderer{testWf, &feedbackView{Groups: true}}).Render(prs))
+	assert.Equal(t, 3, len(testWf.Feedback.Items))
+
+	bts, err := testWf.Feedback.Items[0].MarshalJSON()
+	assert.Nil(t, err)
+	assert.Contains(t, string(bts), `"title":"Bump golang.org/x/oauth2 to 0.4.0 — 2 repos","subtitle":"org/a, org/b","arg":"https://gh.com/org/a/pull/1\nhttps://gh.com/org/b/pull/3"`)
+	assert.Contains(t, string(bts), `"alt":{"arg":"Bump golang.org/x/oauth2 to 0.4.0","subtitle":"Show the 2 pull requests","variables":{"GH_ACTION":"expand_group"}}`)
+
+	assert.Contains(t, marshalWithoutMods(t, testWf.Feedback.Items[1]), `"title":"Title 2"`)
+	assert.Contains(t, marshalWithoutMods(t, testWf.Feedback.Items[2]), `"title":"Bump x to 1.0"`)
+}
+
+func TestAlfredRendererByRepo(t *testing.T) {
+	defer testWf.Feedback.Clear()
+
+	prs := []*prView{
+		{ID: 1, Title: "Title 1", Repo: "org/b", Number: 1},
+		{ID: 2, Title: "Title 2", Repo: "org/a", Number: 2},
+		{ID: 3, Title: "Title 3", Repo: "org/b", Number: 3},
+	}
+
+	testWf.Feedback.Clear()
+	assert.Nil(t, (&AlfredRenderer{testWf, &feedbackView{GroupBy: groupByRepo}}).Render(prs))
+
+	titles := make([]string, len(testWf.Feedback.Items))
+	for i, item := range testWf.Feedback.Items {
+		titles[i] = marshalWithoutMods(t, item)
+	}
+	assert.Equal(t, 5, len(titles))
+	assert.Equal(t, `{"title":"org/a","subtitle":"1 pull request","arg":"","valid":false}`, titles[0])
+	assert.Contains(t, titles[1], `"title":"Title 2"`)
+	assert.Equal(t, `{"title":"org/b","subtitle":"2 pull requests","arg":"","valid":false}`, titles[2])
+	assert.Contains(t, titles[3], `"title":"Title 1"`)
+	assert.Contains(t, titles[4], `"title":"Title 3"`)
+}
+
+func TestAlfredRendererByDate(t *testing.T) {
+	defer testWf.Feedback.Clear()
+
+	prs := []*prView{
+		{ID: 1, Title: "Title 1", Repo: "org/a", Number: 1, UpdatedAt: time.Now()},
+		{ID: 2, Title: "Title 2", Repo: "org/b", Number: 2, UpdatedAt: time.Now().AddDate(0, -1, 0)},
+		{ID: 3, Title: "Title 3", Repo: "org/a", Number: 3, UpdatedAt: time.Now().AddDate(-1, 0, 0)},
+	}
+
+	testWf.Feedback.Clear()
+	assert.Nil(t, (&AlfredRenderer{testWf, &feedbackView{GroupBy: groupByDate}}).Render(prs))
+
+	assert.Equal(t, 5, len(testWf.Feedback.Items))
+	assert.Equal(t, `{"title":"Today","subtitle":"1 pull request","arg":"","valid":false}`, marshalWithoutMods(t, testWf.Feedback.Items[0]))
+	assert.Equal(t, `{"title":"Older","subtitle":"2 pull requests","arg":"","valid":false}`, marshalWithoutMods(t, testWf.Feedback.Items[2]))
+}
+
+func TestAlfredRendererByTurn(t *testing.T) {
+	defer testWf.Feedback.Clear()
+
+	prs := []*prView{
+		{ID: 1, Title: "Title 1", Repo: "org/a", Number: 1},
+		{ID: 2, Title: "Title 2", Repo: "org/b", Number: 2, MyTurn: true},
+		{ID: 3, Title: "Title 3", Repo: "org/a", Number: 3},
+	}
+
+	testWf.Feedback.Clear()
+	assert.Nil(t, (&AlfredRenderer{testWf, &feedbackView{GroupBy: groupByTurn}}).Render(prs))
+
+	assert.Equal(t, 5, len(testWf.Feedback.Items))
+	assert.Equal(t, `{"title":"Your turn","subtitle":"1 pull request","arg":"","valid":false}`, marshalWithoutMods(t, testWf.Feedback.Items[0]))
+	assert.Contains(t, marshalWithoutMods(t, testWf.Feedback.Items[1]), `"title":"Title 2"`)
+	assert.Equal(t, `{"title":"Waiting on others","subtitle":"2 pull requests","arg":"","valid":false}`, marshalWithoutMods(t, testWf.Feedback.Items[2]))
+	assert.Contains(t, marshalWithoutMods(t, testWf.Feedback.Items[3]), `"title":"Title 1"`)
+}
+
+func TestAlfredRendererGroupsWithinSections(t *testing.T) {
+	defer testWf.Feedback.Clear()
+
+	prs := []*prView{
+		{ID: 1, Title: "Bump x to 1.0", Repo: "org/a", Number: 1, Author: "dependabot[bot]"},
+		{ID: 2, Title: "Bump x to 1.0", Repo: "org/b", Number: 2, Author: "dependabot[bot]", MyTurn: true},
+		{ID: 3, Title: "Bump x to 1.0", Repo: "org/c", Number: 3, Author: "dependabot[bot]"},
+	}
+
+	testWf.Feedback.Clear()
+	assert.Nil(t, (&AlfredRenderer{testWf, &feedbackView{Groups: true, GroupBy: groupByTurn}}).Render(prs))
+
+	// the update waiting on the user is listed apart from the group of the others
+	assert.Equal(t, 4, len(testWf.Feedback.Items))
+	assert.Equal(t, `{"title":"Your turn","subtitle":"1 pull request","arg":"","valid":false}`, marshalWithoutMods(t, testWf.Feedback.Items[0]))
+	assert.Contains(t, marshalWithoutMods(t, testWf.Feedback.Items[1]), `"title":"Bump x to 1.0"`)
+	assert.Equal(t, `{"title":"Waiting on others","subtitle":"2 pull requests","arg":"","valid":false}`, marshalWithoutMods(t, testWf.Feedback.Items[2]))
+	assert.Contains(t, marshalWithoutMods(t, testWf.Feedback.Items[3]), `"title":"Bump x to 1.0 — 2 repos"`)
+}
+
+func TestTemplateRenderer(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "standup.tmpl")
+	content := `{{range .PRs}}* {{.}} {{.Title | html}} ({{join .Labels "/"}}){{"\n"}}{{end}}`
+	assert.Nil(t, os.WriteFile(file, []byte(content), 0o600))
+
+	prs := testPRViews()
+	prs[1].Title = "Fix <b>"
+	prs[1].Labels = []string{"bug", "urgent"}
+
+	var buf bytes.Buffer
+	renderer, err := newTemplateRenderer(&buf, file)
+	assert.Nil(t, err)
+	assert.Nil(t, renderer.Render(prs))
+	assert.Equal(t, "* org/repo#78 Title 1 ()\n* org/repo#67 Fix &lt;b&gt; (bug/urgent)\n", buf.String())
+
+	_, err = newTemplateRenderer(&buf, filepath.Join(t.TempDir(), "missing.tmpl"))
+	assert.Error(t, err)
+}
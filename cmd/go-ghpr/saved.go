@@ -0,0 +1,242 @@
+package main
+
+import (
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v48/github"
+)
+
+// savedSearchEnvPrefix is the prefix of numbered environment variables, which define
+// saved searches, like SAVED_SEARCH_1='frontend: org:acme label:frontend'.
+const savedSearchEnvPrefix = "SAVED_SEARCH_"
+
+// savedSearchNameRegex matches the names of saved searches, which are a part of cache keys.
+var savedSearchNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// queryPlaceholderRegex matches the placeholders in saved searches, like {user}.
+var queryPlaceholderRegex = regexp.MustCompile(`\{[^{}\s]*\}`)
+
+// queryPlaceholders are the placeholders, which saved searches may use: the login
+// of the user, and each of the organizations and teams (a search is run for each of them).
+var queryPlaceholders = []string{"{user}", "{org}", "{team}"}
+
+// lookupSavedSearchEnv reads raw values of the numbered environment variables,
+// which define saved searches.
+func lookupSavedSearchEnv() map[string]string {
+	result := make(map[string]string)
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if isSavedSearchEnv(key) {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// isSavedSearchEnv reports whether the environment variable defines a saved search.
+func isSavedSearchEnv(key string) bool {
+	n, err := strconv.Atoi(strings.TrimPrefix(key, savedSearchEnvPrefix))
+	return err == nil && n > 0 && strings.HasPrefix(key, savedSearchEnvPrefix)
+}
+
+// parseSavedSearch parses the name and the query of a saved search, like 'frontend: org:acme label:frontend'.
+func parseSavedSearch(value string) (string, string, error) {
+	name, query, ok := strings.Cut(value, ":")
+	name, query = strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(query)
+	if !ok || !savedSearchNameRegex.MatchString(name) || query == "" {
+		return "", "", &alfredError{"invalid saved search: " + value, "expected a name and a search query, like 'frontend: org:acme label:frontend'"}
+	}
+
+	for _, placeholder := range queryPlaceholderRegex.FindAllString(query, -1) {
+		if !containsString(queryPlaceholders, placeholder) {
+			return "", "", &alfredError{"unknown placeholder in saved search: " + placeholder, "expected one of " + strings.Join(queryPlaceholders, ", ")}
+		}
+	}
+	return name, query, nil
+}
+
+// validateSavedSearches parses the saved searches, by their names.
+func (wf *GithubWorkflow) validateSavedSearches() error {
+	wf.SavedSearches = make(map[string]string)
+	for key, value := range lookupSavedSearchEnv() {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+
+		name, query, err := parseSavedSearch(value)
+		if err != nil {
+			return err
+		}
+		if _, ok := wf.SavedSearches[name]; ok {
+			return &alfredError{"duplicate saved search: " + name, "rename the one in " + key}
+		}
+		wf.SavedSearches[name] = query
+	}
+	return nil
+}
+
+// useSavedSearch switches the workflow to the saved search, so that its pull requests
+// are searched, cached and displayed instead of the ones found by configured queries.
+// The search is cached separately (within the namespace of the host), so it is refreshed
+// independently of other searches.
+func (wf *GithubWorkflow) useSavedSearch(name string) error {
+	name = strings.ToLower(name)
+	if _, ok := wf.SavedSearches[name]; !ok {
+		return &alfredError{"unknown saved search: " + name, "define it by a " + savedSearchEnvPrefix + "<N> variable, like 'frontend: org:acme label:frontend'"}
+	}
+
+	store := newWorkflowStore(wf.Workflow, wf.workflowConfig, wf.namespace+"saved-"+name+"-")
+	wf.prs, wf.reviews, wf.details, wf.branches = store, store, store, store
+	wf.state = &savedSearchState{StateStore: wf.state, search: store}
+	wf.savedSearch = name
+	return nil
+}
+
+// savedSearchState keeps the state of refreshes of a saved search separately, while
+// the state of GitHub itself (like rate limits, or the user and their teams) is shared
+// with all the other searches.
+type savedSearchState struct {
+	StateStore
+	search *cacheStore
+}
+
+func (s *savedSearchState) LoadDescriptionHints() (map[int64]bool, error) {
+	return s.search.LoadDescriptionHints()
+}
+
+func (s *savedSearchState) StoreDescriptionHints(ids map[int64]bool) error {
+	return s.search.StoreDescriptionHints(ids)
+}
+
+func (s *savedSearchState) LoadSyncError() (string, error) {
+	return s.search.LoadSyncError()
+}
+
+func (s *savedSearchState) StoreSyncError(msg string) error {
+	return s.search.StoreSyncError(msg)
+}
+
+func (s *savedSearchState) LoadSyncTimes() (*syncTimes, error) {
+	return s.search.LoadSyncTimes()
+}
+
+func (s *savedSearchState) StoreSyncTimes(times *syncTimes) error {
+	return s.search.StoreSyncTimes(times)
+}
+
+func (s *savedSearchState) LoadLastViewed() (*lastViewed, error) {
+	return s.search.LoadLastViewed()
+}
+
+func (s *savedSearchState) StoreLastViewed(seen *lastViewed) error {
+	return s.search.StoreLastViewed(seen)
+}
+
+func (s *savedSearchState) LoadFailedChecks() (map[int64]string, error) {
+	return s.search.LoadFailedChecks()
+}
+
+func (s *savedSearchState) StoreFailedChecks(commits map[int64]string) error {
+	return s.search.StoreFailedChecks(commits)
+}
+
+// maxSavedSearchQueries caps the number of searches, which a saved search expands to,
+// since each of them is a call of the search API, whose rate limit is low.
+const maxSavedSearchQueries = 10
+
+// savedSearchQueries creates the search queries for open pull requests of the saved
+// search, with its placeholders resolved for the user (from the cached organizations
+// and teams, or the configured ones).
+func (wf *GithubWorkflow) savedSearchQueries(user *github.User) []string {
+	values := map[string][]string{
+		"{user}": {wf.targetLogin(user)},
+		"{org}":  wf.orgs(),
+		"{team}": wf.teams(),
+	}
+
+	queries := expandQueryTemplate(wf.SavedSearches[wf.savedSearch], values)
+	if len(queries) == 0 {
+		log.Printf("Saved search '%s' has no organizations or teams to search for", wf.savedSearch)
+	}
+	if len(queries) > maxSavedSearchQueries {
+		log.Printf("Saved search '%s' expands to %d searches, only the first %d are run", wf.savedSearch, len(queries), maxSavedSearchQueries)
+		queries = queries[:maxSavedSearchQueries]
+	}
+	for i, query := range queries {
+		queries[i] = "type:pr is:open " + query
+	}
+	return queries
+}
+
+// savedSearchUses reports whether the saved search has the placeholder.
+func (wf *GithubWorkflow) savedSearchUses(placeholder string) bool {
+	return wf.savedSearch != "" && strings.Contains(wf.SavedSearches[wf.savedSearch], placeholder)
+}
+
+// orgs returns the organizations of the user: the ones from SEARCH_SCOPES,
+// if any, or the cached organizations the user is a member of.
+func (wf *GithubWorkflow) orgs() []string {
+	if orgs := wf.scopedOrgs(); len(orgs) > 0 {
+		return orgs
+	}
+
+	memberships, err := wf.state.LoadMemberships()
+	if err != nil {
+		log.Println("failed to load memberships:", err)
+		return nil
+	}
+	return memberships.Orgs
+}
+
+// scopedOrgs returns the organizations, which the searches are limited to.
+func (wf *GithubWorkflow) scopedOrgs() []string {
+	var orgs []string
+	for _, scope := range wf.SearchScopes {
+		if strings.HasPrefix(scope, "org:") {
+			orgs = append(orgs, strings.TrimPrefix(scope, "org:"))
+		}
+	}
+	return orgs
+}
+
+// expandQueryTemplate replaces the placeholders in the search query with each
+// of their values, and returns all the queries it takes. There are none, if any
+// of the placeholders has no values. If the query has both {org} and {team},
+// each team is searched for within its own organization only.
+func expandQueryTemplate(template string, values map[string][]string) []string {
+	teamOrgs := strings.Contains(template, "{org}") && strings.Contains(template, "{team}")
+
+	queries := []string{template}
+	for _, placeholder := range queryPlaceholders {
+		if !strings.Contains(template, placeholder) || (teamOrgs && placeholder == "{org}") {
+			continue
+		}
+
+		expanded := make([]string, 0, len(queries)*len(values[placeholder]))
+		for _, query := range queries {
+			for _, value := range values[placeholder] {
+				query := strings.ReplaceAll(query, placeholder, value)
+				if teamOrgs && placeholder == "{team}" {
+					org, _, _ := strings.Cut(value, "/")
+					query = strings.ReplaceAll(query, "{org}", org)
+				}
+				expanded = append(expanded, query)
+			}
+		}
+		queries = expanded
+	}
+	return queries
+}
+
+// jobName names the background task, so that the tasks of saved searches
+// run independently of each other.
+func (wf *GithubWorkflow) jobName(task string) string {
+	if wf.savedSearch == "" {
+		return task
+	}
+	return task + "-saved-" + wf.savedSearch
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AndreyBozhko/go-alfred-prs/pkg/ghpr"
+	"github.com/google/go-github/v48/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSavedSearch(t *testing.T) {
+	name, query, err := parseSavedSearch("Frontend: org:acme label:frontend")
+	assert.Nil(t, err)
+	assert.Equal(t, "frontend", name)
+	assert.Equal(t, "org:acme label:frontend", query)
+
+	_, query, err = parseSavedSearch("mine: team-review-requested:{team} -author:{user}")
+	assert.Nil(t, err)
+	assert.Equal(t, "team-review-requested:{team} -author:{user}", query)
+
+	for _, value := range []string{"frontend", "frontend:", ": org:acme", "front end: org:acme", "mine: org:{organization}"} {
+		_, _, err = parseSavedSearch(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestValidateSavedSearches(t *testing.T) {
+	wf := newTestWorkflow(t)
+
+	t.Setenv("SAVED_SEARCH_1", "frontend: org:acme label:frontend")
+	t.Setenv("SAVED_SEARCH_2", "")
+	t.Setenv("SAVED_SEARCH_X", "ignored: org:acme")
+	assert.Nil(t, wf.validateSavedSearches())
+	assert.Equal(t, map[string]string{"frontend": "org:acme label:frontend"}, wf.SavedSearches)
+	assert.Equal(t, "frontend: org:acme label:frontend", lookupConfigEnv()["SAVED_SEARCH_1"])
+
+	t.Setenv("SAVED_SEARCH_3", "Frontend: org:acme")
+	assert.Error(t, wf.validateSavedSearches())
+}
+
+func TestUseSavedSearch(t *testing.T) {
+	wf := newTestWorkflow(t)
+	wf.RoleFilters = []string{"author"}
+	wf.SavedSearches = map[string]string{"frontend": "org:acme label:frontend"}
+
+	assert.Nil(t, wf.prs.StorePRs([]*github.Issue{{ID: github.Int64(1)}}))
+	assert.Equal(t, taskUpdate, wf.jobName(taskUpdate))
+
+	assert.Error(t, wf.useSavedSearch("backend"))
+	assert.Nil(t, wf.useSavedSearch("frontend"))
+
+	// the saved search is cached separately
+	count, err := wf.prs.CountPRs()
+	assert.Error(t, err)
+	assert.Equal(t, 0, count)
+
+	assert.Nil(t, wf.prs.StorePRs([]*github.Issue{{ID: github.Int64(2)}, {ID: github.Int64(3)}}))
+	count, err = wf.prs.CountPRs()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+
+	user := &github.User{Login: github.String("alice")}
+	assert.Equal(t, []string{"type:pr is:open org:acme label:frontend"}, wf.searchQueries(user))
+	assert.Equal(t, "type:pr is:open org:acme label:frontend", wf.combinedSearchQuery(user))
+	assert.Equal(t, "--update-saved-frontend", wf.jobName(taskUpdate))
+}
+
+func TestSavedSearchState(t *testing.T) {
+	wf := newTestWorkflow(t)
+	wf.SavedSearches = map[string]string{"frontend": "org:acme label:frontend"}
+
+	until := time.Now().Add(time.Minute).Truncate(time.Second)
+	assert.Nil(t, wf.state.StoreThrottledUntil(until))
+	assert.Nil(t, wf.state.StoreSyncError("main search failed"))
+
+	assert.Nil(t, wf.useSavedSearch("frontend"))
+
+	// the slow-down is shared with the main search, but refresh errors are not
+	throttled, err := wf.state.LoadThrottledUntil()
+	assert.Nil(t, err)
+	assert.True(t, until.Equal(throttled))
+
+	msg, _ := wf.state.LoadSyncError()
+	assert.Equal(t, "", msg)
+}
+
+func TestSavedSearchKeepsAvatars(t *testing.T) {
+	wf := newTestWorkflow(t)
+	wf.SavedSearches = map[string]string{"frontend": "org:acme label:frontend"}
+	assert.Nil(t, wf.useSavedSearch("frontend"))
+
+	dir := filepath.Join(wf.Cache.Dir, avatarDir)
+	assert.Nil(t, os.MkdirAll(dir, 0700))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "other.png"), []byte("avatar"), 0600))
+
+	_, err := wf.collectGarbage([]*github.Issue{{ID: github.Int64(1), HTMLURL: github.String("https://github.com/acme/repo/pull/1")}})
+	assert.Nil(t, err)
+	assert.FileExists(t, filepath.Join(dir, "other.png"))
+}
+
+func TestExpandQueryTemplate(t *testing.T) {
+	values := map[string][]string{
+		"{user}": {"alice"},
+		"{org}":  {"acme", "globex"},
+		"{team}": nil,
+	}
+
+	assert.Equal(t, []string{"org:acme label:frontend"}, expandQueryTemplate("org:acme label:frontend", values))
+	assert.Equal(t, []string{"org:acme -author:alice", "org:globex -author:alice"}, expandQueryTemplate("org:{org} -author:{user}", values))
+	assert.Equal(t, []string{}, expandQueryTemplate("team-review-requested:{team}", values))
+
+	// teams are searched for within their own organizations
+	values["{team}"] = []string{"acme/core", "globex/web"}
+	assert.Equal(t, []string{"org:acme team-review-requested:acme/core", "org:globex team-review-requested:globex/web"},
+		expandQueryTemplate("org:{org} team-review-requested:{team}", values))
+}
+
+func TestSavedSearchQueries(t *testing.T) {
+	wf := newTestWorkflow(t)
+	wf.SavedSearches = map[string]string{"mine": "org:{org} commenter:{user}"}
+	assert.Nil(t, wf.useSavedSearch("mine"))
+
+	user := &github.User{Login: github.String("alice")}
+	assert.Equal(t, []string{}, wf.searchQueries(user))
+	assert.Equal(t, "", wf.combinedSearchQuery(user))
+
+	assert.Nil(t, wf.state.StoreMemberships(&ghpr.Memberships{Orgs: []string{"acme", "globex"}}))
+	assert.Equal(t, []string{"type:pr is:open org:acme commenter:alice", "type:pr is:open org:globex commenter:alice"}, wf.searchQueries(user))
+	assert.Equal(t, "type:pr is:open ((org:acme commenter:alice) OR (org:globex commenter:alice))", wf.combinedSearchQuery(user))
+
+	// organizations of search scopes take precedence
+	wf.SearchScopes = []string{"org:initech", "user:octocat"}
+	assert.Equal(t, []string{"type:pr is:open org:initech commenter:alice"}, wf.searchQueries(user))
+	assert.Equal(t, "type:pr is:open org:initech commenter:alice", wf.combinedSearchQuery(user))
+
+	// and the searches are capped
+	wf.SearchScopes = nil
+	orgs := make([]string, 2*maxSavedSearchQueries)
+	for i := range orgs {
+		orgs[i] = fmt.Sprintf("org%d", i)
+	}
+	assert.Nil(t, wf.state.StoreMemberships(&ghpr.Memberships{Orgs: orgs}))
+	assert.Equal(t, maxSavedSearchQueries, len(wf.searchQueries(user)))
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// snapshotDir is the directory in workflow data, where snapshots are archived.
+const snapshotDir = "snapshots"
+
+// snapshotNameLayout names snapshot files by the time they were taken.
+const snapshotNameLayout = "20060102T150405Z"
+
+// prSnapshot is the list of pull requests at some point in time.
+type prSnapshot struct {
+	TakenAt time.Time `json:"taken_at"`
+	PRs     []*prView `json:"prs"`
+}
+
+// TakeSnapshot archives the cached pull requests (in the same format as the json
+// export) to a timestamped file in workflow data, and writes the path of the file.
+func (wf *GithubWorkflow) TakeSnapshot(w io.Writer) error {
+	prs, err := wf.loadPRViews(0)
+	if err != nil {
+		return err
+	}
+
+	file, err := wf.storeSnapshot(&prSnapshot{TakenAt: time.Now().UTC(), PRs: prs})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, file)
+	return err
+}
+
+// storeSnapshot writes the snapshot to the snapshot directory, and returns the file path.
+func (wf *GithubWorkflow) storeSnapshot(snapshot *prSnapshot) (string, error) {
+	dir := filepath.Join(wf.DataDir(), snapshotDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	bts, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	file := filepath.Join(dir, snapshot.TakenAt.UTC().Format(snapshotNameLayout)+".json")
+	return file, os.WriteFile(file, bts, 0600)
+}
+
+// loadSnapshot reads the snapshot, given by its file path,
+// or by its name in the snapshot directory (like 20230115T093000Z).
+func (wf *GithubWorkflow) loadSnapshot(name string) (*prSnapshot, error) {
+	file := name
+	if _, err := os.Stat(file); err != nil {
+		file = filepath.Join(wf.DataDir(), snapshotDir, name+".json")
+	}
+
+	bts, err := os.ReadFile(file)
+	if err != nil {
+		return nil, &alfredError{"cannot read snapshot " + name, err.Error()}
+	}
+
+	var snapshot prSnapshot
+	if err = json.Unmarshal(bts, &snapshot); err != nil {
+		return nil, &alfredError{"invalid snapshot " + name, err.Error()}
+	}
+	return &snapshot, nil
+}
+
+// DiffSnapshots writes the differences between two snapshots: pull requests which were
+// added (+) or removed (-) in the second one, and the ones whose title, review state
+// or badges changed (~).
+func (wf *GithubWorkflow) DiffSnapshots(w io.Writer, args []string) error {
+	if len(args) != 2 {
+		return &alfredError{"expected two snapshots to compare", "usage: --snapshot_diff <a> <b>"}
+	}
+
+	a, err := wf.loadSnapshot(args[0])
+	if err != nil {
+		return err
+	}
+	b, err := wf.loadSnapshot(args[1])
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, diffSnapshots(a, b))
+	return err
+}
+
+// diffSnapshots formats the differences between two snapshots, one pull request per line.
+func diffSnapshots(a, b *prSnapshot) string {
+	before := make(map[int64]*prView)
+	for _, pr := range a.PRs {
+		before[pr.ID] = pr
+	}
+	after := make(map[int64]*prView)
+	for _, pr := range b.PRs {
+		after[pr.ID] = pr
+	}
+
+	var lines string
+	added, removed, changed := 0, 0, 0
+	for _, pr := range b.PRs {
+		prev, ok := before[pr.ID]
+		switch {
+		case !ok:
+			added++
+			lines += fmt.Sprintf("+ %s: %s\n", pr, pr.Title)
+		case prev.FullTitle() != pr.FullTitle():
+			changed++
+			lines += fmt.Sprintf("~ %s: %s → %s\n", pr, prev.FullTitle(), pr.FullTitle())
+		}
+	}
+	for _, pr := range a.PRs {
+		if _, ok := after[pr.ID]; !ok {
+			removed++
+			lines += fmt.Sprintf("- %s: %s\n", pr, pr.Title)
+		}
+	}
+
+	header := fmt.Sprintf("%s → %s: %d added, %d removed, %d changed\n",
+		a.TakenAt.UTC().Format(time.RFC3339), b.TakenAt.UTC().Format(time.RFC3339), added, removed, changed)
+	return header + lines
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTakeSnapshot(t *testing.T) {
+	// given
+	defer os.RemoveAll(filepath.Join(testWf.DataDir(), snapshotDir))
+	assert.Nil(t, testWf.Cache.StoreJSON(wfPullRequestsKey, []map[string]interface{}{
+		{"id": 1, "number": 78, "title": "Title 1", "html_url": "https://gh.com/org/repo/pull/78", "user": map[string]string{"login": "aaa"}},
+	}))
+
+	// when
+	var out bytes.Buffer
+	assert.Nil(t, testWf.TakeSnapshot(&out))
+
+	// then
+	file := strings.TrimSpace(out.String())
+	assert.Equal(t, filepath.Join(testWf.DataDir(), snapshotDir), filepath.Dir(file))
+
+	snapshot, err := testWf.loadSnapshot(file)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(snapshot.PRs))
+	assert.Equal(t, "Title 1", snapshot.PRs[0].Title)
+	assert.WithinDuration(t, time.Now(), snapshot.TakenAt, time.Minute)
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	// given
+	defer os.RemoveAll(filepath.Join(testWf.DataDir(), snapshotDir))
+
+	prs := testPRViews()
+	_, err := testWf.storeSnapshot(&prSnapshot{TakenAt: time.Date(2023, 1, 15, 9, 30, 0, 0, time.UTC), PRs: prs})
+	assert.Nil(t, err)
+
+	updated := *prs[0]
+	updated.ReviewState = "✅✅"
+	added := &prView{ID: 3, Title: "Title 3", Repo: "org/repo", Number: 89}
+	_, err = testWf.storeSnapshot(&prSnapshot{TakenAt: time.Date(2023, 1, 16, 9, 30, 0, 0, time.UTC), PRs: []*prView{&updated, added}})
+	assert.Nil(t, err)
+
+	// when
+	var out bytes.Buffer
+	assert.Nil(t, testWf.DiffSnapshots(&out, []string{"20230115T093000Z", "20230116T093000Z"}))
+
+	// then
+	assert.Equal(t, "2023-01-15T09:30:00Z → 2023-01-16T09:30:00Z: 1 added, 1 removed, 1 changed\n"+
+		"~ org/repo#78: Title 1 ✅ → Title 1 ✅✅\n"+
+		"+ org/repo#89: Title 3\n"+
+		"- org/repo#67: Title 2\n", out.String())
+
+	assert.Error(t, testWf.DiffSnapshots(&out, []string{"20230115T093000Z"}))
+	assert.Error(t, testWf.DiffSnapshots(&out, []string{"20230115T093000Z", "missing"}))
+}
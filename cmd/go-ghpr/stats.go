@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	aw "github.com/deanishe/awgo"
+)
+
+// uncountedCommands are not counted in usage stats: the background
+// tasks are not invoked by the user, and sharing the stats is not a feature to measure.
+var uncountedCommands = map[string]bool{
+	"update":          true,
+	"update_status":   true,
+	"update_workload": true,
+	"stats_share":     true,
+}
+
+// recordUsage counts invocations of workflow commands (like display or approve),
+// given by the parsed flags, in the local usage stats, if the user has opted in.
+// Only the command names are counted: neither queries nor any identifiers are recorded.
+func (wf *GithubWorkflow) recordUsage(flags *flag.FlagSet) {
+	if !wf.UsageStats {
+		return
+	}
+
+	var commands []string
+	flags.Visit(func(f *flag.Flag) {
+		if getter, ok := f.Value.(flag.Getter); ok && getter.Get() == true && !uncountedCommands[f.Name] {
+			commands = append(commands, f.Name)
+		}
+	})
+	if len(commands) == 0 {
+		return
+	}
+
+	stats, err := wf.stats.LoadUsageStats()
+	if err != nil {
+		log.Println("failed to load usage stats:", err)
+		return
+	}
+
+	for _, command := range commands {
+		stats[command]++
+	}
+	if err = wf.stats.StoreUsageStats(stats); err != nil {
+		log.Println("failed to store usage stats:", err)
+	}
+}
+
+// formatUsageStats summarizes the usage stats, the most used commands first.
+func formatUsageStats(version string, stats map[string]int) string {
+	commands := make([]string, 0, len(stats))
+	for command := range stats {
+		commands = append(commands, command)
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		if stats[commands[i]] != stats[commands[j]] {
+			return stats[commands[i]] > stats[commands[j]]
+		}
+		return commands[i] < commands[j]
+	})
+
+	lines := []string{fmt.Sprintf("go-alfred-prs %s usage stats:", version)}
+	for _, command := range commands {
+		lines = append(lines, fmt.Sprintf("- %s: %d", command, stats[command]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ShareStats copies the summary of the usage stats to the clipboard,
+// to be pasted into GitHub discussions.
+func (wf *GithubWorkflow) ShareStats() error {
+	if !wf.UsageStats {
+		return &alfredError{"Usage stats are disabled", "set USAGE_STATS to true to collect them"}
+	}
+
+	stats, err := wf.stats.LoadUsageStats()
+	if err != nil {
+		return err
+	}
+	if len(stats) == 0 {
+		return &alfredError{"No usage stats yet", "they are collected as you use the workflow"}
+	}
+
+	if err = copyToClipboard(formatUsageStats(wf.Version(), stats)); err != nil {
+		return err
+	}
+
+	wf.Notify("Usage stats copied", "paste them into a GitHub discussion, thank you!")
+	return nil
+}
+
+// showStatsItem adds the item to share usage stats to the diagnostics, if they are collected.
+func (wf *GithubWorkflow) showStatsItem() {
+	if !wf.UsageStats {
+		return
+	}
+
+	wf.NewItem("Share usage stats").
+		Subtitle("copy the number of times each command was used, to paste into GitHub discussions").
+		Valid(true).
+		Icon(aw.IconInfo).
+		Var(fbActionKey, actionStatsShare)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordUsage(t *testing.T) {
+	// given
+	defer func() {
+		testWf.UsageStats = false
+		assert.Nil(t, testWf.stats.StoreUsageStats(map[string]int{}))
+	}()
+
+	parse := func(args ...string) *flag.FlagSet {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.Bool("display", false, "")
+		flags.Bool("approve", false, "")
+		flags.Bool("update", false, "")
+		flags.String("query", "", "")
+		assert.Nil(t, flags.Parse(args))
+		return flags
+	}
+
+	// when
+	testWf.recordUsage(parse("--display"))
+	testWf.UsageStats = true
+	testWf.recordUsage(parse("--display", "--query=secret"))
+	testWf.recordUsage(parse("--display=false", "--approve"))
+	testWf.recordUsage(parse("--update"))
+	testWf.recordUsage(parse("--display"))
+
+	// then
+	stats, err := testWf.stats.LoadUsageStats()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"display": 2, "approve": 1}, stats)
+}
+
+func TestFormatUsageStats(t *testing.T) {
+	assert.Equal(t,
+		"go-alfred-prs v1.2.0 usage stats:\n- display: 5\n- approve: 2\n- snooze: 2",
+		formatUsageStats("v1.2.0", map[string]int{"snooze": 2, "display": 5, "approve": 2}))
+}
+
+func TestShareStats(t *testing.T) {
+	// given
+	originalCopy := copyToClipboard
+	var copied string
+	copyToClipboard = func(text string) error {
+		copied = text
+		return nil
+	}
+
+	defer func() {
+		copyToClipboard = originalCopy
+		testWf.UsageStats = false
+		testWf.notification = nil
+		assert.Nil(t, testWf.stats.StoreUsageStats(map[string]int{}))
+	}()
+
+	// when
+	assert.Error(t, testWf.ShareStats())
+	testWf.UsageStats = true
+	assert.Error(t, testWf.ShareStats())
+	assert.Nil(t, testWf.stats.StoreUsageStats(map[string]int{"display": 3}))
+	assert.Nil(t, testWf.ShareStats())
+
+	// then
+	assert.Contains(t, copied, "usage stats:\n- display: 3")
+	assert.NotNil(t, testWf.notification)
+}
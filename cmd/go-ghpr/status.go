@@ -0,0 +1,434 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	aw "github.com/deanishe/awgo"
+	kc "github.com/deanishe/awgo/keychain"
+	"github.com/google/go-github/v48/github"
+)
+
+// doctorKeyword is the Alfred keyword of the diagnostics view.
+const doctorKeyword = "ghpr-doctor"
+
+// showSummary adds the summary of the pull requests on top of them, like
+// "12 open PRs · 4 need your review", which opens the pulls dashboard on GitHub.
+// Pull requests of others need the user's review, if the user (or one of their teams)
+// is requested to review them. The row keeps the same UID in views with UIDs.
+func (wf *GithubWorkflow) showSummary(prs []*prView, view *feedbackView) {
+	mine, toReview, changes := 0, 0, 0
+	for _, pr := range prs {
+		switch {
+		case pr.Mine:
+			mine++
+			if strings.Contains(pr.ReviewState, "❌") {
+				changes++
+			}
+		case pr.ReviewRequested:
+			toReview++
+		}
+	}
+
+	title := fmt.Sprintf("%d open PRs · %d need your review", len(prs), toReview)
+	subtitle := fmt.Sprintf("%d yours, %d with changes requested · ↩ to open the dashboard", mine, changes)
+
+	item := wf.NewItem(title).
+		Subtitle(subtitle).
+		Arg(wf.GetBaseWebUrl() + "/pulls").
+		Valid(true).
+		Icon(aw.IconInfo)
+
+	if view.UIDs {
+		item.UID(summaryUID)
+	}
+}
+
+// summaryUID is the UID of the summary row.
+const summaryUID = "summary"
+
+// statusUID is the UID of the status row.
+const statusUID = "status"
+
+// doctorTimeout limits how long the diagnostics wait for GitHub.
+const doctorTimeout = 10 * time.Second
+
+// ShowStatus adds the status row on top of the pull requests, which tells
+// when they were last refreshed, whether a refresh is in progress, or has failed.
+// Expired pull requests are refreshed in the background, if allowed by the attempt
+// limit, unless GitHub was found to be under maintenance in the last few minutes,
+// GitHub asked to slow down, or the rate limit is low. The row refreshes pull
+// requests on demand, and holding ⌘ or ⌥ opens the workflow log or the diagnostics.
+// It reports whether a refresh is in progress.
+// If an organization requires SSO authorization of the API token, it is followed
+// by the item, which opens the authorization page.
+// While refreshing, the list is rerun to show the new pull requests, unless
+// QUIET_REFRESH is set, so that the selection is not moved during triage.
+// The row keeps the same UID in views with UIDs, so that it is the same item
+// across reruns, whatever the status is.
+func (wf *GithubWorkflow) ShowStatus(count, currentAttempt int, view *feedbackView) bool {
+	expired := wf.prs.PRsExpired(wf.CacheMaxAge)
+	retryIn := wf.maintenanceRetryIn()
+	slowDownIn := wf.slowDownRetryIn()
+	rate := wf.lowRateLimit(0)
+	if expired && currentAttempt < maxAttempts && retryIn == 0 && slowDownIn == 0 && rate == nil {
+		wf.LaunchUpdateTask(currentAttempt)
+	}
+
+	refreshing := wf.IsRunning(wf.jobName(taskUpdate))
+	if refreshing && !wf.QuietRefresh {
+		wf.Rerun(rerunDelayDefault.Seconds())
+	}
+
+	syncError, err := wf.state.LoadSyncError()
+	if err != nil {
+		log.Println("failed to load last sync error:", err)
+	}
+	ssoURL, err := wf.state.LoadSSOURL()
+	if err != nil {
+		log.Println("failed to load SSO authorization:", err)
+	}
+
+	age, err := wf.prs.PRsAge()
+	lastUpdated := "never updated"
+	if err == nil {
+		lastUpdated = "updated " + formatAge(age)
+	}
+
+	var title, subtitle string
+	icon := aw.IconInfo
+	switch {
+	case refreshing:
+		title, subtitle, icon = "Refreshing pull requests...", lastUpdated, aw.IconSync
+		if currentAttempt > 0 {
+			subtitle = fmt.Sprintf("something went wrong - retrying (attempt #%d)...", currentAttempt)
+		}
+		if wf.QuietRefresh {
+			subtitle += ", reopen to see the changes"
+		}
+	case retryIn > 0:
+		title, subtitle, icon = "GitHub is under maintenance", "retrying in "+formatRetryIn(retryIn), aw.IconWarning
+	case slowDownIn > 0:
+		title, subtitle, icon = slowDownTitle, "too many requests, retrying in "+formatRetryIn(slowDownIn), aw.IconWarning
+	case expired && rate != nil:
+		title, subtitle, icon = "GitHub rate limit is low", rate.String()+", refreshes are paused", aw.IconWarning
+	case ssoURL != "":
+		title, subtitle, icon = "Could not refresh pull requests :(", "an organization requires SSO authorization of your API token", aw.IconWarning
+	case syncError != "":
+		title, subtitle, icon = "Could not refresh pull requests :(", syncError, aw.IconWarning
+	case expired:
+		title, subtitle, icon = "Could not load pull requests :(", "try running ghpr-update manually", aw.IconWarning
+	default:
+		title, subtitle = fmt.Sprintf("%d pull requests", count), lastUpdated
+		if rate != nil {
+			subtitle += " · rate limit is low"
+		}
+	}
+
+	item := wf.NewItem(title).
+		Subtitle(subtitle+" · ↩ to refresh").
+		Valid(true).
+		Icon(icon).
+		Var(fbActionKey, actionRefresh)
+
+	if view.UIDs {
+		item.UID(statusUID)
+	}
+
+	keys := view.Keys
+
+	// without an action, the log is opened like any other url
+	keys.add(item, modOpenLog).
+		Arg("file://"+wf.LogFile()).
+		Var(fbActionKey, "")
+
+	keys.add(item, modRunDoctor).
+		Var(fbActionKey, actionOpenDoctor)
+
+	// without an action, the authorization page is opened like any other url
+	if ssoURL != "" && !refreshing {
+		wf.NewItem(ssoTitle).
+			Subtitle("↩ to authorize it on GitHub, then refresh pull requests").
+			Arg(ssoURL).
+			Valid(true).
+			Icon(aw.IconWeb)
+	}
+
+	return refreshing
+}
+
+// slowDownTitle is shown when GitHub rejects requests by its secondary rate limit.
+const slowDownTitle = "GitHub asked us to slow down"
+
+// storeSyncResult remembers the error of the last refresh for the status row
+// (or clears it, if the refresh succeeded), and passes the error through.
+// If GitHub is under maintenance, or asked to slow down, the time is remembered
+// as well, so that refreshes are suspended for a while. If an organization requires
+// SSO authorization of the API token, the authorization URL is remembered.
+func (wf *GithubWorkflow) storeSyncResult(e error) error {
+	msg, since, until, ssoURL := "", time.Time{}, time.Time{}, isSSORequired(e)
+	switch retryAfter, slowDown := isSlowDown(e); {
+	case isMaintenance(e):
+		msg, since = "GitHub is under maintenance", time.Now()
+	case slowDown:
+		msg, until = slowDownTitle, time.Now().Add(retryAfter)
+	case ssoURL != "":
+		msg = ssoTitle
+	case e != nil:
+		msg = e.Error()
+	}
+
+	if err := wf.state.StoreSyncError(msg); err != nil {
+		log.Println("failed to store sync error:", err)
+	}
+	if err := wf.state.StoreMaintenance(since); err != nil {
+		log.Println("failed to store maintenance:", err)
+	}
+	if err := wf.state.StoreThrottledUntil(until); err != nil {
+		log.Println("failed to store throttling:", err)
+	}
+	if err := wf.state.StoreSSOURL(ssoURL); err != nil {
+		log.Println("failed to store SSO authorization:", err)
+	}
+	return e
+}
+
+// ssoTitle is shown when an organization requires the API token to be authorized for SAML SSO.
+const ssoTitle = "API token is not authorized for SSO"
+
+// isSSORequired returns the URL, where the API token can be authorized
+// for SAML SSO, if the error was caused by an organization, which enforces SSO:
+// GitHub rejects such requests with 403 Forbidden, and the X-GitHub-SSO header,
+// like 'required; url=https://github.com/orgs/acme/sso?authorization_request=...'.
+// Otherwise, it returns an empty string.
+func isSSORequired(err error) string {
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil || errResp.Response.StatusCode != http.StatusForbidden {
+		return ""
+	}
+
+	header := errResp.Response.Header.Get("X-GitHub-SSO")
+	if !strings.HasPrefix(header, "required;") {
+		return ""
+	}
+
+	_, url, found := strings.Cut(header, "url=")
+	if !found {
+		return ""
+	}
+	return strings.TrimSpace(url)
+}
+
+// slowDownRetryIn returns how long refreshes are still suspended for,
+// if GitHub asked to slow down, or zero otherwise.
+func (wf *GithubWorkflow) slowDownRetryIn() time.Duration {
+	until, err := wf.state.LoadThrottledUntil()
+	if err != nil {
+		log.Println("failed to load throttling:", err)
+		return 0
+	}
+
+	if retryIn := time.Until(until); retryIn > 0 {
+		return retryIn
+	}
+	return 0
+}
+
+// isSlowDown reports whether the error was caused by the secondary rate limit
+// of GitHub, which rejects too many requests in a short time with 403 Forbidden
+// (or 429 Too Many Requests), and returns how long to wait before retrying:
+// as given by the Retry-After header, or a minute, as GitHub recommends otherwise.
+func isSlowDown(err error) (time.Duration, bool) {
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		if abuseErr.RetryAfter != nil && *abuseErr.RetryAfter > 0 {
+			return *abuseErr.RetryAfter, true
+		}
+		return slowDownRetryDelay, true
+	}
+
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return 0, false
+	}
+
+	code := errResp.Response.StatusCode
+	if code != http.StatusForbidden && code != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(errResp.Response.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	msg := strings.ToLower(errResp.Message)
+	if strings.Contains(msg, "secondary rate limit") || strings.Contains(msg, "abuse") {
+		return slowDownRetryDelay, true
+	}
+	return 0, false
+}
+
+// formatRetryIn describes how soon a suspended refresh is retried, rounded up.
+func formatRetryIn(retryIn time.Duration) string {
+	if retryIn <= time.Minute {
+		return fmt.Sprintf("%ds", (retryIn+time.Second-1)/time.Second)
+	}
+	return fmt.Sprintf("%dm", (retryIn+time.Minute-1)/time.Minute)
+}
+
+// maintenanceRetryIn returns how long refreshes are still suspended for,
+// if GitHub was found to be under maintenance recently, or zero otherwise.
+func (wf *GithubWorkflow) maintenanceRetryIn() time.Duration {
+	since, err := wf.state.LoadMaintenance()
+	if err != nil {
+		log.Println("failed to load maintenance:", err)
+		return 0
+	}
+	if since.IsZero() {
+		return 0
+	}
+
+	if retryIn := maintenanceRetryDelay - time.Since(since); retryIn > 0 {
+		return retryIn
+	}
+	return 0
+}
+
+// isMaintenance reports whether the error means that GitHub is temporarily
+// unavailable: either 503 Service Unavailable (which GitHub Enterprise also
+// responds with, along with its maintenance page), or a maintenance message.
+func isMaintenance(err error) bool {
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return false
+	}
+	return errResp.Response.StatusCode == http.StatusServiceUnavailable ||
+		strings.Contains(strings.ToLower(errResp.Message), "maintenance")
+}
+
+// Refresh fetches pull requests in the background, even if they are not expired yet.
+func (wf *GithubWorkflow) Refresh() error {
+	if err := wf.LaunchBackgroundTask(taskUpdate); err != nil {
+		return err
+	}
+
+	wf.Notify("Refreshing pull requests", "the list is updated in the background")
+	return nil
+}
+
+// OpenDoctor opens the diagnostics view in Alfred.
+func (wf *GithubWorkflow) OpenDoctor() error {
+	return wf.Alfred.Search(doctorKeyword + " ")
+}
+
+// Doctor diagnoses common problems of the workflow: it checks the API token
+// and the connection to GitHub, and shows the state of the last refresh.
+// If usage stats are collected, it also offers to share them, and if the teams
+// of the user are searched, it offers to refresh them.
+func (wf *GithubWorkflow) Doctor() error {
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+
+	_, err := wf.GetToken()
+	switch {
+	case err == nil:
+		wf.checkConnection(ctx)
+	case err == kc.ErrNotFound:
+		wf.NewWarningItem("No API token configured", "use ghpr-auth to set your GitHub personal token")
+	default:
+		wf.NewWarningItem("Could not get API token", err.Error())
+	}
+
+	if syncError, err := wf.state.LoadSyncError(); err == nil && syncError != "" {
+		wf.NewWarningItem("Last refresh failed", syncError)
+	}
+
+	refreshed := "Pull requests were never refreshed"
+	if age, err := wf.prs.PRsAge(); err == nil {
+		refreshed = "Pull requests were refreshed " + formatAge(age)
+	}
+	if wf.IsRunning(wf.jobName(taskUpdate)) {
+		refreshed += " (refreshing now)"
+	}
+	wf.NewItem(refreshed).
+		Subtitle("searched by " + describeFilters(wf.RoleFilters, wf.teams())).
+		Valid(false).
+		Icon(aw.IconInfo)
+
+	wf.NewItem("Open workflow log").
+		Subtitle(wf.LogFile()).
+		Arg("file://" + wf.LogFile()).
+		Valid(true).
+		Icon(aw.IconInfo)
+
+	wf.showMembershipsItem()
+	wf.showStatsItem()
+	return nil
+}
+
+// showMembershipsItem adds the item to refresh the cached organizations and teams
+// of the user to the diagnostics, if the teams are searched.
+func (wf *GithubWorkflow) showMembershipsItem() {
+	if !wf.QueryMyTeams {
+		return
+	}
+
+	memberships, err := wf.state.LoadMemberships()
+	if err != nil {
+		log.Println("failed to load memberships:", err)
+		return
+	}
+
+	wf.NewItem(fmt.Sprintf("Member of %d organizations and %d teams", len(memberships.Orgs), len(memberships.Teams))).
+		Subtitle("↩ to refresh organizations and teams, which are cached for a day").
+		Valid(true).
+		Icon(aw.IconInfo).
+		Var(fbActionKey, actionRefreshOrgs)
+}
+
+// RefreshMemberships fetches the organizations and teams of the user,
+// even if the cached ones are not expired yet.
+func (wf *GithubWorkflow) RefreshMemberships() error {
+	ctx := context.Background()
+
+	client, err := wf.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = wf.fetchMemberships(ctx, client); err != nil {
+		return err
+	}
+
+	memberships, err := wf.state.LoadMemberships()
+	if err != nil {
+		return err
+	}
+
+	wf.Notify("Memberships refreshed", fmt.Sprintf("%d organizations, %d teams", len(memberships.Orgs), len(memberships.Teams)))
+	return nil
+}
+
+// checkConnection shows whether GitHub accepts the API token.
+func (wf *GithubWorkflow) checkConnection(ctx context.Context) {
+	client, err := wf.NewClient(ctx)
+	if err == nil {
+		var user *github.User
+		if user, _, err = client.Users.Get(ctx, ""); err == nil {
+			wf.NewItem("Connected to GitHub as " + user.GetLogin()).
+				Subtitle(wf.GitApiUrl).
+				Valid(false).
+				Icon(aw.IconInfo)
+			wf.showInstanceItem(ctx, client)
+			return
+		}
+	}
+
+	wf.NewWarningItem("Could not connect to GitHub", err.Error())
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	kc "github.com/deanishe/awgo/keychain"
+	"github.com/google/go-github/v48/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsMaintenance(t *testing.T) {
+	response := func(code int, message string) error {
+		return &github.ErrorResponse{Response: &http.Response{StatusCode: code}, Message: message}
+	}
+
+	assert.True(t, isMaintenance(response(http.StatusServiceUnavailable, "")))
+	assert.True(t, isMaintenance(fmt.Errorf("search failed: %w", response(http.StatusServiceUnavailable, ""))))
+	assert.True(t, isMaintenance(response(http.StatusBadGateway, "GitHub is down for scheduled maintenance")))
+	assert.False(t, isMaintenance(response(http.StatusForbidden, "API rate limit exceeded")))
+	assert.False(t, isMaintenance(errors.New("connection refused")))
+	assert.False(t, isMaintenance(nil))
+}
+
+func TestIsSlowDown(t *testing.T) {
+	response := func(code int, header http.Header, message string) error {
+		return &github.ErrorResponse{Response: &http.Response{StatusCode: code, Header: header}, Message: message}
+	}
+	retryAfter := 30 * time.Second
+
+	data := []struct {
+		err      error
+		expected time.Duration
+		slowDown bool
+	}{
+		{&github.AbuseRateLimitError{RetryAfter: &retryAfter}, retryAfter, true},
+		{fmt.Errorf("search failed: %w", &github.AbuseRateLimitError{}), slowDownRetryDelay, true},
+		{response(http.StatusForbidden, http.Header{"Retry-After": {"120"}}, ""), 2 * time.Minute, true},
+		{response(http.StatusTooManyRequests, http.Header{}, "You have exceeded a secondary rate limit"), slowDownRetryDelay, true},
+		{response(http.StatusForbidden, http.Header{}, "Resource not accessible by integration"), 0, false},
+		{response(http.StatusServiceUnavailable, http.Header{"Retry-After": {"120"}}, ""), 0, false},
+		{errors.New("connection refused"), 0, false},
+		{nil, 0, false},
+	}
+
+	for _, testcase := range data {
+		retryIn, slowDown := isSlowDown(testcase.err)
+		assert.Equal(t, testcase.slowDown, slowDown, testcase.err)
+		assert.Equal(t, testcase.expected, retryIn, testcase.err)
+	}
+}
+
+func TestShowSummary(t *testing.T) {
+	defer testWf.Feedback.Clear()
+
+	prs := testPRViews()
+	prs[0].Mine = true
+	prs[0].ReviewState = "✅❌"
+	prs[1].ReviewRequested = true
+	prs = append(prs, &prView{ID: 3}, &prView{ID: 4, MyReview: "APPROVED"})
+
+	testWf.Feedback.Clear()
+	testWf.showSummary(prs, &feedbackView{})
+
+	assert.Equal(t, 1, len(testWf.Feedback.Items))
+	assert.Equal(t, `{"title":"4 open PRs · 1 need your review","subtitle":"1 yours, 1 with changes requested · ↩ to open the dashboard","arg":"`+testWf.GetBaseWebUrl()+`/pulls","valid":true}`, marshalWithoutMods(t, testWf.Feedback.Items[0]))
+
+	testWf.Feedback.Clear()
+	testWf.showSummary(prs, &feedbackView{UIDs: true})
+	bts, err := testWf.Feedback.Items[0].MarshalJSON()
+	assert.Nil(t, err)
+	assert.Contains(t, string(bts), `"uid":"summary"`)
+}
+
+func TestShowStatusMaintenance(t *testing.T) {
+	// given
+	defer func() {
+		testWf.Feedback.Clear()
+		assert.Nil(t, testWf.storeSyncResult(nil))
+	}()
+
+	testWf.Feedback.Clear()
+	assert.Nil(t, testWf.prs.StorePRs([]*github.Issue{{ID: github.Int64(1)}}))
+
+	failure := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusServiceUnavailable}}
+	assert.Equal(t, failure, testWf.storeSyncResult(failure))
+	assert.Greater(t, testWf.maintenanceRetryIn(), 9*time.Minute)
+
+	// when
+	assert.False(t, testWf.ShowStatus(1, 0, &feedbackView{}))
+
+	// then
+	assert.Equal(t, `{"title":"GitHub is under maintenance","subtitle":"retrying in 10m · ↩ to refresh","arg":"","valid":true}`, marshalWithoutMods(t, testWf.Feedback.Items[0]))
+
+	assert.Nil(t, testWf.storeSyncResult(nil))
+	assert.Equal(t, time.Duration(0), testWf.maintenanceRetryIn())
+}
+
+func TestShowStatusSlowDown(t *testing.T) {
+	// given
+	defer func() {
+		testWf.Feedback.Clear()
+		assert.Nil(t, testWf.storeSyncResult(nil))
+	}()
+
+	testWf.Feedback.Clear()
+	assert.Nil(t, testWf.prs.StorePRs([]*github.Issue{{ID: github.Int64(1)}}))
+
+	retryAfter := 30 * time.Second
+	failure := &github.AbuseRateLimitError{RetryAfter: &retryAfter}
+	assert.Equal(t, failure, testWf.storeSyncResult(failure))
+	assert.Greater(t, testWf.slowDownRetryIn(), 29*time.Second)
+
+	// when
+	assert.False(t, testWf.ShowStatus(1, 0, &feedbackView{}))
+
+	// then
+	assert.Equal(t, `{"title":"GitHub asked us to slow down","subtitle":"too many requests, retrying in 30s · ↩ to refresh","arg":"","valid":true}`, marshalWithoutMods(t, testWf.Feedback.Items[0]))
+
+	title, subtitle := toAlfredMessage(failure).Parts()
+	assert.Equal(t, "GitHub asked us to slow down", title)
+	assert.Equal(t, "too many requests, try again in 30s", subtitle)
+
+	assert.Nil(t, testWf.storeSyncResult(nil))
+	assert.Equal(t, time.Duration(0), testWf.slowDownRetryIn())
+}
+
+func TestShowStatus(t *testing.T) {
+	// given
+	defer func() {
+		testWf.Feedback.Clear()
+		assert.Nil(t, testWf.storeSyncResult(nil))
+	}()
+
+	testWf.Feedback.Clear()
+	assert.Nil(t, testWf.prs.StorePRs([]*github.Issue{{ID: github.Int64(1)}}))
+
+	failure := errors.New("rate limit exceeded")
+	assert.Equal(t, failure, testWf.storeSyncResult(failure))
+
+	// when
+	assert.False(t, testWf.ShowStatus(1, 0, &feedbackView{}))
+	assert.Nil(t, testWf.storeSyncResult(nil))
+	assert.False(t, testWf.ShowStatus(1, 0, &feedbackView{UIDs: true}))
+
+	// then
+	assert.Equal(t, 2, len(testWf.Feedback.Items))
+	assert.Equal(t, `{"title":"Could not refresh pull requests :(","subtitle":"rate limit exceeded · ↩ to refresh","arg":"","valid":true}`, marshalWithoutMods(t, testWf.Feedback.Items[0]))
+	assert.Equal(t, `{"title":"1 pull requests","subtitle":"updated just now · ↩ to refresh","arg":"","valid":true}`, marshalWithoutMods(t, testWf.Feedback.Items[1]))
+
+	bts, err := testWf.Feedback.Items[1].MarshalJSON()
+	assert.Nil(t, err)
+	assert.Contains(t, string(bts), `"uid":"status"`)
+	assert.Contains(t, string(bts), `"variables":{"GH_ACTION":"refresh"}`)
+	assert.Contains(t, string(bts), `"cmd":{"arg":"file://`+testWf.LogFile()+`","subtitle":"Open workflow log","variables":{"GH_ACTION":""}}`)
+	assert.Contains(t, string(bts), `"alt":{"subtitle":"Run diagnostics","variables":{"GH_ACTION":"open_doctor"}}`)
+}
+
+func TestDoctor(t *testing.T) {
+	// given
+	url, teardown := setupFakeGitHub()
+	defer teardown()
+
+	testWf.GitApiUrl = url
+
+	kc.ErrNotFound = nil // effectively disable using keychain
+	defer func() {
+		kc.ErrNotFound = kcErr
+		testWf.Feedback.Clear()
+		assert.Nil(t, testWf.storeSyncResult(nil))
+	}()
+
+	testWf.Feedback.Clear()
+	assert.Nil(t, testWf.prs.StorePRs([]*github.Issue{{ID: github.Int64(1)}}))
+	testWf.storeSyncResult(errors.New("timeout"))
+
+	// when
+	assert.Nil(t, testWf.Doctor())
+
+	// then
+	actual := make([]string, 0)
+	for _, itm := range testWf.Feedback.Items {
+		actual = append(actual, marshalWithoutMods(t, itm))
+	}
+
+	assert.Equal(t, []string{
+		`{"title":"Connected to GitHub as testuser","subtitle":"` + url + `","arg":"","valid":false}`,
+		`{"title":"GitHub Enterprise 3.9.2","subtitle":"not supported: fine-grained tokens (3.10+)","arg":"","valid":false}`,
+		`{"title":"Last refresh failed","subtitle":"timeout","arg":"","valid":false}`,
+		`{"title":"Pull requests were refreshed just now","subtitle":"searched by roles: author, involves","arg":"","valid":false}`,
+		`{"title":"Open workflow log","subtitle":"` + testWf.LogFile() + `","arg":"file://` + testWf.LogFile() + `","valid":true}`,
+	}, actual)
+}
+
+func TestShowStatusSSO(t *testing.T) {
+	// given
+	defer func() {
+		testWf.Feedback.Clear()
+		assert.Nil(t, testWf.storeSyncResult(nil))
+	}()
+
+	testWf.Feedback.Clear()
+	assert.Nil(t, testWf.prs.StorePRs([]*github.Issue{{ID: github.Int64(1)}}))
+
+	authUrl := "https://github.com/orgs/acme/sso?authorization_request=abc"
+	resp := &http.Response{StatusCode: http.StatusForbidden, Header: http.Header{}}
+	resp.Header.Set("X-GitHub-SSO", "required; url="+authUrl)
+	failure := &github.ErrorResponse{Response: resp, Message: "Resource protected by organization SAML enforcement"}
+	assert.Equal(t, authUrl, isSSORequired(failure))
+	assert.Equal(t, failure, testWf.storeSyncResult(failure))
+
+	// when
+	assert.False(t, testWf.ShowStatus(1, 0, &feedbackView{}))
+
+	// then
+	assert.Equal(t, 2, len(testWf.Feedback.Items))
+	assert.Equal(t, `{"title":"Could not refresh pull requests :(","subtitle":"an organization requires SSO authorization of your API token · ↩ to refresh","arg":"","valid":true}`, marshalWithoutMods(t, testWf.Feedback.Items[0]))
+	assert.Contains(t, marshalWithoutMods(t, testWf.Feedback.Items[1]), `"title":"API token is not authorized for SSO","subtitle":"↩ to authorize it on GitHub, then refresh pull requests","arg":"`+authUrl+`"`)
+
+	resp.Header.Set("X-GitHub-SSO", "partial-results; organizations=21955855")
+	assert.Equal(t, "", isSSORequired(failure))
+}
@@ -0,0 +1,451 @@
+package main
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/AndreyBozhko/go-alfred-prs/pkg/ghpr"
+	"github.com/AndreyBozhko/go-alfred-prs/pkg/prcache"
+	"github.com/google/go-github/v48/github"
+)
+
+// PRStore persists pull requests found by the workflow.
+type PRStore interface {
+	LoadPRs(limit int) ([]*github.Issue, error)
+	StorePRs(prs []*github.Issue) error
+	CountPRs() (int, error)
+	LoadSearchTotal() (int, error)
+	StoreSearchTotal(total int) error
+	UpdatePR(id int64, update func(pr *github.Issue)) error
+	PRsExpired(maxAge time.Duration) bool
+	PRsAge() (time.Duration, error)
+}
+
+// ReviewStore persists reviews of pull requests, keyed by PR ID.
+// Reviews of all pull requests are kept together, so that they are loaded at once.
+type ReviewStore interface {
+	LoadReviews() (map[int64]*ghpr.ReviewSummary, error)
+	StoreReviews(summaries map[int64]*ghpr.ReviewSummary) error
+	UpdateReviews(id int64, reviews []*github.PullRequestReview) error
+	MergeReviews(fetched map[int64]*ghpr.ReviewSummary) error
+	PruneReviews(keep map[int64]bool) error
+}
+
+// DetailStore persists details of pull requests, keyed by PR ID.
+// Details of all pull requests are kept together, so that they are loaded at once.
+type DetailStore interface {
+	LoadDetails() (map[int64]*prDetails, error)
+	MergeDetails(fetched map[int64]*prDetails) error
+	PruneDetails(keep map[int64]bool) error
+}
+
+// BranchStore persists head branch names of pull requests, keyed by PR ID.
+type BranchStore interface {
+	LoadBranches() (map[int64]string, error)
+	StoreBranches(branches map[int64]string) error
+}
+
+// SnoozeStore persists snoozed pull requests, keyed by PR ID.
+type SnoozeStore interface {
+	LoadSnoozes() (map[int64]*snooze, error)
+	StoreSnoozes(snoozes map[int64]*snooze) error
+}
+
+// StatsStore persists local usage stats, i.e. invocation counts by command.
+type StatsStore interface {
+	LoadUsageStats() (map[string]int, error)
+	StoreUsageStats(stats map[string]int) error
+}
+
+// StateStore persists auxiliary workflow state.
+type StateStore interface {
+	LoadUser() (*github.User, error)
+	LoadOrStoreUser(reload func() (*github.User, error)) (*github.User, error)
+	LoadConfigSnapshot() (*configSnapshot, error)
+	StoreConfigSnapshot(snapshot *configSnapshot) error
+	LoadOrStoreRepoLanguage(repo string, maxAge time.Duration, reload func() (string, error)) (string, error)
+	LoadOrStoreRequiredApprovals(repo, branch string, maxAge time.Duration, reload func() (int, error)) (int, error)
+	LoadOrStoreApiEndpoint(host string, maxAge time.Duration, reload func() (string, error)) (string, error)
+	LoadInstanceVersion(host string) (string, error)
+	LoadOrStoreInstanceVersion(host string, maxAge time.Duration, reload func() (string, error)) (string, error)
+	PruneExpired(prefix string, maxAge time.Duration) error
+	LoadDescriptionHints() (map[int64]bool, error)
+	StoreDescriptionHints(ids map[int64]bool) error
+	LoadSyncError() (string, error)
+	StoreSyncError(msg string) error
+	LoadSyncTimes() (*syncTimes, error)
+	StoreSyncTimes(times *syncTimes) error
+	LoadMaintenance() (time.Time, error)
+	StoreMaintenance(since time.Time) error
+	LoadThrottledUntil() (time.Time, error)
+	StoreThrottledUntil(until time.Time) error
+	LoadSSOURL() (string, error)
+	StoreSSOURL(url string) error
+	LoadCacheVersion() (int, error)
+	StoreCacheVersion(version int) error
+	LoadRateLimit() (*rateLimit, error)
+	StoreRateLimit(rate *rateLimit) error
+	LoadWorkload() (map[string]int, error)
+	StoreWorkload(workload map[string]int) error
+	WorkloadExpired(maxAge time.Duration) bool
+	LoadMemberships() (*ghpr.Memberships, error)
+	StoreMemberships(memberships *ghpr.Memberships) error
+	MembershipsExpired(maxAge time.Duration) bool
+	LoadLastViewed() (*lastViewed, error)
+	StoreLastViewed(seen *lastViewed) error
+	LoadFailedChecks() (map[int64]string, error)
+	StoreFailedChecks(commits map[int64]string) error
+}
+
+// cacheStore implements workflow stores on top of a cache backend (awgo cache
+// directory, unless another one is plugged in). Pull requests and their reviews
+// are kept by prcache.Store, along with the rest of the workflow state.
+type cacheStore struct {
+	*prcache.Store
+}
+
+// newCacheStore creates a store which keeps its data in backend.
+func newCacheStore(backend prcache.Backend, namespace string) *cacheStore {
+	return &cacheStore{prcache.NewStore(backend, namespace)}
+}
+
+func (s *cacheStore) LoadDetails() (map[int64]*prDetails, error) {
+	details := make(map[int64]*prDetails)
+	if !s.Exists(wfDetailsKey) {
+		return details, nil
+	}
+
+	err := s.Load(wfDetailsKey, &details)
+	return details, err
+}
+
+// MergeDetails reloads the cached details, and replaces the ones of the fetched pull
+// requests, unless they were fetched later on.
+func (s *cacheStore) MergeDetails(fetched map[int64]*prDetails) error {
+	details := make(map[int64]*prDetails)
+	return s.Update(wfDetailsKey, &details, func() error {
+		for id, d := range fetched {
+			if cached, ok := details[id]; !ok || !cached.FetchedAt.After(d.FetchedAt) {
+				details[id] = d
+			}
+		}
+		return nil
+	})
+}
+
+// PruneDetails drops the details of pull requests which are not kept.
+func (s *cacheStore) PruneDetails(keep map[int64]bool) error {
+	if !s.Exists(wfDetailsKey) {
+		return nil
+	}
+
+	details := make(map[int64]*prDetails)
+	return s.Update(wfDetailsKey, &details, func() error {
+		for id := range details {
+			if !keep[id] {
+				delete(details, id)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *cacheStore) LoadBranches() (map[int64]string, error) {
+	var branches map[int64]string
+	err := s.Load(wfBranchesKey, &branches)
+	return branches, err
+}
+
+func (s *cacheStore) StoreBranches(branches map[int64]string) error {
+	return s.Save(wfBranchesKey, branches)
+}
+
+func (s *cacheStore) LoadUser() (*github.User, error) {
+	var user github.User
+	if err := s.Load(wfUserInfoKey, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *cacheStore) LoadOrStoreUser(reload func() (*github.User, error)) (*github.User, error) {
+	var user github.User
+	err := s.LoadOrStore(
+		wfUserInfoKey,
+		0,
+		func() (interface{}, error) { return reload() },
+		&user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *cacheStore) LoadConfigSnapshot() (*configSnapshot, error) {
+	var snapshot configSnapshot
+	if err := s.Load(wfConfigSnapshotKey, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+func (s *cacheStore) StoreConfigSnapshot(snapshot *configSnapshot) error {
+	return s.Save(wfConfigSnapshotKey, snapshot)
+}
+
+func (s *cacheStore) LoadOrStoreRepoLanguage(repo string, maxAge time.Duration, reload func() (string, error)) (string, error) {
+	var language string
+	err := s.LoadOrStore(
+		wfRepoLanguageKey+url.PathEscape(repo),
+		maxAge,
+		func() (interface{}, error) { return reload() },
+		&language)
+	return language, err
+}
+
+func (s *cacheStore) LoadOrStoreRequiredApprovals(repo, branch string, maxAge time.Duration, reload func() (int, error)) (int, error) {
+	var count int
+	err := s.LoadOrStore(
+		wfApprovalsKey+url.PathEscape(repo+":"+branch),
+		maxAge,
+		func() (interface{}, error) { return reload() },
+		&count)
+	return count, err
+}
+
+func (s *cacheStore) LoadOrStoreApiEndpoint(host string, maxAge time.Duration, reload func() (string, error)) (string, error) {
+	var apiUrl string
+	err := s.LoadOrStore(
+		wfApiEndpointKey+url.PathEscape(host),
+		maxAge,
+		func() (interface{}, error) { return reload() },
+		&apiUrl)
+	return apiUrl, err
+}
+
+func (s *cacheStore) LoadInstanceVersion(host string) (string, error) {
+	version := ""
+	if !s.Exists(wfInstanceVersionKey + url.PathEscape(host)) {
+		return version, nil
+	}
+
+	err := s.Load(wfInstanceVersionKey+url.PathEscape(host), &version)
+	return version, err
+}
+
+func (s *cacheStore) LoadOrStoreInstanceVersion(host string, maxAge time.Duration, reload func() (string, error)) (string, error) {
+	var version string
+	err := s.LoadOrStore(
+		wfInstanceVersionKey+url.PathEscape(host),
+		maxAge,
+		func() (interface{}, error) { return reload() },
+		&version)
+	return version, err
+}
+
+func (s *cacheStore) LoadDescriptionHints() (map[int64]bool, error) {
+	var ids map[int64]bool
+	err := s.Load(wfDescriptionsKey, &ids)
+	return ids, err
+}
+
+func (s *cacheStore) StoreDescriptionHints(ids map[int64]bool) error {
+	return s.Save(wfDescriptionsKey, ids)
+}
+
+func (s *cacheStore) LoadSyncError() (string, error) {
+	msg := ""
+	if !s.Exists(wfSyncErrorKey) {
+		return msg, nil
+	}
+
+	err := s.Load(wfSyncErrorKey, &msg)
+	return msg, err
+}
+
+func (s *cacheStore) StoreSyncError(msg string) error {
+	return s.Save(wfSyncErrorKey, msg)
+}
+
+func (s *cacheStore) LoadSyncTimes() (*syncTimes, error) {
+	times := &syncTimes{}
+	if !s.Exists(wfSyncTimesKey) {
+		return times, nil
+	}
+
+	err := s.Load(wfSyncTimesKey, times)
+	return times, err
+}
+
+func (s *cacheStore) StoreSyncTimes(times *syncTimes) error {
+	return s.Save(wfSyncTimesKey, times)
+}
+
+func (s *cacheStore) LoadMaintenance() (time.Time, error) {
+	var since time.Time
+	if !s.Exists(wfMaintenanceKey) {
+		return since, nil
+	}
+
+	err := s.Load(wfMaintenanceKey, &since)
+	return since, err
+}
+
+func (s *cacheStore) StoreMaintenance(since time.Time) error {
+	return s.Save(wfMaintenanceKey, since)
+}
+
+func (s *cacheStore) LoadThrottledUntil() (time.Time, error) {
+	var until time.Time
+	if !s.Exists(wfThrottledUntilKey) {
+		return until, nil
+	}
+
+	err := s.Load(wfThrottledUntilKey, &until)
+	return until, err
+}
+
+func (s *cacheStore) StoreThrottledUntil(until time.Time) error {
+	return s.Save(wfThrottledUntilKey, until)
+}
+
+func (s *cacheStore) LoadSSOURL() (string, error) {
+	url := ""
+	if !s.Exists(wfSSOURLKey) {
+		return url, nil
+	}
+
+	err := s.Load(wfSSOURLKey, &url)
+	return url, err
+}
+
+func (s *cacheStore) StoreSSOURL(url string) error {
+	return s.Save(wfSSOURLKey, url)
+}
+
+func (s *cacheStore) LoadCacheVersion() (int, error) {
+	var version int
+	if !s.Exists(wfCacheVersionKey) {
+		return version, nil
+	}
+
+	err := s.Load(wfCacheVersionKey, &version)
+	return version, err
+}
+
+func (s *cacheStore) StoreCacheVersion(version int) error {
+	return s.Save(wfCacheVersionKey, version)
+}
+
+func (s *cacheStore) LoadRateLimit() (*rateLimit, error) {
+	if !s.Exists(wfRateLimitKey) {
+		return nil, nil
+	}
+
+	rate := &rateLimit{}
+	err := s.Load(wfRateLimitKey, rate)
+	return rate, err
+}
+
+func (s *cacheStore) StoreRateLimit(rate *rateLimit) error {
+	return s.Save(wfRateLimitKey, rate)
+}
+
+func (s *cacheStore) LoadWorkload() (map[string]int, error) {
+	var workload map[string]int
+	err := s.Load(wfWorkloadKey, &workload)
+	return workload, err
+}
+
+func (s *cacheStore) StoreWorkload(workload map[string]int) error {
+	return s.Save(wfWorkloadKey, workload)
+}
+
+func (s *cacheStore) WorkloadExpired(maxAge time.Duration) bool {
+	return s.Expired(wfWorkloadKey, maxAge)
+}
+
+func (s *cacheStore) LoadMemberships() (*ghpr.Memberships, error) {
+	memberships := &ghpr.Memberships{}
+	if !s.Exists(wfMembershipsKey) {
+		return memberships, nil
+	}
+
+	err := s.Load(wfMembershipsKey, memberships)
+	return memberships, err
+}
+
+func (s *cacheStore) StoreMemberships(memberships *ghpr.Memberships) error {
+	return s.Save(wfMembershipsKey, memberships)
+}
+
+func (s *cacheStore) MembershipsExpired(maxAge time.Duration) bool {
+	return s.Expired(wfMembershipsKey, maxAge)
+}
+
+func (s *cacheStore) LoadLastViewed() (*lastViewed, error) {
+	seen := &lastViewed{}
+	if !s.Exists(wfLastViewedKey) {
+		return seen, nil
+	}
+
+	err := s.Load(wfLastViewedKey, seen)
+	return seen, err
+}
+
+func (s *cacheStore) StoreLastViewed(seen *lastViewed) error {
+	return s.Save(wfLastViewedKey, seen)
+}
+
+func (s *cacheStore) LoadFailedChecks() (map[int64]string, error) {
+	commits := make(map[int64]string)
+	if !s.Exists(wfFailedChecksKey) {
+		return commits, nil
+	}
+
+	err := s.Load(wfFailedChecksKey, &commits)
+	return commits, err
+}
+
+func (s *cacheStore) StoreFailedChecks(commits map[int64]string) error {
+	return s.Save(wfFailedChecksKey, commits)
+}
+
+func (s *cacheStore) LoadSnoozes() (map[int64]*snooze, error) {
+	snoozes := make(map[int64]*snooze)
+	if !s.Exists(wfSnoozedKey) {
+		return snoozes, nil
+	}
+
+	err := s.Load(wfSnoozedKey, &snoozes)
+	return snoozes, err
+}
+
+func (s *cacheStore) StoreSnoozes(snoozes map[int64]*snooze) error {
+	return s.Save(wfSnoozedKey, snoozes)
+}
+
+func (s *cacheStore) LoadUsageStats() (map[string]int, error) {
+	stats := make(map[string]int)
+	if !s.Exists(wfUsageStatsKey) {
+		return stats, nil
+	}
+
+	err := s.Load(wfUsageStatsKey, &stats)
+	return stats, err
+}
+
+func (s *cacheStore) StoreUsageStats(stats map[string]int) error {
+	return s.Save(wfUsageStatsKey, stats)
+}
+
+// check that interfaces are implemented
+var (
+	_ PRStore     = (*cacheStore)(nil)
+	_ ReviewStore = (*cacheStore)(nil)
+	_ DetailStore = (*cacheStore)(nil)
+	_ BranchStore = (*cacheStore)(nil)
+	_ StateStore  = (*cacheStore)(nil)
+	_ SnoozeStore = (*cacheStore)(nil)
+	_ StatsStore  = (*cacheStore)(nil)
+)
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AndreyBozhko/go-alfred-prs/pkg/prcache"
+	"github.com/google/go-github/v48/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheStoreNamespaces(t *testing.T) {
+	cache := prcache.NewFileBackend(t.TempDir())
+	first, second := newCacheStore(cache, "first-"), newCacheStore(cache, "second-")
+
+	id := int64(1)
+	assert.Nil(t, first.StorePRs([]*github.Issue{{ID: &id}}))
+	assert.Nil(t, first.StoreBranches(map[int64]string{id: "main"}))
+
+	_, err := second.LoadPRs(0)
+	assert.Error(t, err)
+	_, err = second.LoadBranches()
+	assert.Error(t, err)
+
+	branches, err := first.LoadBranches()
+	assert.Nil(t, err)
+	assert.Equal(t, map[int64]string{id: "main"}, branches)
+	assert.True(t, cache.Exists("first-"+wfBranchesKey))
+}
+
+func TestCacheStoreMergeDetails(t *testing.T) {
+	store := newCacheStore(prcache.NewMemoryBackend(), "")
+
+	now := time.Now()
+	assert.Nil(t, store.MergeDetails(map[int64]*prDetails{1: {BaseBranch: "main", FetchedAt: now}}))
+
+	// the details of a slower status pass do not replace the newer ones
+	assert.Nil(t, store.MergeDetails(map[int64]*prDetails{
+		1: {BaseBranch: "dev", FetchedAt: now.Add(-time.Minute)},
+		2: {BaseBranch: "release", FetchedAt: now},
+	}))
+
+	details, err := store.LoadDetails()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(details))
+	assert.Equal(t, "main", details[1].BaseBranch)
+	assert.Equal(t, "release", details[2].BaseBranch)
+	assert.True(t, details[2].fresh(now.Add(-time.Second)))
+	assert.False(t, details[2].fresh(now.Add(time.Second)))
+}
+
+func TestCacheStorePrune(t *testing.T) {
+	cache := prcache.NewMemoryBackend()
+	store := newCacheStore(cache, "")
+
+	assert.Nil(t, store.UpdateReviews(1, nil))
+	assert.Nil(t, store.UpdateReviews(2, nil))
+	assert.Nil(t, store.MergeDetails(map[int64]*prDetails{1: {BaseBranch: "main"}, 2: {BaseBranch: "dev"}}))
+	assert.Nil(t, store.StoreBranches(map[int64]string{1: "feature"}))
+
+	keep := map[int64]bool{1: true}
+	assert.Nil(t, store.PruneReviews(keep))
+	assert.Nil(t, store.PruneDetails(keep))
+
+	summaries, err := store.LoadReviews()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(summaries))
+	assert.NotNil(t, summaries[1])
+
+	details, err := store.LoadDetails()
+	assert.Nil(t, err)
+	assert.Equal(t, map[int64]*prDetails{1: {BaseBranch: "main"}}, details)
+	assert.True(t, cache.Exists(wfBranchesKey))
+}
+
+func TestCacheStoreDescriptionHints(t *testing.T) {
+	store := newCacheStore(prcache.NewMemoryBackend(), "")
+
+	assert.Nil(t, store.StoreDescriptionHints(map[int64]bool{1: true}))
+
+	hints, err := store.LoadDescriptionHints()
+	assert.Nil(t, err)
+	assert.Equal(t, map[int64]bool{1: true}, hints)
+}
+
+func TestCacheStoreBranches(t *testing.T) {
+	store := newCacheStore(prcache.NewMemoryBackend(), "")
+
+	_, err := store.LoadBranches()
+	assert.Error(t, err)
+
+	assert.Nil(t, store.StoreBranches(map[int64]string{1: "main", 2: "feature"}))
+
+	branches, err := store.LoadBranches()
+	assert.Nil(t, err)
+	assert.Equal(t, map[int64]string{1: "main", 2: "feature"}, branches)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+
+	kc "github.com/deanishe/awgo/keychain"
+	"golang.org/x/oauth2"
+)
+
+// fineGrainedTokenPrefix starts fine-grained personal access tokens, unlike
+// classic ones (ghp_), which have OAuth scopes instead of permissions per repository.
+const fineGrainedTokenPrefix = "github_pat_"
+
+// isFineGrainedToken reports whether the API token is a fine-grained one.
+func isFineGrainedToken(token string) bool {
+	return strings.HasPrefix(token, fineGrainedTokenPrefix)
+}
+
+// supportsFineGrainedTokens reports whether fine-grained tokens can be generated
+// on the GitHub instance: github.com, and GitHub Enterprise instances of a recent
+// enough version (older instances, and the ones of unknown version, do not).
+func (wf *GithubWorkflow) supportsFineGrainedTokens() bool {
+	if !wf.isEnterprise() {
+		return true
+	}
+	return wf.instanceVersion() != "" && wf.supports(featureFineGrainedTokens)
+}
+
+// commandTokenSource mints API tokens by running an external command
+// (for example, to exchange an OIDC token for a short-lived GitHub token).
+// Minted tokens are kept in user's keychain until they expire.
+type commandTokenSource struct {
+	command  string
+	keychain *kc.Keychain
+	mu       sync.Mutex
+}
+
+// newCommandTokenSource creates a token source for the command.
+func newCommandTokenSource(command string, keychain *kc.Keychain) *commandTokenSource {
+	return &commandTokenSource{command: command, keychain: keychain}
+}
+
+// Token returns the previously minted token, if it is still valid,
+// or mints a new token otherwise.
+func (s *commandTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if data, err := s.keychain.Get(wfMintedTokenKey); err == nil {
+		var token oauth2.Token
+		if err = json.Unmarshal([]byte(data), &token); err == nil && token.Valid() {
+			return &token, nil
+		}
+	}
+
+	return s.mint()
+}
+
+// Refresh mints a new token, regardless of the previously minted one.
+func (s *commandTokenSource) Refresh() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.mint()
+}
+
+// mint runs the token command, and saves its token in the keychain.
+func (s *commandTokenSource) mint() (*oauth2.Token, error) {
+	log.Println("Minting new API token...")
+
+	out, err := exec.Command("/bin/sh", "-c", s.command).Output()
+	if err != nil {
+		return nil, &alfredError{"token command failed", err.Error()}
+	}
+
+	token, err := parseMintedToken(out)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return token, s.keychain.Set(wfMintedTokenKey, string(data))
+}
+
+// onePasswordCLIs are the locations of 1Password CLI, which is looked up in PATH
+// first. Alfred runs the workflow with a minimal PATH, without Homebrew in it.
+var onePasswordCLIs = []string{"op", "/opt/homebrew/bin/op", "/usr/local/bin/op"}
+
+// readOnePasswordToken reads the API token from 1Password by its secret reference
+// (like op://Private/GitHub/token), with 1Password CLI.
+func readOnePasswordToken(reference string) (string, error) {
+	for _, cli := range onePasswordCLIs {
+		path, err := exec.LookPath(cli)
+		if err != nil {
+			continue
+		}
+
+		out, err := exec.Command(path, "read", "--no-newline", reference).Output()
+		if err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+				return "", &alfredError{"1Password CLI failed", strings.TrimSpace(string(exitErr.Stderr))}
+			}
+			return "", &alfredError{"1Password CLI failed", err.Error()}
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+	return "", &alfredError{"1Password CLI is not found", "install it, and turn on its integration in 1Password app"}
+}
+
+// refreshingTransport retries requests rejected with 401 Unauthorized once,
+// after minting a new token.
+type refreshingTransport struct {
+	source *commandTokenSource
+	base   http.RoundTripper
+}
+
+func (t *refreshingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.roundTrip(req, t.source.Token)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// request body can be sent again only if it is replayable
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+
+	resp.Body.Close()
+	return t.roundTrip(retry, t.source.Refresh)
+}
+
+func (t *refreshingTransport) roundTrip(req *http.Request, getToken func() (*oauth2.Token, error)) (*http.Response, error) {
+	token, err := getToken()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &oauth2.Transport{Source: oauth2.StaticTokenSource(token), Base: t.base}
+	return transport.RoundTrip(req)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshingTransport(t *testing.T) {
+	// given
+	counter := filepath.Join(t.TempDir(), "counter")
+	command := "echo x >> " + counter + " && echo token-$(wc -l < " + counter + " | tr -d ' ')"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	source := newCommandTokenSource(command, testWf.Keychain)
+	defer testWf.Keychain.Delete(wfMintedTokenKey)
+
+	client := &http.Client{Transport: &refreshingTransport{source: source}}
+
+	// when
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("body"))
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	minted, err := os.ReadFile(counter)
+	assert.Nil(t, err)
+	assert.Equal(t, "x\nx\n", string(minted))
+
+	// and then, the minted token is reused
+	token, err := source.Token()
+	assert.Nil(t, err)
+	assert.Equal(t, "token-2", token.AccessToken)
+}
+
+func TestIsFineGrainedToken(t *testing.T) {
+	assert.True(t, isFineGrainedToken("github_pat_11ABCDEFG"))
+	assert.False(t, isFineGrainedToken("ghp_abcdefg"))
+	assert.False(t, isFineGrainedToken(""))
+}
+
+func TestSupportsFineGrainedTokens(t *testing.T) {
+	wf := newTestWorkflow(t)
+
+	wf.GitApiUrl = "https://api.github.com"
+	assert.True(t, wf.supportsFineGrainedTokens())
+
+	wf.GitApiUrl = "https://ghe.mycorp.com/api/v3/"
+	assert.False(t, wf.supportsFineGrainedTokens())
+
+	storeInstanceVersion(t, wf, "ghe.mycorp.com", "3.9.4")
+	assert.False(t, wf.supportsFineGrainedTokens())
+
+	storeInstanceVersion(t, wf, "ghe.mycorp.com", "3.10.0")
+	assert.True(t, wf.supportsFineGrainedTokens())
+}
+
+func TestReadOnePasswordToken(t *testing.T) {
+	dir := t.TempDir()
+	script := "#!/bin/sh\n" +
+		`if [ "$3" = "op://Private/GitHub/token" ]; then printf ghp_secret; else echo "item not found" >&2; exit 1; fi` + "\n"
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "op"), []byte(script), 0700))
+
+	defer func(clis []string) { onePasswordCLIs = clis }(onePasswordCLIs)
+	onePasswordCLIs = []string{filepath.Join(dir, "op")}
+
+	token, err := readOnePasswordToken("op://Private/GitHub/token")
+	assert.Nil(t, err)
+	assert.Equal(t, "ghp_secret", token)
+
+	_, err = readOnePasswordToken("op://Private/Missing/token")
+	assert.Equal(t, &alfredError{"1Password CLI failed", "item not found"}, err)
+
+	onePasswordCLIs = []string{filepath.Join(dir, "missing")}
+	_, err = readOnePasswordToken("op://Private/GitHub/token")
+	assert.Equal(t, "1Password CLI is not found", err.(*alfredError).title)
+}
+
+func TestDisplayWithOnePasswordToken(t *testing.T) {
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "counter")
+	script := "#!/bin/sh\necho x >> " + counter + "\nprintf ghp_secret\n"
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "op"), []byte(script), 0700))
+
+	defer func(clis []string) { onePasswordCLIs = clis }(onePasswordCLIs)
+	onePasswordCLIs = []string{filepath.Join(dir, "op")}
+
+	wf := newTestWorkflow(t)
+	wf.GitApiUrl = "https://api.github.com"
+	wf.TokenSource, wf.TokenReference = tokenSourceOp, "op://Private/GitHub/token"
+
+	// the token is not read just to display the cached pull requests
+	assert.Nil(t, wf.DisplayPRs(viewSorted, maxAttempts))
+	assert.NoFileExists(t, counter)
+}
@@ -0,0 +1,907 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v48/github"
+	"golang.org/x/oauth2"
+)
+
+var (
+	ghHtmlUrlPattern = regexp.MustCompile(`^https?://[a-z0-9.\-]+(:\d+)?/([a-zA-Z0-9/_\-]+)/pull/\d+$`)
+	ghPullUrlPattern = regexp.MustCompile(`^https?://[a-z0-9.\-]+(:\d+)?/([a-zA-Z0-9_.\-]+)/([a-zA-Z0-9_.\-]+)/pull/(\d+)([/?#].*)?$`)
+	ghHostPattern    = regexp.MustCompile(`^[a-z0-9\-]+(\.[a-z0-9\-]+)+$`)
+
+	availableRoles     = []string{"assignee", "author", "commenter", "involves", "mentions", "review-requested", "reviewed-by"}
+	singleRolePattern  = regexp.MustCompile(`^(([+-])(` + strings.Join(availableRoles, "|") + `))$`)
+	singleTeamPattern  = regexp.MustCompile(`^[a-zA-Z0-9_\-]+/[a-zA-Z0-9_.\-]+$`)
+	searchScopePattern = regexp.MustCompile(`^(org|user):[a-zA-Z0-9][a-zA-Z0-9\-]*$`)
+	loginPattern       = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9\-]*(\[bot\])?$`)
+)
+
+// parseRepoFromUrl extracts 'org/repo' substring from the HTML URL of a GitHub issue.
+func parseRepoFromUrl(htmlUrl string) string {
+	match := ghHtmlUrlPattern.FindStringSubmatch(htmlUrl)
+	if match != nil {
+		return match[2]
+	}
+	return ""
+}
+
+// githubHost holds the web and API URLs of a GitHub instance.
+type githubHost struct {
+	WebUrl string
+	ApiUrl string
+}
+
+// parseGithubHost derives web and API URLs from the configured GitHub host,
+// which may be given as 'github.com', 'https://ghe.mycorp.com',
+// 'https://ghe.mycorp.com/api/v3' or 'api.github.com'.
+// Hosts with 'api.' subdomain serve the API from the root path, like github.com,
+// while GitHub Enterprise hosts serve it from '/api/v3/' on the web host.
+func parseGithubHost(raw string) (*githubHost, error) {
+	invalid := &alfredError{"invalid github url: " + raw, "expected something like github.com"}
+
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || !isValidHost(u.Hostname()) {
+		return nil, invalid
+	}
+	// host names are case-insensitive, but cached URLs are compared as strings
+	u.Host = strings.ToLower(u.Host)
+
+	base := u.Scheme + "://" + u.Host
+	webHost := strings.TrimPrefix(u.Host, "api.")
+
+	switch {
+	case webHost != u.Host:
+		return &githubHost{u.Scheme + "://" + webHost, base}, nil
+	case u.Host == "github.com":
+		return &githubHost{base, "https://api.github.com"}, nil
+	}
+
+	return &githubHost{base, base + "/api/v3/"}, nil
+}
+
+// parseCustomUrl checks the explicitly configured API or web URL of the GitHub instance,
+// like 'https://ghe.mycorp.com/api/v3', and returns it without the trailing slash.
+func parseCustomUrl(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || !isValidHost(u.Hostname()) {
+		return "", &alfredError{"invalid github url: " + raw, "expected something like https://ghe.mycorp.com/api/v3"}
+	}
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
+
+// isValidHost reports whether the host is an IP address, or a domain name
+// with at least two labels (like ghe.mycorp.io, or git.corp.internal).
+func isValidHost(host string) bool {
+	return net.ParseIP(host) != nil || ghHostPattern.MatchString(strings.ToLower(host))
+}
+
+// parsePullRequestUrl extracts the repository and number from the web URL
+// of a pull request, which may also point to any of its tabs.
+func parsePullRequestUrl(rawUrl string) (owner, repo string, number int, err error) {
+	match := ghPullUrlPattern.FindStringSubmatch(strings.TrimSpace(rawUrl))
+	if match == nil {
+		return "", "", 0, &alfredError{"not a pull request url: " + rawUrl, "expected something like https://github.com/org/repo/pull/123"}
+	}
+
+	number, _ = strconv.Atoi(match[4])
+	return match[2], match[3], number, nil
+}
+
+// summarizeChecks counts check runs by their outcome.
+func summarizeChecks(runs []*github.CheckRun) string {
+	if len(runs) == 0 {
+		return "none"
+	}
+
+	var passed, failed, pending int
+	for _, run := range runs {
+		switch {
+		case run.GetStatus() != "completed":
+			pending++
+		case checkPassed(run):
+			passed++
+		default:
+			failed++
+		}
+	}
+
+	return fmt.Sprintf("%d passed, %d failed, %d pending", passed, failed, pending)
+}
+
+// checkPassed reports whether the completed check run did not fail.
+func checkPassed(run *github.CheckRun) bool {
+	switch run.GetConclusion() {
+	case "success", "neutral", "skipped":
+		return true
+	}
+	return false
+}
+
+// checksFailed reports whether any of the completed check runs failed.
+func checksFailed(runs []*github.CheckRun) bool {
+	for _, run := range runs {
+		if run.GetStatus() == "completed" && !checkPassed(run) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRoleFilters analyzes configuration strings
+// and extracts roles that are enabled.
+func parseRoleFilters(roles []string) ([]string, error) {
+	result := make([]string, 0)
+
+	seen := make(map[string]string)
+	for _, roleString := range roles {
+		matches := singleRolePattern.FindAllStringSubmatch(roleString, -1)
+		if len(matches) != 1 {
+			return nil, &alfredError{
+				"invalid role: " + roleString,
+				"expected one of: " + strings.Join(availableRoles, ","),
+			}
+		}
+
+		flag, role := matches[0][2], matches[0][3]
+		seen[role] = flag
+	}
+
+	for role, flag := range seen {
+		if flag == "+" {
+			result = append(result, role)
+		}
+	}
+
+	return result, nil
+}
+
+// parseTeamFilters validates 'org/team' strings
+// and removes duplicate teams.
+func parseTeamFilters(teams []string) ([]string, error) {
+	result := make([]string, 0)
+
+	seen := make(map[string]bool)
+	for _, team := range teams {
+		team = strings.TrimSpace(team)
+		if team == "" {
+			continue
+		}
+
+		if !singleTeamPattern.MatchString(team) {
+			return nil, &alfredError{
+				"invalid team: " + team,
+				"expected something like org/team",
+			}
+		}
+
+		if !seen[team] {
+			seen[team] = true
+			result = append(result, team)
+		}
+	}
+
+	return result, nil
+}
+
+// parseLogins extracts GitHub logins, separated by commas or spaces,
+// from the input, dropping the optional '@' prefixes and duplicates.
+func parseLogins(input string) []string {
+	result := make([]string, 0)
+
+	seen := make(map[string]bool)
+	for _, login := range strings.FieldsFunc(input, func(r rune) bool { return r == ',' || r == ' ' }) {
+		login = strings.TrimPrefix(login, "@")
+		if login != "" && !seen[login] {
+			seen[login] = true
+			result = append(result, login)
+		}
+	}
+
+	return result
+}
+
+// markdownLinkEscaper escapes characters which would break the text of a Markdown link.
+var markdownLinkEscaper = strings.NewReplacer(`[`, `\[`, `]`, `\]`)
+
+// markdownLink formats a pull request reference as a Markdown link,
+// like '[org/repo#123: Title](url)'.
+func markdownLink(ref, title, url string) string {
+	return fmt.Sprintf("[%s: %s](%s)", ref, markdownLinkEscaper.Replace(title), url)
+}
+
+// checkoutCommand returns the GitHub CLI command which checks out the pull request.
+// Repositories outside of github.com are qualified with the host, as gh expects.
+func checkoutCommand(htmlUrl, repo string, number int) string {
+	if u, err := url.Parse(htmlUrl); err == nil && u.Host != "" && u.Host != "github.com" {
+		repo = u.Host + "/" + repo
+	}
+	return fmt.Sprintf("gh pr checkout %d --repo %s", number, repo)
+}
+
+// describeFilters summarizes the roles and teams used to search for pull requests.
+func describeFilters(roles, teams []string) string {
+	sortedRoles := append([]string(nil), roles...)
+	sort.Strings(sortedRoles)
+
+	parts := []string{"roles: " + strings.Join(sortedRoles, ", ")}
+	if len(sortedRoles) == 0 {
+		parts[0] = "roles: none"
+	}
+	if len(teams) > 0 {
+		parts = append(parts, "teams: "+strings.Join(teams, ", "))
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// broadenRoleFilters merges all available roles into the role filter: the disabled
+// roles are enabled in place, and the missing ones are appended, so that the rest
+// of the filter is kept as the user wrote it.
+func broadenRoleFilters(current string) string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, filter := range strings.Split(current, ",") {
+		filter = strings.TrimSpace(filter)
+		if filter == "" {
+			continue
+		}
+
+		role := strings.TrimLeft(filter, "+-")
+		if containsString(availableRoles, role) {
+			filter = "+" + role
+			seen[role] = true
+		}
+		result = append(result, filter)
+	}
+
+	for _, role := range availableRoles {
+		if !seen[role] {
+			result = append(result, "+"+role)
+		}
+	}
+	return strings.Join(result, ",")
+}
+
+// searchWebUrl returns URL of the GitHub web page with pull requests matching the query.
+func searchWebUrl(baseUrl, query string) string {
+	return baseUrl + "/search?type=pullrequests&q=" + url.QueryEscape(query)
+}
+
+// parseSearchScopes validates 'org:name' and 'user:name' qualifiers,
+// which limit the searches to the organizations and users.
+func parseSearchScopes(scopes []string) ([]string, error) {
+	result := make([]string, 0)
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			continue
+		}
+
+		if !searchScopePattern.MatchString(scope) {
+			return nil, &alfredError{
+				"invalid search scope: " + scope,
+				"expected something like org:acme or user:octocat",
+			}
+		}
+		result = append(result, scope)
+	}
+
+	return result, nil
+}
+
+// scopeQuery limits the search query to the scopes
+// (GitHub matches any of several org: and user: qualifiers).
+func scopeQuery(query string, scopes []string) string {
+	if len(scopes) == 0 {
+		return query
+	}
+	return query + " " + strings.Join(scopes, " ")
+}
+
+// combineSearchQueries builds a single search query, which matches
+// the same pull requests as all queries built by ghpr.SearchQueries.
+func combineSearchQueries(roles, teams []string, login string) string {
+	qualifiers := make([]string, 0, len(roles)+len(teams))
+	for _, role := range roles {
+		qualifiers = append(qualifiers, fmt.Sprintf("%s:%s", role, login))
+	}
+	for _, team := range teams {
+		qualifiers = append(qualifiers, "team-review-requested:"+team)
+	}
+
+	sort.Strings(qualifiers)
+	return "type:pr is:open (" + strings.Join(qualifiers, " OR ") + ")"
+}
+
+// excludeArchived narrows the search query down to pull requests in repositories,
+// which are not archived (and so can still be acted on).
+func excludeArchived(query string) string {
+	return query + " archived:false"
+}
+
+// withoutArchived drops the pull requests in archived repositories: the ones known
+// to be archived by their IDs, or by the repository returned along with them.
+func withoutArchived(prs []*github.Issue, archived map[int64]bool) []*github.Issue {
+	result := make([]*github.Issue, 0, len(prs))
+	for _, pr := range prs {
+		if !archived[pr.GetID()] && !pr.GetRepository().GetArchived() {
+			result = append(result, pr)
+		}
+	}
+	return result
+}
+
+// filterByLabels keeps the pull requests, which have any of the included labels
+// (unless none are given), and none of the excluded ones. Labels are matched ignoring case.
+func filterByLabels(prs []*github.Issue, include, exclude []string) []*github.Issue {
+	result := make([]*github.Issue, 0, len(prs))
+	for _, pr := range prs {
+		included, excluded := len(include) == 0, false
+		for _, label := range pr.Labels {
+			included = included || containsFold(include, label.GetName())
+			excluded = excluded || containsFold(exclude, label.GetName())
+		}
+		if included && !excluded {
+			result = append(result, pr)
+		}
+	}
+	return result
+}
+
+// pluralize prefixes the singular or the plural form with the count, like "1 pull request".
+func pluralize(count int, singular, plural string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, singular)
+	}
+	return fmt.Sprintf("%d %s", count, plural)
+}
+
+// containsString reports whether the string is present in the slice.
+func containsString(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// markdownHeadingPattern matches headings of Markdown sections, like '## Summary'.
+var markdownHeadingPattern = regexp.MustCompile(`^#{1,6}\s+(.*?)\s*#*$`)
+
+// htmlCommentPattern matches HTML comments, which PR templates use for instructions.
+var htmlCommentPattern = regexp.MustCompile(`(?s)<!--.*?-->`)
+
+// isPoorDescription reports whether the PR description is empty,
+// or any of the required sections is missing or left empty.
+// Section names are matched against Markdown headings, ignoring case.
+func isPoorDescription(body string, sections []string) bool {
+	body = htmlCommentPattern.ReplaceAllString(body, "")
+	if strings.TrimSpace(body) == "" {
+		return true
+	}
+
+	filled := make(map[string]bool)
+	current := ""
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if match := markdownHeadingPattern.FindStringSubmatch(line); match != nil {
+			current = strings.ToLower(match[1])
+			if _, ok := filled[current]; !ok {
+				filled[current] = false
+			}
+			continue
+		}
+		if line != "" {
+			filled[current] = true
+		}
+	}
+
+	for _, section := range sections {
+		if !filled[strings.ToLower(strings.TrimSpace(section))] {
+			return true
+		}
+	}
+	return false
+}
+
+// findPoorDescriptions returns IDs of pull requests authored by the user,
+// whose descriptions need more attention.
+func findPoorDescriptions(prs []*github.Issue, login string, sections []string) map[int64]bool {
+	result := make(map[int64]bool)
+	for _, pr := range prs {
+		if pr.GetUser().GetLogin() == login && isPoorDescription(pr.GetBody(), sections) {
+			result[pr.GetID()] = true
+		}
+	}
+	return result
+}
+
+// containsFold reports whether the string is present in the slice, ignoring case.
+func containsFold(items []string, s string) bool {
+	for _, item := range items {
+		if strings.EqualFold(strings.TrimSpace(item), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// dependencyBots are the authors of automated dependency updates.
+var dependencyBots = []string{"dependabot[bot]", "dependabot-preview[bot]", "renovate[bot]"}
+
+// isBot reports whether the user is a bot, like dependabot[bot] or a GitHub App.
+func isBot(user *github.User) bool {
+	return user.GetType() == "Bot" || strings.HasSuffix(user.GetLogin(), "[bot]")
+}
+
+// withoutBots drops the pull requests authored by bots.
+func withoutBots(prs []*github.Issue) []*github.Issue {
+	result := make([]*github.Issue, 0, len(prs))
+	for _, pr := range prs {
+		if !isBot(pr.GetUser()) {
+			result = append(result, pr)
+		}
+	}
+	return result
+}
+
+// sortByRepo orders pull requests by repository, keeping the more recently
+// updated ones first within each repository.
+func sortByRepo(prs []*prView) []*prView {
+	sorted := append([]*prView{}, prs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Repo < sorted[j].Repo
+	})
+	return sorted
+}
+
+// Headers of pull requests, which wait on the user, and the ones which wait on others.
+const (
+	sectionMyTurn     = "Your turn"
+	sectionTheirsTurn = "Waiting on others"
+)
+
+// sortByTurn orders pull requests, which wait on the user, before the ones which
+// wait on others, keeping the order within each of them.
+func sortByTurn(prs []*prView) []*prView {
+	sorted := append([]*prView{}, prs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].MyTurn && !sorted[j].MyTurn
+	})
+	return sorted
+}
+
+// isMyTurn reports whether the pull request waits on the user: their own one, once
+// changes are requested, or it is approved (by as many reviewers as the target branch
+// requires), or the one of somebody else, if the user (or one of the teams) is
+// requested to review it. Without the details of the pull request, the ones which
+// the user has not reviewed yet are assumed to wait on them.
+func isMyTurn(pr *prView, login string, details *prDetails, teams []string) bool {
+	if pr.Mine {
+		required := pr.RequiredApprovals
+		if required < 1 {
+			required = 1
+		}
+		return strings.Contains(pr.ReviewState, "❌") || pr.Approvals >= required
+	}
+
+	if details == nil {
+		return pr.MyReview == "" || pr.MyReview == "COMMENTED"
+	}
+	return reviewRequested(details, login, teams)
+}
+
+// reviewRequested reports whether the user, or one of the teams, is requested to review
+// the pull request.
+func reviewRequested(details *prDetails, login string, teams []string) bool {
+	for _, reviewer := range details.RequestedReviewers {
+		if reviewer == login || containsString(teams, reviewer) {
+			return true
+		}
+	}
+	return false
+}
+
+// countSections counts distinct pull requests by their section.
+func countSections(prs []*prView, sectionOf func(pr *prView) string) map[string]int {
+	counts := make(map[string]int)
+	seen := make(map[int64]bool)
+	for _, pr := range prs {
+		if !seen[pr.ID] {
+			seen[pr.ID] = true
+			counts[sectionOf(pr)]++
+		}
+	}
+	return counts
+}
+
+// defaultDateGroupLabels are the headers of recency groups, from the most recent one.
+var defaultDateGroupLabels = []string{"Today", "Yesterday", "This week", "Older"}
+
+// parseDateGroupLabels checks the custom headers of recency groups (like translations),
+// which are either not set, or set for every group.
+func parseDateGroupLabels(labels []string) ([]string, error) {
+	result := make([]string, 0, len(labels))
+	for _, label := range labels {
+		if label = strings.TrimSpace(label); label != "" {
+			result = append(result, label)
+		}
+	}
+
+	if len(result) != 0 && len(result) != len(defaultDateGroupLabels) {
+		return nil, &alfredError{
+			fmt.Sprintf("expected %d date group labels, got %d", len(defaultDateGroupLabels), len(result)),
+			"like " + strings.Join(defaultDateGroupLabels, ","),
+		}
+	}
+	return result, nil
+}
+
+// dateGroupLabels returns the headers of recency groups: the custom ones, if set.
+func dateGroupLabels(labels []string) []string {
+	if len(labels) == len(defaultDateGroupLabels) {
+		return labels
+	}
+	return defaultDateGroupLabels
+}
+
+// dateGroup returns the header of the recency group of the time: today, yesterday,
+// this week (i.e. within the last 7 days) or older, in the time zone.
+func dateGroup(t, now time.Time, zone *time.Location, labels []string) string {
+	y, m, d := now.In(zone).Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, zone)
+
+	switch {
+	case !t.Before(today):
+		return labels[0]
+	case !t.Before(today.AddDate(0, 0, -1)):
+		return labels[1]
+	case !t.Before(today.AddDate(0, 0, -6)):
+		return labels[2]
+	}
+	return labels[3]
+}
+
+// groupDependencyUpdates finds identical dependency updates (by bot author and title)
+// across repositories, and returns them keyed by the ID of the first update in the list.
+// If the list has sections, updates are grouped only within the same section, so that
+// each of them is listed in its own section. Updates which appear in a single
+// repository (or section) are not grouped.
+func groupDependencyUpdates(prs []*prView, sectionOf func(pr *prView) string) map[int64][]*prView {
+	byTitle := make(map[string][]*prView)
+	for _, pr := range prs {
+		if containsString(dependencyBots, pr.Author) {
+			key := pr.Title
+			if sectionOf != nil {
+				key = sectionOf(pr) + "\x00" + key
+			}
+			byTitle[key] = append(byTitle[key], pr)
+		}
+	}
+
+	result := make(map[int64][]*prView)
+	for _, group := range byTitle {
+		if len(group) > 1 {
+			result[group[0].ID] = group
+		}
+	}
+	return result
+}
+
+// nagBadges escalate as my pull requests wait for review longer.
+var nagBadges = []string{"🕐", "🕕", "🔥"}
+
+// parseNagThresholds parses up to three durations (like '1d,3d,7d' or '12h,2d,5d'),
+// after which my pull requests awaiting review get more urgent badges.
+func parseNagThresholds(values []string) ([]time.Duration, error) {
+	result := make([]time.Duration, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		var d time.Duration
+		var err error
+		if strings.HasSuffix(value, "d") {
+			var n int
+			n, err = strconv.Atoi(strings.TrimSuffix(value, "d"))
+			d = time.Duration(n) * 24 * time.Hour
+		} else {
+			d, err = time.ParseDuration(value)
+		}
+
+		if err != nil || d <= 0 || len(result) == len(nagBadges) || (len(result) > 0 && d <= result[len(result)-1]) {
+			return nil, &alfredError{"invalid nag thresholds: " + strings.Join(values, ","), "expected up to three increasing durations, like 1d,3d,7d"}
+		}
+		result = append(result, d)
+	}
+	return result, nil
+}
+
+// nagBadge returns the badge for a pull request, which has been awaiting review
+// for the duration, or an empty string, if no threshold is reached yet.
+func nagBadge(waiting time.Duration, thresholds []time.Duration) string {
+	badge := ""
+	for i, threshold := range thresholds {
+		if waiting >= threshold {
+			badge = nagBadges[i]
+		}
+	}
+	return badge
+}
+
+// awaitingReview reports whether nobody but the author has reviewed the pull request.
+func awaitingReview(author string, reviews []*github.PullRequestReview) bool {
+	for _, review := range reviews {
+		if review.GetUser().GetLogin() != author {
+			return false
+		}
+	}
+	return true
+}
+
+// formatWaiting describes how long a pull request has been waiting, in days or hours.
+func formatWaiting(waiting time.Duration) string {
+	if waiting >= 24*time.Hour {
+		return fmt.Sprintf("%dd", int(waiting.Hours()/24))
+	}
+	return fmt.Sprintf("%dh", int(waiting.Hours()))
+}
+
+// nudgeComment creates a reminder for the requested reviewers (users and teams).
+// Teams are mentioned as org/slug, and belong to the owner of the repository,
+// unless told otherwise.
+func nudgeComment(reviewers *github.Reviewers, owner string) string {
+	mentions := make([]string, 0)
+	for _, user := range reviewers.Users {
+		mentions = append(mentions, "@"+user.GetLogin())
+	}
+	for _, team := range reviewers.Teams {
+		mentions = append(mentions, "@"+teamName(team, owner))
+	}
+
+	text := "friendly reminder: this pull request is still waiting for review 🙏"
+	if len(mentions) == 0 {
+		return "Hi, " + text
+	}
+	return "Hi " + strings.Join(mentions, " ") + ", " + text
+}
+
+// teamName refers to the team as org/slug, like mentions and team-review-requested do.
+func teamName(team *github.Team, owner string) string {
+	if org := team.GetOrganization().GetLogin(); org != "" {
+		owner = org
+	}
+	return owner + "/" + team.GetSlug()
+}
+
+// formatAge describes how long ago something happened.
+func formatAge(age time.Duration) string {
+	if age < time.Minute {
+		return "just now"
+	}
+	if age < time.Hour {
+		return fmt.Sprintf("%dm ago", int(age.Minutes()))
+	}
+	return formatWaiting(age) + " ago"
+}
+
+// activityDays is the number of days shown in sparklines of activity.
+const activityDays = 7
+
+// sparkBars are the bars of sparklines, from the lowest to the highest.
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline shows the number of events on each of the last activityDays days
+// (the oldest first) as bars, relative to the busiest day, like ▁▁▃▁▁▇█.
+func sparkline(times []time.Time, now time.Time) string {
+	counts := make([]int, activityDays)
+	busiest := 0
+	for _, t := range times {
+		day := int(now.Sub(t) / (24 * time.Hour))
+		if day < 0 || day >= activityDays {
+			continue
+		}
+
+		i := activityDays - 1 - day
+		counts[i]++
+		if counts[i] > busiest {
+			busiest = counts[i]
+		}
+	}
+	if busiest == 0 {
+		return ""
+	}
+
+	bars := make([]rune, activityDays)
+	for i, count := range counts {
+		bars[i] = sparkBars[count*(len(sparkBars)-1)/busiest]
+	}
+	return string(bars)
+}
+
+// Styles of timestamps of pull requests.
+const (
+	dateStyleAbsolute = "absolute"
+	dateStyleRelative = "relative"
+)
+
+// defaultDateFormat is the layout of absolute timestamps, unless configured otherwise.
+const defaultDateFormat = "02-Jan-2006 15:04"
+
+// parseOption checks that the value is one of the options,
+// the first of which is the default for an empty value.
+func parseOption(name, value string, options ...string) (string, error) {
+	if value == "" {
+		return options[0], nil
+	}
+	for _, option := range options {
+		if value == option {
+			return value, nil
+		}
+	}
+	return "", &alfredError{"invalid " + name + ": " + value, "expected one of: " + strings.Join(options, ",")}
+}
+
+// parseDateStyle checks the date style, which is absolute by default.
+func parseDateStyle(style string) (string, error) {
+	return parseOption("date style", style, dateStyleAbsolute, dateStyleRelative)
+}
+
+// Timestamps of pull requests, which are shown, and which they are sorted by.
+const (
+	dateFieldUpdated = "updated"
+	dateFieldCreated = "created"
+)
+
+// parseDateField checks which timestamp is shown, which is the update time by default.
+func parseDateField(field string) (string, error) {
+	return parseOption("date field", field, dateFieldUpdated, dateFieldCreated)
+}
+
+// sortByCreated orders pull requests by their creation time, the newest ones first
+// (ties are broken by ID, like in ghpr.DeduplicateAndSort).
+func sortByCreated(prs []*github.Issue) {
+	sort.SliceStable(prs, func(i, j int) bool {
+		if prs[i].GetCreatedAt().Equal(prs[j].GetCreatedAt()) {
+			return prs[i].GetID() < prs[j].GetID()
+		}
+		return prs[i].GetCreatedAt().After(prs[j].GetCreatedAt())
+	})
+}
+
+// Styles of review states of pull requests.
+const (
+	reviewStyleEmoji = "emoji"
+	reviewStyleIcons = "icons"
+)
+
+// parseReviewStyle checks the style of review states, which are emoji by default.
+func parseReviewStyle(style string) (string, error) {
+	return parseOption("review style", style, reviewStyleEmoji, reviewStyleIcons)
+}
+
+// Groupings of pull requests in the sorted view.
+const (
+	groupByNone = "none"
+	groupByRepo = "repo"
+	groupByDate = "date"
+	groupByTurn = "turn"
+)
+
+// parseGroupBy checks the grouping of pull requests, which are not grouped by default.
+func parseGroupBy(groupBy string) (string, error) {
+	return parseOption("grouping", groupBy, groupByNone, groupByRepo, groupByDate, groupByTurn)
+}
+
+// Levels of detail of pull request items.
+const (
+	detailNormal  = "normal"
+	detailCompact = "compact"
+	detailVerbose = "verbose"
+)
+
+// parseDetailLevel checks the level of detail, which is normal by default.
+func parseDetailLevel(level string) (string, error) {
+	return parseOption("detail level", level, detailNormal, detailCompact, detailVerbose)
+}
+
+// Sources of the API token.
+const (
+	tokenSourceKeychain = "keychain"
+	tokenSourceOp       = "op"
+)
+
+// parseTokenSource checks where the API token is kept, which is user's keychain by default.
+func parseTokenSource(source string) (string, error) {
+	return parseOption("token source", source, tokenSourceKeychain, tokenSourceOp)
+}
+
+// formatDate formats the timestamp in the date style: either relative
+// to now (like '2h ago'), or with the layout in the time zone.
+func formatDate(t time.Time, style, layout string, zone *time.Location, now time.Time) string {
+	if style == dateStyleRelative {
+		return formatAge(now.Sub(t))
+	}
+	if layout == "" {
+		layout = defaultDateFormat
+	}
+	return t.In(zone).Format(layout)
+}
+
+// leastLoaded returns the reviewer with the fewest open review requests
+// (ties are broken alphabetically), except the author of the pull request.
+func leastLoaded(workload map[string]int, author string) (login string, count int) {
+	for candidate, n := range workload {
+		if candidate == author {
+			continue
+		}
+		if login == "" || n < count || (n == count && candidate < login) {
+			login, count = candidate, n
+		}
+	}
+	return login, count
+}
+
+// findNewPRs returns issues from current slice which are not present in the previous one.
+func findNewPRs(previous, current []*github.Issue) []*github.Issue {
+	seen := make(map[int64]bool)
+	for _, item := range previous {
+		seen[item.GetID()] = true
+	}
+
+	var result []*github.Issue
+	for _, item := range current {
+		if !seen[item.GetID()] {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+// mintedToken is the output of the token command.
+type mintedToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// parseMintedToken reads the output of the token command, which is either
+// the token itself, or a JSON object with "token" and (optional) "expires_at" fields.
+func parseMintedToken(out []byte) (*oauth2.Token, error) {
+	text := strings.TrimSpace(string(out))
+
+	if !strings.HasPrefix(text, "{") {
+		if text == "" {
+			return nil, errTokenEmpty
+		}
+		return &oauth2.Token{AccessToken: text}, nil
+	}
+
+	var minted mintedToken
+	if err := json.Unmarshal([]byte(text), &minted); err != nil {
+		return nil, &alfredError{"invalid token command output", err.Error()}
+	}
+	if minted.Token == "" {
+		return nil, errTokenEmpty
+	}
+
+	return &oauth2.Token{AccessToken: minted.Token, Expiry: minted.ExpiresAt}, nil
+}
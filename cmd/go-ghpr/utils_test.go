@@ -0,0 +1,609 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v48/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRepoFromUrl(t *testing.T) {
+	data := []struct {
+		url, repo string
+	}{
+		{"https://github.com/deanishe/awgo/pull/77", "deanishe/awgo"},
+		{"https://github.com/renuo/alfred-pr-workflow/pull/1", "renuo/alfred-pr-workflow"},
+		{"https://github.com/chokkan/simstring/pull/", ""},
+		{"https://ghe.mycorp.io/team/service/pull/12", "team/service"},
+	}
+
+	for _, testcase := range data {
+		assert.Equal(t, testcase.repo, parseRepoFromUrl(testcase.url))
+	}
+}
+
+func TestParseGithubHost(t *testing.T) {
+	data := []struct {
+		input, webUrl, apiUrl string
+	}{
+		{"github.com", "https://github.com", "https://api.github.com"},
+		{"https://api.github.com", "https://github.com", "https://api.github.com"},
+		{"ghe.mycorp.io", "https://ghe.mycorp.io", "https://ghe.mycorp.io/api/v3/"},
+		{"https://ghe.mycorp.io/api/v3/", "https://ghe.mycorp.io", "https://ghe.mycorp.io/api/v3/"},
+		{"api.ghe.mycorp.io", "https://ghe.mycorp.io", "https://api.ghe.mycorp.io"},
+		{"http://127.0.0.1:8080", "http://127.0.0.1:8080", "http://127.0.0.1:8080/api/v3/"},
+		{"https://git.corp.internal:8443", "https://git.corp.internal:8443", "https://git.corp.internal:8443/api/v3/"},
+		{"GHE.MyCorp.io", "https://ghe.mycorp.io", "https://ghe.mycorp.io/api/v3/"},
+		{"http://[fd00::1]:8080", "http://[fd00::1]:8080", "http://[fd00::1]:8080/api/v3/"},
+		{"ghe-01.eu.my-corp.co.uk", "https://ghe-01.eu.my-corp.co.uk", "https://ghe-01.eu.my-corp.co.uk/api/v3/"},
+	}
+
+	for _, testcase := range data {
+		host, err := parseGithubHost(testcase.input)
+		assert.Nil(t, err)
+		assert.Equal(t, &githubHost{testcase.webUrl, testcase.apiUrl}, host)
+
+		// parsing is idempotent for API URLs
+		host, err = parseGithubHost(testcase.apiUrl)
+		assert.Nil(t, err)
+		assert.Equal(t, testcase.apiUrl, host.ApiUrl)
+	}
+
+	for _, input := range []string{"", "ftp://github.com", "https://localhost", "github.com:bad", "ghe mycorp.io", "https://ghe_mycorp.io"} {
+		_, err := parseGithubHost(input)
+		assert.Error(t, err)
+	}
+}
+
+func TestParsePullRequestUrl(t *testing.T) {
+	owner, repo, number, err := parsePullRequestUrl(" https://github.com/deanishe/awgo/pull/77/files?w=1\n")
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"deanishe", "awgo", 77}, []interface{}{owner, repo, number})
+
+	_, _, _, err = parsePullRequestUrl("https://github.com/deanishe/awgo/issues/77")
+	assert.Error(t, err)
+}
+
+func TestSummarizeChecks(t *testing.T) {
+	run := func(status, conclusion string) *github.CheckRun {
+		return &github.CheckRun{Status: &status, Conclusion: &conclusion}
+	}
+
+	assert.Equal(t, "none", summarizeChecks(nil))
+	assert.Equal(t, "2 passed, 1 failed, 1 pending", summarizeChecks([]*github.CheckRun{
+		run("completed", "success"),
+		run("completed", "skipped"),
+		run("completed", "failure"),
+		run("queued", ""),
+	}))
+
+	assert.False(t, checksFailed([]*github.CheckRun{run("completed", "neutral"), run("in_progress", "")}))
+	assert.True(t, checksFailed([]*github.CheckRun{run("completed", "success"), run("completed", "timed_out")}))
+}
+
+func TestParseRoleFilters(t *testing.T) {
+	data := []struct {
+		input    []string
+		expected []string
+	}{
+		{
+			[]string{"+author"},
+			[]string{"author"},
+		},
+		{
+			[]string{"-author"},
+			[]string{},
+		},
+		{
+			[]string{"+assignee", "+author", "+involves", "+mentions", "+review-requested"},
+			[]string{"assignee", "author", "involves", "mentions", "review-requested"},
+		},
+		{
+			[]string{"+author", "+assignee", "-involves", "+mentions", "-review-requested"},
+			[]string{"assignee", "author", "mentions"},
+		},
+		{
+			[]string{"+author", "+author", "-author", "+mentions"},
+			[]string{"mentions"},
+		},
+		{
+			[]string{"-author", "-assignee", "+involves", "+assignee"},
+			[]string{"assignee", "involves"},
+		},
+	}
+
+	for _, testcase := range data {
+		actual, err := parseRoleFilters(testcase.input)
+		assert.Nil(t, err)
+
+		sort.Strings(actual)
+
+		assert.Equal(t, testcase.expected, actual)
+	}
+}
+
+func TestParseRoleFiltersError(t *testing.T) {
+	data := []struct {
+		input []string
+	}{
+		{
+			[]string{"+not-author"},
+		},
+		{
+			[]string{"*author"},
+		},
+		{
+			[]string{"+assignee", "+author", "+involves", "+mentions", "+review-request"},
+		},
+		{
+			[]string{"+author", "+assignee", "/involves", "+mentions", "-review-requested"},
+		},
+		{
+			[]string{"+author+author", "-author", "+mentions"},
+		},
+		{
+			[]string{"-author", "-assignee,+involves", "+assignee"},
+		},
+	}
+
+	for _, testcase := range data {
+		_, err := parseRoleFilters(testcase.input)
+		assert.Error(t, err)
+	}
+}
+
+func TestParseTeamFilters(t *testing.T) {
+	data := []struct {
+		input    []string
+		expected []string
+	}{
+		{
+			[]string{},
+			[]string{},
+		},
+		{
+			[]string{""},
+			[]string{},
+		},
+		{
+			[]string{" org/team"},
+			[]string{"org/team"},
+		},
+		{
+			[]string{"my-org/team_1", "other/team.name", "my-org/team_1"},
+			[]string{"my-org/team_1", "other/team.name"},
+		},
+	}
+
+	for _, testcase := range data {
+		actual, err := parseTeamFilters(testcase.input)
+		assert.Nil(t, err)
+		assert.Equal(t, testcase.expected, actual)
+	}
+
+	for _, input := range []string{"team", "org/", "/team", "org/team/sub", "org team"} {
+		_, err := parseTeamFilters([]string{input})
+		assert.Error(t, err)
+	}
+}
+
+func TestFindNewPRs(t *testing.T) {
+	issue := func(id int64) *github.Issue {
+		return &github.Issue{ID: &id}
+	}
+
+	previous := []*github.Issue{issue(1), issue(2), issue(3)}
+	current := []*github.Issue{issue(4), issue(2), issue(5), issue(1)}
+
+	assert.Equal(t, []*github.Issue{issue(4), issue(5)}, findNewPRs(previous, current))
+	assert.Nil(t, findNewPRs(current, previous[:2]))
+}
+
+func TestParseMintedToken(t *testing.T) {
+	data := []struct {
+		output, token string
+		expiry        time.Time
+	}{
+		{"ghs_abc\n", "ghs_abc", time.Time{}},
+		{`{"token": "ghs_abc"}`, "ghs_abc", time.Time{}},
+		{`{"token": "ghs_abc", "expires_at": "2023-01-01T10:00:00Z"}`, "ghs_abc", time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)},
+	}
+
+	for _, testcase := range data {
+		actual, err := parseMintedToken([]byte(testcase.output))
+		assert.Nil(t, err)
+		assert.Equal(t, testcase.token, actual.AccessToken)
+		assert.True(t, testcase.expiry.Equal(actual.Expiry))
+	}
+
+	for _, output := range []string{"", " \n", `{"token": ""}`, `{"token": 1}`} {
+		_, err := parseMintedToken([]byte(output))
+		assert.Error(t, err)
+	}
+}
+
+func TestParseLogins(t *testing.T) {
+	assert.Equal(t, []string{}, parseLogins(""))
+	assert.Equal(t, []string{"alice", "bob"}, parseLogins("@alice, bob alice,,"))
+}
+
+func TestMarkdownLink(t *testing.T) {
+	assert.Equal(t, `[org/repo#1: Fix \[bug\]](https://gh.com/org/repo/pull/1)`, markdownLink("org/repo#1", "Fix [bug]", "https://gh.com/org/repo/pull/1"))
+}
+
+func TestCheckoutCommand(t *testing.T) {
+	assert.Equal(t, "gh pr checkout 12 --repo org/repo", checkoutCommand("https://github.com/org/repo/pull/12", "org/repo", 12))
+	assert.Equal(t, "gh pr checkout 12 --repo ghe.mycorp.io/org/repo", checkoutCommand("https://ghe.mycorp.io/org/repo/pull/12", "org/repo", 12))
+}
+
+func TestIsPoorDescription(t *testing.T) {
+	sections := []string{"Summary", "test plan"}
+
+	data := []struct {
+		body     string
+		sections []string
+		expected bool
+	}{
+		{"", nil, true},
+		{"  <!-- describe your change -->\n", nil, true},
+		{"Fixes the bug", nil, false},
+		{"Fixes the bug", sections, true},
+		{"## Summary\nFixes the bug\n## Test Plan\n<!-- how was it tested? -->\n", sections, true},
+		{"## Summary\nFixes the bug\n\n### Test plan ###\nunit tests\n", sections, false},
+	}
+
+	for _, testcase := range data {
+		assert.Equal(t, testcase.expected, isPoorDescription(testcase.body, testcase.sections), testcase.body)
+	}
+}
+
+func TestFindPoorDescriptions(t *testing.T) {
+	mine, other := &github.User{Login: github.String("me")}, &github.User{Login: github.String("other")}
+
+	prs := []*github.Issue{
+		{ID: github.Int64(1), User: mine, Body: github.String("")},
+		{ID: github.Int64(2), User: mine, Body: github.String("Fixes the bug")},
+		{ID: github.Int64(3), User: other, Body: github.String("")},
+	}
+
+	assert.Equal(t, map[int64]bool{1: true}, findPoorDescriptions(prs, "me", nil))
+}
+
+func TestGroupDependencyUpdates(t *testing.T) {
+	prs := []*prView{
+		{ID: 1, Title: "Bump a to 1.0", Repo: "org/a", Author: "dependabot[bot]"},
+		{ID: 2, Title: "Bump a to 1.0", Repo: "org/b", Author: "renovate[bot]"},
+		{ID: 3, Title: "Bump a to 1.0", Repo: "org/c", Author: "dependabot[bot]"},
+		{ID: 4, Title: "Bump a to 1.0", Repo: "org/d", Author: "human"},
+		{ID: 5, Title: "Bump b to 2.0", Repo: "org/a", Author: "dependabot[bot]"},
+	}
+
+	groups := groupDependencyUpdates(prs, nil)
+	assert.Equal(t, 1, len(groups))
+	assert.Equal(t, []*prView{prs[0], prs[1], prs[2]}, groups[1])
+
+	// each update is grouped within its own section
+	prs[0].MyTurn, prs[2].MyTurn = true, true
+	groups = groupDependencyUpdates(prs, func(pr *prView) string { return strconv.FormatBool(pr.MyTurn) })
+	assert.Equal(t, map[int64][]*prView{1: {prs[0], prs[2]}}, groups)
+}
+
+func TestDescribeFilters(t *testing.T) {
+	assert.Equal(t, "roles: none", describeFilters(nil, nil))
+	assert.Equal(t, "roles: author, involves", describeFilters([]string{"involves", "author"}, nil))
+	assert.Equal(t, "roles: author; teams: org/a, org/b", describeFilters([]string{"author"}, []string{"org/a", "org/b"}))
+}
+
+func TestBroadenRoleFilters(t *testing.T) {
+	roles, err := parseRoleFilters(strings.Split(broadenRoleFilters(""), ","))
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, availableRoles, roles)
+
+	assert.Equal(t, "+author,+involves,+assignee,+commenter,+mentions,+review-requested,+reviewed-by",
+		broadenRoleFilters("+author, -involves"))
+}
+
+func TestCombineSearchQueries(t *testing.T) {
+	assert.Equal(t,
+		"type:pr is:open (author:user OR involves:user OR team-review-requested:org/team)",
+		combineSearchQueries([]string{"involves", "author"}, []string{"org/team"}, "user"))
+}
+
+func TestSearchWebUrl(t *testing.T) {
+	assert.Equal(t,
+		"https://github.com/search?type=pullrequests&q=type%3Apr+is%3Aopen+author%3Auser",
+		searchWebUrl("https://github.com", "type:pr is:open author:user"))
+}
+
+func TestParseNagThresholds(t *testing.T) {
+	thresholds, err := parseNagThresholds([]string{"12h", " 2d", "5d"})
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Duration{12 * time.Hour, 48 * time.Hour, 120 * time.Hour}, thresholds)
+
+	thresholds, err = parseNagThresholds(nil)
+	assert.Nil(t, err)
+	assert.Empty(t, thresholds)
+
+	for _, values := range [][]string{{"1x"}, {"3d", "1d"}, {"0d"}, {"1d", "2d", "3d", "4d"}} {
+		_, err = parseNagThresholds(values)
+		assert.Error(t, err, values)
+	}
+}
+
+func TestNagBadge(t *testing.T) {
+	thresholds := []time.Duration{24 * time.Hour, 72 * time.Hour, 168 * time.Hour}
+
+	assert.Equal(t, "", nagBadge(time.Hour, thresholds))
+	assert.Equal(t, "🕐", nagBadge(24*time.Hour, thresholds))
+	assert.Equal(t, "🕕", nagBadge(100*time.Hour, thresholds))
+	assert.Equal(t, "🔥", nagBadge(1000*time.Hour, thresholds))
+	assert.Equal(t, "", nagBadge(1000*time.Hour, nil))
+}
+
+func TestAwaitingReview(t *testing.T) {
+	author, other := &github.User{Login: github.String("aaa")}, &github.User{Login: github.String("bbb")}
+
+	assert.True(t, awaitingReview("aaa", nil))
+	assert.True(t, awaitingReview("aaa", []*github.PullRequestReview{{User: author}}))
+	assert.False(t, awaitingReview("aaa", []*github.PullRequestReview{{User: author}, {User: other}}))
+}
+
+func TestFormatWaiting(t *testing.T) {
+	assert.Equal(t, "5h", formatWaiting(5*time.Hour+30*time.Minute))
+	assert.Equal(t, "3d", formatWaiting(80*time.Hour))
+}
+
+func TestFormatAge(t *testing.T) {
+	assert.Equal(t, "just now", formatAge(10*time.Second))
+	assert.Equal(t, "5m ago", formatAge(5*time.Minute))
+	assert.Equal(t, "2h ago", formatAge(150*time.Minute))
+	assert.Equal(t, "1d ago", formatAge(30*time.Hour))
+}
+
+func TestParseDateStyle(t *testing.T) {
+	style, err := parseDateStyle("")
+	assert.Nil(t, err)
+	assert.Equal(t, dateStyleAbsolute, style)
+
+	style, err = parseDateStyle("relative")
+	assert.Nil(t, err)
+	assert.Equal(t, dateStyleRelative, style)
+
+	_, err = parseDateStyle("fuzzy")
+	assert.Error(t, err)
+}
+
+func TestParseDateField(t *testing.T) {
+	field, err := parseDateField("")
+	assert.Nil(t, err)
+	assert.Equal(t, dateFieldUpdated, field)
+
+	field, err = parseDateField("created")
+	assert.Nil(t, err)
+	assert.Equal(t, dateFieldCreated, field)
+
+	_, err = parseDateField("merged")
+	assert.Error(t, err)
+}
+
+func TestSortByCreated(t *testing.T) {
+	issue := func(id int64, created int64) *github.Issue {
+		c := time.UnixMilli(created)
+		return &github.Issue{ID: &id, CreatedAt: &c}
+	}
+
+	prs := []*github.Issue{issue(1, 1000), issue(2, 3000), issue(4, 2000), issue(3, 2000)}
+	sortByCreated(prs)
+
+	ids := make([]int64, len(prs))
+	for i, pr := range prs {
+		ids[i] = pr.GetID()
+	}
+	assert.Equal(t, []int64{2, 3, 4, 1}, ids)
+}
+
+func TestFilterByLabels(t *testing.T) {
+	issue := func(id int64, labels ...string) *github.Issue {
+		pr := &github.Issue{ID: &id}
+		for _, label := range labels {
+			pr.Labels = append(pr.Labels, &github.Label{Name: github.String(label)})
+		}
+		return pr
+	}
+
+	prs := []*github.Issue{issue(1), issue(2, "WIP"), issue(3, "needs-review"), issue(4, "needs-review", "wip")}
+	ids := func(prs []*github.Issue) []int64 {
+		result := make([]int64, len(prs))
+		for i, pr := range prs {
+			result[i] = pr.GetID()
+		}
+		return result
+	}
+
+	assert.Equal(t, []int64{1, 2, 3, 4}, ids(filterByLabels(prs, nil, nil)))
+	assert.Equal(t, []int64{1, 3}, ids(filterByLabels(prs, nil, []string{"wip"})))
+	assert.Equal(t, []int64{3, 4}, ids(filterByLabels(prs, []string{"Needs-Review"}, nil)))
+	assert.Equal(t, []int64{3}, ids(filterByLabels(prs, []string{"needs-review"}, []string{"WIP"})))
+}
+
+func TestWithoutBots(t *testing.T) {
+	issue := func(id int64, login, typ string) *github.Issue {
+		return &github.Issue{ID: &id, User: &github.User{Login: github.String(login), Type: github.String(typ)}}
+	}
+
+	prs := []*github.Issue{issue(1, "alice", "User"), issue(2, "dependabot[bot]", "Bot"), issue(3, "renovate[bot]", ""), issue(4, "release-app", "Bot")}
+	assert.Equal(t, []*github.Issue{prs[0]}, withoutBots(prs))
+	assert.True(t, isBot(prs[2].User))
+	assert.False(t, isBot(nil))
+}
+
+func TestExcludeArchived(t *testing.T) {
+	assert.Equal(t, "type:pr is:open author:bob archived:false", excludeArchived("type:pr is:open author:bob"))
+
+	issue := func(id int64, archived bool) *github.Issue {
+		return &github.Issue{ID: &id, Repository: &github.Repository{Archived: &archived}}
+	}
+
+	prs := []*github.Issue{issue(1, false), issue(2, true), issue(3, false), {ID: github.Int64(4)}}
+	assert.Equal(t, []*github.Issue{prs[0], prs[3]}, withoutArchived(prs, map[int64]bool{3: true}))
+	assert.Equal(t, []*github.Issue{prs[0], prs[2], prs[3]}, withoutArchived(prs, nil))
+}
+
+func TestSparkline(t *testing.T) {
+	now := time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)
+	at := func(hoursAgo int) time.Time { return now.Add(-time.Duration(hoursAgo) * time.Hour) }
+
+	assert.Equal(t, "", sparkline(nil, now))
+	assert.Equal(t, "", sparkline([]time.Time{at(24 * 8)}, now))
+	assert.Equal(t, "▁▁▁▁▁▁█", sparkline([]time.Time{at(1)}, now))
+	assert.Equal(t, "▄▁▁▁▁█▁", sparkline([]time.Time{at(24*6 + 1), at(25), at(30)}, now))
+}
+
+func TestParseGroupBy(t *testing.T) {
+	groupBy, err := parseGroupBy("")
+	assert.Nil(t, err)
+	assert.Equal(t, groupByNone, groupBy)
+
+	groupBy, err = parseGroupBy("repo")
+	assert.Nil(t, err)
+	assert.Equal(t, groupByRepo, groupBy)
+
+	_, err = parseGroupBy("org")
+	assert.EqualError(t, err, "invalid grouping: org\nexpected one of: none,repo,date,turn")
+}
+
+func TestIsMyTurn(t *testing.T) {
+	teams := []string{"org/core"}
+
+	// own pull requests
+	assert.False(t, isMyTurn(&prView{Mine: true}, "alice", nil, teams))
+	assert.True(t, isMyTurn(&prView{Mine: true, ReviewState: "✅❌"}, "alice", nil, teams))
+	assert.True(t, isMyTurn(&prView{Mine: true, ReviewState: "✅", Approvals: 1}, "alice", nil, teams))
+	assert.False(t, isMyTurn(&prView{Mine: true, ReviewState: "✅", Approvals: 1, RequiredApprovals: 2}, "alice", nil, teams))
+
+	// pull requests of others, without details
+	assert.True(t, isMyTurn(&prView{}, "alice", nil, teams))
+	assert.True(t, isMyTurn(&prView{MyReview: "COMMENTED"}, "alice", nil, teams))
+	assert.False(t, isMyTurn(&prView{MyReview: "APPROVED"}, "alice", nil, teams))
+
+	// and with them
+	assert.True(t, isMyTurn(&prView{MyReview: "CHANGES_REQUESTED"}, "alice", &prDetails{RequestedReviewers: []string{"alice"}}, teams))
+	assert.True(t, isMyTurn(&prView{}, "alice", &prDetails{RequestedReviewers: []string{"bob", "org/core"}}, teams))
+	assert.False(t, isMyTurn(&prView{}, "alice", &prDetails{RequestedReviewers: []string{"bob", "org/docs"}}, teams))
+	assert.False(t, isMyTurn(&prView{}, "alice", &prDetails{RequestedReviewers: []string{"other/core"}}, teams))
+}
+
+func TestSortByTurn(t *testing.T) {
+	prs := []*prView{{ID: 1}, {ID: 2, MyTurn: true}, {ID: 3}, {ID: 4, MyTurn: true}}
+
+	ids := make([]int64, 0)
+	for _, pr := range sortByTurn(prs) {
+		ids = append(ids, pr.ID)
+	}
+	assert.Equal(t, []int64{2, 4, 1, 3}, ids)
+}
+
+func TestParseDateGroupLabels(t *testing.T) {
+	labels, err := parseDateGroupLabels(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Today", "Yesterday", "This week", "Older"}, dateGroupLabels(labels))
+
+	labels, err = parseDateGroupLabels([]string{"Heute", " Gestern", "Diese Woche", "Älter"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Heute", "Gestern", "Diese Woche", "Älter"}, dateGroupLabels(labels))
+
+	_, err = parseDateGroupLabels([]string{"Heute", "Gestern"})
+	assert.EqualError(t, err, "expected 4 date group labels, got 2\nlike Today,Yesterday,This week,Older")
+}
+
+func TestDateGroup(t *testing.T) {
+	zone := time.FixedZone("UTC+2", 2*60*60)
+	now := time.Date(2023, 1, 15, 10, 0, 0, 0, zone)
+
+	data := map[string]time.Time{
+		"Today":     time.Date(2023, 1, 14, 22, 30, 0, 0, time.UTC),
+		"Yesterday": time.Date(2023, 1, 14, 1, 0, 0, 0, zone),
+		"This week": time.Date(2023, 1, 9, 0, 0, 0, 0, zone),
+		"Older":     time.Date(2023, 1, 8, 23, 59, 0, 0, zone),
+	}
+	for expected, updated := range data {
+		assert.Equal(t, expected, dateGroup(updated, now, zone, defaultDateGroupLabels), updated)
+	}
+}
+
+func TestParseReviewStyle(t *testing.T) {
+	style, err := parseReviewStyle("")
+	assert.Nil(t, err)
+	assert.Equal(t, reviewStyleEmoji, style)
+
+	style, err = parseReviewStyle("icons")
+	assert.Nil(t, err)
+	assert.Equal(t, reviewStyleIcons, style)
+
+	_, err = parseReviewStyle("png")
+	assert.EqualError(t, err, "invalid review style: png\nexpected one of: emoji,icons")
+}
+
+func TestParseDetailLevel(t *testing.T) {
+	level, err := parseDetailLevel("")
+	assert.Nil(t, err)
+	assert.Equal(t, detailNormal, level)
+
+	level, err = parseDetailLevel("verbose")
+	assert.Nil(t, err)
+	assert.Equal(t, detailVerbose, level)
+
+	_, err = parseDetailLevel("Verbose")
+	assert.EqualError(t, err, "invalid detail level: Verbose\nexpected one of: normal,compact,verbose")
+}
+
+func TestFormatDate(t *testing.T) {
+	now := time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "15-Jan-2023 10:00", formatDate(now.Add(-2*time.Hour), dateStyleAbsolute, "", time.UTC, now))
+	assert.Equal(t, "2h ago", formatDate(now.Add(-2*time.Hour), dateStyleRelative, "", time.UTC, now))
+	assert.Equal(t, "3d ago", formatDate(now.Add(-80*time.Hour), dateStyleRelative, "", time.UTC, now))
+
+	tokyo := time.FixedZone("JST", 9*60*60)
+	assert.Equal(t, "2023/01/15 19:00", formatDate(now.Add(-2*time.Hour), dateStyleAbsolute, "2006/01/02 15:04", tokyo, now))
+}
+
+func TestParseSearchScopes(t *testing.T) {
+	scopes, err := parseSearchScopes([]string{"org:acme", " user:octocat", ""})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"org:acme", "user:octocat"}, scopes)
+
+	for _, scope := range []string{"acme", "repo:acme/app", "org:", "org:acme corp"} {
+		_, err = parseSearchScopes([]string{scope})
+		assert.Error(t, err, scope)
+	}
+}
+
+func TestScopeQuery(t *testing.T) {
+	assert.Equal(t, "type:pr is:open author:bob", scopeQuery("type:pr is:open author:bob", nil))
+	assert.Equal(t, "type:pr is:open author:bob org:acme user:octocat", scopeQuery("type:pr is:open author:bob", []string{"org:acme", "user:octocat"}))
+}
+
+func TestLeastLoaded(t *testing.T) {
+	login, count := leastLoaded(map[string]int{"alice": 2, "bob": 1, "carol": 1, "dave": 0}, "dave")
+	assert.Equal(t, "bob", login)
+	assert.Equal(t, 1, count)
+
+	login, _ = leastLoaded(nil, "dave")
+	assert.Equal(t, "", login)
+}
+
+func TestNudgeComment(t *testing.T) {
+	assert.Equal(t, "Hi, friendly reminder: this pull request is still waiting for review 🙏", nudgeComment(&github.Reviewers{}, "org"))
+	assert.Equal(t, "Hi @alice @org/core @other/infra, friendly reminder: this pull request is still waiting for review 🙏", nudgeComment(&github.Reviewers{
+		Users: []*github.User{{Login: github.String("alice")}},
+		Teams: []*github.Team{
+			{Slug: github.String("core")},
+			{Slug: github.String("infra"), Organization: &github.Organization{Login: github.String("other")}},
+		},
+	}, "org"))
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/AndreyBozhko/go-alfred-prs/pkg/ghpr"
+	aw "github.com/deanishe/awgo"
+	"github.com/google/go-github/v48/github"
+	"golang.org/x/oauth2"
+)
+
+// ShowWhoami lists the user authenticated by the API token, the OAuth scopes
+// of the token, and the GitHub API endpoint in use, to verify the setup.
+func (wf *GithubWorkflow) ShowWhoami() error {
+	ctx := context.Background()
+
+	client, err := wf.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	user, resp, err := client.Users.Get(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	wf.NewItem("Logged in as " + user.GetLogin()).
+		Subtitle(user.GetName()).
+		Valid(false).
+		Icon(aw.IconAccount)
+
+	// fine-grained tokens (and tokens of GitHub Apps) have no OAuth scopes
+	scopes := "none (fine-grained token)"
+	if header := resp.Header.Get("X-OAuth-Scopes"); header != "" {
+		scopes = header
+	}
+	wf.NewItem("Token scopes: " + scopes).
+		Subtitle("the workflow needs the repo scope (and read:org, to search by teams)").
+		Valid(false).
+		Icon(aw.IconInfo)
+
+	wf.NewItem("API endpoint: " + wf.GitApiUrl).
+		Subtitle("use ghpr-host to change it").
+		Valid(false).
+		Icon(aw.IconInfo)
+	return nil
+}
+
+// impliedScopes are the OAuth scopes, which grant the required ones as well.
+var impliedScopes = map[string][]string{
+	"read:org": {"write:org", "admin:org"},
+}
+
+// requiredScopes are the OAuth scopes of the API token, which the workflow needs.
+// Teams (of the user, or their members, whose review workload is suggested) need read:org.
+func (wf *GithubWorkflow) requiredScopes() []string {
+	scopes := []string{"repo"}
+	if wf.QueryMyTeams || len(wf.TeamFilters) > 0 {
+		scopes = append(scopes, "read:org")
+	}
+	return scopes
+}
+
+// missingScopes returns the required scopes, which are not granted by the scopes
+// of the X-OAuth-Scopes header.
+func missingScopes(header string, required []string) []string {
+	granted := make(map[string]bool)
+	for _, scope := range strings.Split(header, ",") {
+		granted[strings.TrimSpace(scope)] = true
+	}
+
+	var missing []string
+outer:
+	for _, scope := range required {
+		if granted[scope] {
+			continue
+		}
+		for _, implied := range impliedScopes[scope] {
+			if granted[implied] {
+				continue outer
+			}
+		}
+		missing = append(missing, scope)
+	}
+	return missing
+}
+
+// checkTokenScopes verifies that GitHub accepts the API token, and that the token
+// has the required scopes, so that it does not fail later with errors like 404.
+// Fine-grained tokens have permissions per repository instead of scopes, which
+// are not checked. If GitHub cannot be reached, the token is not checked either.
+func (wf *GithubWorkflow) checkTokenScopes(ctx context.Context, token string) error {
+	// the response is not cached, since the token is not saved yet
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: wf.base})
+	client, err := ghpr.NewClient(ctx, wf.GitApiUrl, token)
+	if err != nil {
+		return err
+	}
+
+	fineGrained := isFineGrainedToken(token)
+
+	_, resp, err := client.Users.Get(ctx, "")
+	if err != nil {
+		var errResp *github.ErrorResponse
+		if errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == http.StatusUnauthorized {
+			if fineGrained && !wf.supportsFineGrainedTokens() {
+				return &alfredError{"API token is rejected by GitHub", "this GitHub Enterprise instance may not support fine-grained tokens, use a classic one"}
+			}
+			return &alfredError{"API token is rejected by GitHub", "check that the token is copied in full, and not expired"}
+		}
+		log.Println("failed to check API token:", err)
+		return nil
+	}
+
+	if fineGrained {
+		log.Println("Fine-grained API token, make sure it can read and write pull requests of your repositories")
+		return nil
+	}
+
+	header := resp.Header.Get("X-OAuth-Scopes")
+	if header == "" {
+		return nil
+	}
+
+	if missing := missingScopes(header, wf.requiredScopes()); len(missing) > 0 {
+		return &alfredError{
+			"API token lacks scopes: " + strings.Join(missing, ", "),
+			"add them at " + wf.GetBaseWebUrl() + "/settings/tokens, and run ghpr-auth again",
+		}
+	}
+	return nil
+}
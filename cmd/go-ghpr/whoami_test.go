@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	kc "github.com/deanishe/awgo/keychain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShowWhoami(t *testing.T) {
+	// given
+	url, teardown := setupFakeGitHub()
+	defer teardown()
+
+	testWf.GitApiUrl = url
+
+	kc.ErrNotFound = nil // effectively disable using keychain
+	defer func() {
+		kc.ErrNotFound = kcErr
+		testWf.Feedback.Clear()
+	}()
+
+	// when
+	testWf.Feedback.Clear()
+	assert.Nil(t, testWf.ShowWhoami())
+
+	// then
+	items := testWf.Feedback.Items
+	assert.Equal(t, 3, len(items))
+	assert.Contains(t, marshalWithoutMods(t, items[0]), `"title":"Logged in as testuser"`)
+	assert.Contains(t, marshalWithoutMods(t, items[1]), `"title":"Token scopes: read:org, repo"`)
+	assert.Contains(t, marshalWithoutMods(t, items[2]), `"title":"API endpoint: `+url+`"`)
+}
+
+func TestMissingScopes(t *testing.T) {
+	assert.Nil(t, missingScopes("read:org, repo", []string{"repo", "read:org"}))
+	assert.Nil(t, missingScopes("repo, admin:org", []string{"repo", "read:org"}))
+	assert.Equal(t, []string{"repo"}, missingScopes("read:org", []string{"repo", "read:org"}))
+	assert.Equal(t, []string{"repo", "read:org"}, missingScopes("gist", []string{"repo", "read:org"}))
+}
+
+func TestRequiredScopes(t *testing.T) {
+	wf := newTestWorkflow(t)
+	assert.Equal(t, []string{"repo"}, wf.requiredScopes())
+
+	wf.TeamFilters = []string{"org/team"}
+	assert.Equal(t, []string{"repo", "read:org"}, wf.requiredScopes())
+
+	wf.TeamFilters, wf.QueryMyTeams = nil, true
+	assert.Equal(t, []string{"repo", "read:org"}, wf.requiredScopes())
+}
+
+func TestCheckTokenScopes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer classic":
+			w.Header().Set("X-OAuth-Scopes", "gist, read:org")
+		case "Bearer fine-grained":
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+		w.Write([]byte(`{"login": "testuser"}`))
+	}))
+	defer server.Close()
+
+	wf := newTestWorkflow(t)
+	wf.GitApiUrl = server.URL + "/api/v3/"
+	ctx := context.Background()
+
+	err := wf.checkTokenScopes(ctx, "classic")
+	assert.Equal(t, &alfredError{"API token lacks scopes: repo", "add them at " + server.URL + "/settings/tokens, and run ghpr-auth again"}, err)
+
+	assert.Nil(t, wf.checkTokenScopes(ctx, "fine-grained"))
+
+	err = wf.checkTokenScopes(ctx, "expired")
+	assert.Equal(t, &alfredError{"API token is rejected by GitHub", "check that the token is copied in full, and not expired"}, err)
+
+	err = wf.checkTokenScopes(ctx, fineGrainedTokenPrefix+"unsupported")
+	assert.Equal(t, &alfredError{"API token is rejected by GitHub", "this GitHub Enterprise instance may not support fine-grained tokens, use a classic one"}, err)
+}
@@ -0,0 +1,1808 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/AndreyBozhko/go-alfred-prs/pkg/ghpr"
+	"github.com/AndreyBozhko/go-alfred-prs/pkg/prcache"
+	aw "github.com/deanishe/awgo"
+	kc "github.com/deanishe/awgo/keychain"
+	"github.com/deanishe/awgo/update"
+	"github.com/google/go-github/v48/github"
+	"go.deanishe.net/env"
+	"golang.org/x/oauth2"
+	"golang.org/x/sync/errgroup"
+)
+
+// Workflow flags and arguments.
+var (
+	attempt             int
+	maxAttempts         int
+	cmdApprove          bool
+	cmdAssignMe         bool
+	cmdAuth             bool
+	cmdBackup           bool
+	cmdBroadenRoles     bool
+	cmdCacheGC          bool
+	cmdCheck            bool
+	cmdChooseReviewers  bool
+	cmdDisplay          bool
+	cmdDoctor           bool
+	cmdExpandGroup      bool
+	cmdExport           bool
+	cmdHandoff          bool
+	cmdHelpCommands     bool
+	cmdHelpKeys         bool
+	cmdInspect          bool
+	cmdLogout           bool
+	cmdNudge            bool
+	cmdOpenAll          bool
+	cmdOpenCommand      bool
+	cmdOpenDoctor       bool
+	cmdRateLimits       bool
+	cmdRecent           bool
+	cmdRefresh          bool
+	cmdRefreshOrgs      bool
+	cmdRequestReviewers bool
+	cmdRestore          bool
+	cmdSnapshot         bool
+	cmdSnapshotDiff     bool
+	cmdSnooze           bool
+	cmdStatsShare       bool
+	cmdToggleDraft      bool
+	cmdUpdatePRs        bool
+	cmdUpdatePRStatus   bool
+	cmdUpdateWorkload   bool
+	cmdWhoami           bool
+	format              string
+	query               string
+	restoreCommands     bool
+	savedSearch         string
+	templateFile        string
+	view                string
+)
+
+// Cache keys used by the workflow.
+const (
+	wfAuthTokenKey       = "gh-auth-token"
+	wfMintedTokenKey     = "gh-minted-token"
+	wfUserInfoKey        = "gh-user-info"
+	wfPullRequestsKey    = prcache.PullRequestsKey
+	wfSearchTotalKey     = prcache.SearchTotalKey
+	wfBranchesKey        = "gh-branches"
+	wfReviewsKey         = prcache.ReviewsKey
+	wfDescriptionsKey    = "gh-description-hints"
+	wfRepoLanguageKey    = "gh-repo-language-"
+	wfSnoozedKey         = "gh-snoozed"
+	wfSyncErrorKey       = "gh-sync-error"
+	wfSyncTimesKey       = "gh-sync-times"
+	wfMaintenanceKey     = "gh-maintenance"
+	wfRateLimitKey       = "gh-rate-limit"
+	wfThrottledUntilKey  = "gh-throttled-until"
+	wfSSOURLKey          = "gh-sso-url"
+	wfLastViewedKey      = "gh-last-viewed"
+	wfDetailsKey         = "gh-details"
+	wfFailedChecksKey    = "gh-failed-checks"
+	wfWorkloadKey        = "gh-review-workload"
+	wfMembershipsKey     = "gh-memberships"
+	wfApprovalsKey       = "gh-required-approvals-"
+	wfApiEndpointKey     = "gh-api-endpoint-"
+	wfInstanceVersionKey = "gh-instance-version-"
+	wfConfigSnapshotKey  = "gh-config-snapshot"
+	wfUsageStatsKey      = "gh-usage-stats"
+	wfCacheVersionKey    = "gh-cache-version"
+)
+
+// Variables that can be set in the workflow feedback.
+const (
+	fbCurrentAttemptKey    = "GH_CURRENT_ATTEMPT"
+	fbErrorOccurredKey     = "GH_ERROR_OCCURRED"
+	fbActionKey            = "GH_ACTION"
+	fbNotifyTitleKey       = "GH_NOTIFY_TITLE"
+	fbPullRequestIdKey     = "GH_PR_ID"
+	fbPullRequestRepoKey   = "GH_PR_REPO"
+	fbPullRequestNumberKey = "GH_PR_NUMBER"
+)
+
+// Actions that can be triggered by the workflow feedback.
+const (
+	actionApprove          = "approve"
+	actionAssignMe         = "assign_me"
+	actionBroadenRoles     = "broaden_roles"
+	actionRequestReviewers = "request_reviewers"
+	actionCopy             = "copy"
+	actionExpandGroup      = "expand_group"
+	actionHandoff          = "handoff"
+	actionLogout           = "logout"
+	actionNudge            = "nudge"
+	actionOpenAll          = "open_all"
+	actionOpenCommand      = "open_command"
+	actionOpenDoctor       = "open_doctor"
+	actionRefresh          = "refresh"
+	actionRefreshOrgs      = "refresh_orgs"
+	actionSnooze           = "snooze"
+	actionStatsShare       = "stats_share"
+	actionToggleDraft      = "toggle_draft"
+)
+
+// workflowConfig holds environment variables used by the workflow.
+type workflowConfig struct {
+	ActionMap            []string      `env:"ACTION_MAP"`
+	AllowUpdates         bool          `env:"CHECK_FOR_UPDATES"`
+	CACert               string        `env:"GIT_CA_CERT"`
+	CacheMaxAge          time.Duration `env:"CACHE_MAX_AGE"`
+	CheckDescriptions    bool          `env:"CHECK_DESCRIPTIONS"`
+	CompressCache        bool          `env:"CACHE_COMPRESSION"`
+	CustomApiUrl         string        `env:"GIT_API_URL"`
+	CustomWebUrl         string        `env:"GIT_WEB_URL"`
+	DateField            string        `env:"DATE_FIELD"`
+	DateFormat           string        `env:"DATE_FORMAT"`
+	DateGroupLabels      []string      `env:"DATE_GROUP_LABELS"`
+	DateStyle            string        `env:"DATE_STYLE"`
+	DeltaFetch           bool          `env:"DELTA_FETCH"`
+	DescriptionSections  []string      `env:"DESCRIPTION_SECTIONS"`
+	DetailLevel          string        `env:"DETAIL_LEVEL"`
+	ExcludeArchived      bool          `env:"EXCLUDE_ARCHIVED"`
+	ExcludeLabels        []string      `env:"EXCLUDE_LABELS"`
+	FetchReviews         bool          `env:"SHOW_REVIEWS"`
+	GitApiUrl            string        `env:"GIT_BASE_URL"`
+	GroupBy              string        `env:"GROUP_BY"`
+	GroupUpdates         bool          `env:"GROUP_DEPENDENCY_UPDATES"`
+	HideBots             bool          `env:"HIDE_BOT_PRS"`
+	Hooks                []string      `env:"HOOKS" exec:"true"`
+	HTTPSProxy           string        `env:"HTTPS_PROXY" secret:"true"`
+	IncludeLabels        []string      `env:"INCLUDE_LABELS"`
+	ItemSubtitleTemplate string        `env:"ITEM_SUBTITLE_TEMPLATE"`
+	ItemTitleTemplate    string        `env:"ITEM_TITLE_TEMPLATE"`
+	ItemUIDs             bool          `env:"ITEM_UIDS"`
+	Languages            []string      `env:"LANGUAGE_FILTER"`
+	MaxItems             int           `env:"MAX_ITEMS"`
+	NagThresholds        []string      `env:"NAG_THRESHOLDS"`
+	NoProxy              string        `env:"NO_PROXY"`
+	QueryMyTeams         bool          `env:"QUERY_BY_MY_TEAMS"`
+	QuietRefresh         bool          `env:"QUIET_REFRESH"`
+	RecentDays           int           `env:"RECENT_DAYS"`
+	ReviewConcurrency    int           `env:"REVIEW_FETCH_CONCURRENCY"`
+	ReviewStyle          string        `env:"REVIEW_STYLE"`
+	RoleFilters          []string      `env:"QUERY_BY_ROLES"`
+	SearchScopes         []string      `env:"SEARCH_SCOPES"`
+	ShowActivity         bool          `env:"SHOW_ACTIVITY"`
+	ShowAvatars          bool          `env:"SHOW_AVATARS"`
+	ShowDiffSize         bool          `env:"SHOW_DIFF_SIZE"`
+	ShowLabels           bool          `env:"SHOW_LABELS"`
+	ShowReviewers        bool          `env:"SHOW_REVIEWERS"`
+	ShowSummary          bool          `env:"SHOW_SUMMARY"`
+	ShowTargetBranch     bool          `env:"SHOW_TARGET_BRANCH"`
+	SnoozeDays           int           `env:"SNOOZE_DAYS"`
+	TargetUser           string        `env:"TARGET_USER"`
+	TeamFilters          []string      `env:"QUERY_BY_TEAMS"`
+	TimeZone             string        `env:"TIMEZONE"`
+	TokenCommand         string        `env:"TOKEN_COMMAND" exec:"true"`
+	TokenReference       string        `env:"TOKEN_REFERENCE"`
+	TokenSource          string        `env:"TOKEN_SOURCE"`
+	UsageStats           bool          `env:"USAGE_STATS"`
+
+	// saved searches by name, parsed from numbered SAVED_SEARCH_<N> variables
+	SavedSearches map[string]string `env:"-"`
+}
+
+// Background tasks, which refresh pull requests, and the review workload of teammates.
+const (
+	taskUpdate         = "--update"
+	taskUpdateWorkload = "--update_workload"
+)
+
+// backgroundContext is the context of background tasks, which is cancelled once the
+// workflow is terminated (or interrupted), or runs for longer than backgroundTaskTimeout,
+// so that abandoned tasks stop calling GitHub API.
+func backgroundContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	ctx, cancel := context.WithTimeout(ctx, backgroundTaskTimeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// Common time and duration parameters used by the workflow.
+const (
+	rerunDelayDefault        = 3 * time.Second
+	fullSyncMaxAge           = time.Hour
+	deltaOverlap             = time.Minute
+	maintenanceRetryDelay    = 10 * time.Minute
+	slowDownRetryDelay       = time.Minute
+	repoLanguageMaxAge       = 7 * 24 * time.Hour
+	workloadMaxAge           = time.Hour
+	membershipsMaxAge        = 24 * time.Hour
+	avatarMaxAge             = 7 * 24 * time.Hour
+	branchProtectionMaxAge   = 24 * time.Hour
+	httpCacheMaxAge          = 7 * 24 * time.Hour
+	apiEndpointMaxAge        = 30 * 24 * time.Hour
+	instanceVersionMaxAge    = 24 * time.Hour
+	backgroundTaskTimeout    = 5 * time.Minute
+	defaultSnoozeDays        = 3
+	defaultReviewConcurrency = 8
+	repoFetchConcurrency     = 4
+	searchConcurrency        = 2
+)
+
+// Common workflow errors.
+var (
+	errMissingUrl = errors.New("github url is not set")
+	errTokenEmpty = errors.New("token must not be empty")
+	errNoPRChosen = errors.New("pull request is not selected")
+	errNoLogins   = errors.New("reviewers are not specified")
+)
+
+// GithubWorkflow is a wrapper around aw.Workflow.
+type GithubWorkflow struct {
+	// Alfred workflow API
+	*aw.Workflow
+	// additional configs
+	*workflowConfig
+	// result of an action command
+	notification *aw.ArgVars
+	// typed access to cached data
+	prs      PRStore
+	reviews  ReviewStore
+	details  DetailStore
+	branches BranchStore
+	state    StateStore
+	snoozes  SnoozeStore
+	stats    StatsStore
+	// transport of all requests to GitHub, through the configured proxy and CA
+	base *baseTransport
+	// rate limit of GitHub API, observed by clients
+	rates *rateLimitTransport
+	// prefix of the cache keys of the configured host, unless it is github.com
+	namespace string
+	// name of the saved search, which is displayed and refreshed instead of configured queries
+	savedSearch string
+}
+
+// newGithubWorkflow creates a workflow with the given configuration.
+func newGithubWorkflow(wf *aw.Workflow, cfg *workflowConfig) *GithubWorkflow {
+	store := newWorkflowStore(wf, cfg, "")
+	data := newCacheStore(prcache.NewFileBackend(wf.Data.Dir), "")
+	base := &baseTransport{cfg: cfg}
+
+	return &GithubWorkflow{
+		Workflow:       wf,
+		workflowConfig: cfg,
+		prs:            store,
+		reviews:        store,
+		details:        store,
+		branches:       store,
+		state:          store,
+		snoozes:        data,
+		stats:          data,
+		base:           base,
+		rates:          &rateLimitTransport{base: newHTTPCache(wf, base)},
+	}
+}
+
+// newWorkflowStore creates the store of cached pull requests and workflow state,
+// whose keys are prefixed with namespace.
+func newWorkflowStore(wf *aw.Workflow, cfg *workflowConfig, namespace string) *cacheStore {
+	// only the largest entries are compressed, which shrinks them on disk, at the cost
+	// of decompressing them on every keystroke
+	compress := func(name string) bool {
+		name = strings.TrimPrefix(name, namespace)
+		return cfg.CompressCache && (name == wfPullRequestsKey || name == wfReviewsKey)
+	}
+
+	return newCacheStore(prcache.NewGzipBackend(prcache.NewFileBackend(wf.Cache.Dir), compress), namespace)
+}
+
+// validateRoleFilters parses user roles which will be used to search for open pull requests.
+func (wf *GithubWorkflow) validateRoleFilters() error {
+	filters, err := parseRoleFilters(wf.RoleFilters)
+	if err != nil {
+		return err
+	}
+
+	wf.RoleFilters = filters
+	return nil
+}
+
+// validateTeamFilters parses teams which will be used to search for pull requests
+// where a review was requested from the team.
+func (wf *GithubWorkflow) validateTeamFilters() error {
+	teams, err := parseTeamFilters(wf.TeamFilters)
+	if err != nil {
+		return err
+	}
+
+	wf.TeamFilters = teams
+	return nil
+}
+
+// validateDateStyle checks the style, the layout and the time zone of timestamps
+// of pull requests, and which of them is shown. By default, the update times
+// are shown as absolute timestamps, in the local time zone.
+func (wf *GithubWorkflow) validateDateStyle() error {
+	style, err := parseDateStyle(wf.DateStyle)
+	if err != nil {
+		return err
+	}
+	wf.DateStyle = style
+
+	field, err := parseDateField(wf.DateField)
+	if err != nil {
+		return err
+	}
+	wf.DateField = field
+
+	if wf.DateFormat == "" {
+		wf.DateFormat = defaultDateFormat
+	}
+
+	if _, err = time.LoadLocation(wf.TimeZone); err != nil {
+		return &alfredError{"invalid time zone: " + wf.TimeZone, "expected a name like Local, UTC or Europe/Berlin"}
+	}
+	return nil
+}
+
+// validateDetailLevel checks how much metadata is shown for pull requests.
+func (wf *GithubWorkflow) validateDetailLevel() error {
+	level, err := parseDetailLevel(wf.DetailLevel)
+	if err != nil {
+		return err
+	}
+
+	wf.DetailLevel = level
+	return nil
+}
+
+// validateTokenSource checks where the API token is kept,
+// and that the token can be found in 1Password, if it is kept there.
+func (wf *GithubWorkflow) validateTokenSource() error {
+	source, err := parseTokenSource(wf.TokenSource)
+	if err != nil {
+		return err
+	}
+
+	if source == tokenSourceOp && wf.TokenReference == "" {
+		return &alfredError{"token reference is not set", "expected a 1Password secret reference like op://Private/GitHub/token"}
+	}
+
+	wf.TokenSource = source
+	return nil
+}
+
+// validateReviewStyle checks whether review states are shown as emoji or icons.
+func (wf *GithubWorkflow) validateReviewStyle() error {
+	style, err := parseReviewStyle(wf.ReviewStyle)
+	if err != nil {
+		return err
+	}
+
+	wf.ReviewStyle = style
+	return nil
+}
+
+// validateGroupBy checks how pull requests are grouped in the sorted view,
+// and the headers of recency groups.
+func (wf *GithubWorkflow) validateGroupBy() error {
+	groupBy, err := parseGroupBy(wf.GroupBy)
+	if err != nil {
+		return err
+	}
+	wf.GroupBy = groupBy
+
+	labels, err := parseDateGroupLabels(wf.DateGroupLabels)
+	if err != nil {
+		return err
+	}
+	wf.DateGroupLabels = labels
+	return nil
+}
+
+// location returns the time zone, in which timestamps of pull requests are shown.
+func (wf *GithubWorkflow) location() *time.Location {
+	if wf.TimeZone == "" {
+		return time.Local
+	}
+
+	zone, err := time.LoadLocation(wf.TimeZone)
+	if err != nil {
+		return time.Local
+	}
+	return zone
+}
+
+// validateSearchScopes parses the organizations and users the searches are limited to,
+// and the user the roles apply to, if it is not the owner of the API token
+// (like when the workflow authenticates as a service account).
+func (wf *GithubWorkflow) validateSearchScopes() error {
+	scopes, err := parseSearchScopes(wf.SearchScopes)
+	if err != nil {
+		return err
+	}
+	wf.SearchScopes = scopes
+
+	wf.TargetUser = strings.TrimSpace(wf.TargetUser)
+	if wf.TargetUser != "" && !loginPattern.MatchString(wf.TargetUser) {
+		return &alfredError{"invalid target user: " + wf.TargetUser, "expected a GitHub login, like octocat"}
+	}
+	return nil
+}
+
+// targetLogin returns the login the roles apply to: the target user,
+// if it is configured, or the owner of the API token otherwise.
+func (wf *GithubWorkflow) targetLogin(user *github.User) string {
+	if wf.TargetUser != "" {
+		return wf.TargetUser
+	}
+	return user.GetLogin()
+}
+
+// maxMyTeams caps the cached teams of the user, which are searched by QUERY_BY_MY_TEAMS,
+// since each of them takes a search of its own (out of 30 per minute).
+const maxMyTeams = 10
+
+// teams returns the teams to search review requests of: the ones from QUERY_BY_TEAMS
+// and, if QUERY_BY_MY_TEAMS is set, up to maxMyTeams cached teams of the user.
+func (wf *GithubWorkflow) teams() []string {
+	if !wf.QueryMyTeams {
+		return wf.TeamFilters
+	}
+
+	memberships, err := wf.state.LoadMemberships()
+	if err != nil {
+		log.Println("failed to load memberships:", err)
+		return wf.TeamFilters
+	}
+
+	teams := append([]string{}, wf.TeamFilters...)
+	seen := make(map[string]bool)
+	for _, team := range teams {
+		seen[team] = true
+	}
+	added := 0
+	for _, team := range memberships.Teams {
+		if seen[team] {
+			continue
+		}
+		if added == maxMyTeams {
+			log.Printf("Searching only %d of your teams, add the others to QUERY_BY_TEAMS", maxMyTeams)
+			break
+		}
+		seen[team] = true
+		teams = append(teams, team)
+		added++
+	}
+	return teams
+}
+
+// searchQueries creates the search queries for the user,
+// limited to the search scopes.
+func (wf *GithubWorkflow) searchQueries(user *github.User) []string {
+	if wf.savedSearch != "" {
+		return wf.savedSearchQueries(user)
+	}
+
+	queries := ghpr.SearchQueries(wf.RoleFilters, wf.teams(), wf.targetLogin(user))
+	for i, query := range queries {
+		queries[i] = scopeQuery(query, wf.SearchScopes)
+		if wf.ExcludeArchived {
+			queries[i] = excludeArchived(queries[i])
+		}
+	}
+	return queries
+}
+
+// combinedSearchQuery creates a single search query, which matches
+// the same pull requests as all search queries for the user.
+// It is empty, if there is nothing to search for.
+func (wf *GithubWorkflow) combinedSearchQuery(user *github.User) string {
+	if wf.savedSearch != "" {
+		queries := wf.savedSearchQueries(user)
+		switch len(queries) {
+		case 0:
+			return ""
+		case 1:
+			return queries[0]
+		}
+		for i, query := range queries {
+			queries[i] = "(" + strings.TrimPrefix(query, "type:pr is:open ") + ")"
+		}
+		return "type:pr is:open (" + strings.Join(queries, " OR ") + ")"
+	}
+	teams := wf.teams()
+	if len(wf.RoleFilters) == 0 && len(teams) == 0 {
+		return ""
+	}
+	combined := scopeQuery(combineSearchQueries(wf.RoleFilters, teams, wf.targetLogin(user)), wf.SearchScopes)
+	if wf.ExcludeArchived {
+		combined = excludeArchived(combined)
+	}
+	return combined
+}
+
+// validateBaseUrl parses git url from an environment variable,
+// updates the workflow, and invalidates workflow cache if needed.
+func (wf *GithubWorkflow) validateBaseUrl() error {
+	if wf.GitApiUrl == "" {
+		return errMissingUrl
+	}
+
+	host, err := parseGithubHost(wf.GitApiUrl)
+	if err != nil {
+		return err
+	}
+
+	wf.GitApiUrl = wf.resolveApiUrl(wf.GitApiUrl, host)
+
+	// explicit urls take precedence over the ones derived from the host
+	if wf.CustomApiUrl != "" {
+		apiUrl, err := parseCustomUrl(wf.CustomApiUrl)
+		if err != nil {
+			return err
+		}
+		wf.GitApiUrl = apiUrl + "/"
+	}
+	if wf.CustomWebUrl != "" {
+		webUrl, err := parseCustomUrl(wf.CustomWebUrl)
+		if err != nil {
+			return err
+		}
+		wf.CustomWebUrl = webUrl
+	}
+
+	wf.useHostNamespace()
+
+	// remove previously cached user info and PRs
+	// if current git url does not match cached url
+	user, err := wf.state.LoadUser()
+	if err == nil && !strings.HasPrefix(user.GetHTMLURL(), wf.GetBaseWebUrl()) {
+		return wf.ClearCache()
+	}
+
+	return nil
+}
+
+// loadConfig binds environment variables to the workflow configuration and validates them.
+func (wf *GithubWorkflow) loadConfig() error {
+	if err := env.Bind(wf.workflowConfig); err != nil {
+		return &alfredError{"cannot parse environment variables", err.Error()}
+	}
+
+	if err := wf.validateBaseUrl(); err != nil {
+		return err
+	}
+	if err := wf.validateRoleFilters(); err != nil {
+		return err
+	}
+	if err := wf.validateTeamFilters(); err != nil {
+		return err
+	}
+	if err := wf.validateSearchScopes(); err != nil {
+		return err
+	}
+	if _, err := parseActionMap(wf.ActionMap); err != nil {
+		return err
+	}
+	if err := parseProxyUrl(wf.HTTPSProxy); err != nil {
+		return err
+	}
+	if wf.CACert != "" {
+		if _, err := loadCACert(wf.CACert); err != nil {
+			return err
+		}
+	}
+	if err := wf.validateDateStyle(); err != nil {
+		return err
+	}
+	if err := wf.validateDetailLevel(); err != nil {
+		return err
+	}
+	if err := wf.validateReviewStyle(); err != nil {
+		return err
+	}
+	if err := wf.validateTokenSource(); err != nil {
+		return err
+	}
+	if err := wf.validateGroupBy(); err != nil {
+		return err
+	}
+	if err := wf.validateSavedSearches(); err != nil {
+		return err
+	}
+	if _, err := parseItemTemplates(wf.ItemTitleTemplate, wf.ItemSubtitleTemplate); err != nil {
+		return err
+	}
+	_, err := parseNagThresholds(wf.NagThresholds)
+	return err
+}
+
+// configSnapshot is a validated workflow configuration, along with
+// the raw environment variables it was created from.
+type configSnapshot struct {
+	Environ map[string]string
+	Config  workflowConfig
+}
+
+// storeConfigSnapshot caches current (already validated) workflow configuration.
+// The values of secret variables are not cached, but only their hashes.
+func (wf *GithubWorkflow) storeConfigSnapshot() error {
+	config := *wf.workflowConfig
+	setSecretConfig(&config, func(string) string { return "" })
+
+	return wf.state.StoreConfigSnapshot(&configSnapshot{
+		Environ: hashSecretConfigEnv(lookupConfigEnv()),
+		Config:  config,
+	})
+}
+
+// loadConfigSnapshot restores workflow configuration from cache, and reports
+// whether it succeeded. The snapshot is ignored if it is missing or stale,
+// i.e. if any of the environment variables changed since it was created.
+func (wf *GithubWorkflow) loadConfigSnapshot() bool {
+	snapshot, err := wf.state.LoadConfigSnapshot()
+	if err != nil {
+		return false
+	}
+
+	if !reflect.DeepEqual(snapshot.Environ, hashSecretConfigEnv(lookupConfigEnv())) {
+		return false
+	}
+
+	*wf.workflowConfig = snapshot.Config
+	setSecretConfig(wf.workflowConfig, os.Getenv)
+	wf.useHostNamespace()
+	return true
+}
+
+// lookupConfigEnv reads raw values of the environment variables used by the workflow config.
+func lookupConfigEnv() map[string]string {
+	result := make(map[string]string)
+
+	typ := reflect.TypeOf(workflowConfig{})
+	for i := 0; i < typ.NumField(); i++ {
+		key := typ.Field(i).Tag.Get("env")
+		if v, ok := os.LookupEnv(key); ok {
+			result[key] = v
+		}
+	}
+	for key, v := range lookupSavedSearchEnv() {
+		result[key] = v
+	}
+
+	return result
+}
+
+// secretConfigEnv lists the environment variables of the workflow config (tagged as secret),
+// which may hold credentials, like the password of the proxy in HTTPS_PROXY.
+func secretConfigEnv() []string {
+	var result []string
+
+	typ := reflect.TypeOf(workflowConfig{})
+	for i := 0; i < typ.NumField(); i++ {
+		if typ.Field(i).Tag.Get("secret") == "true" {
+			result = append(result, typ.Field(i).Tag.Get("env"))
+		}
+	}
+	return result
+}
+
+// executableConfigEnv lists the environment variables of the workflow config (tagged as exec),
+// whose values are executed by the workflow, like the shell command in TOKEN_COMMAND.
+func executableConfigEnv() []string {
+	var result []string
+
+	typ := reflect.TypeOf(workflowConfig{})
+	for i := 0; i < typ.NumField(); i++ {
+		if typ.Field(i).Tag.Get("exec") == "true" {
+			result = append(result, typ.Field(i).Tag.Get("env"))
+		}
+	}
+	return result
+}
+
+// hashSecretConfigEnv replaces the values of secret variables with their hashes,
+// which still tell whether the variables changed.
+func hashSecretConfigEnv(environ map[string]string) map[string]string {
+	for _, key := range secretConfigEnv() {
+		if v, ok := environ[key]; ok {
+			environ[key] = fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(v)))
+		}
+	}
+	return environ
+}
+
+// setSecretConfig sets the (string) fields of the config tagged as secret
+// to the values of their variables.
+func setSecretConfig(cfg *workflowConfig, value func(key string) string) {
+	v := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if field := v.Type().Field(i); field.Tag.Get("secret") == "true" {
+			v.Field(i).SetString(value(field.Tag.Get("env")))
+		}
+	}
+}
+
+// GetBaseWebUrl retrieves web URL of the GitHub instance from workflow data.
+func (wf *GithubWorkflow) GetBaseWebUrl() string {
+	if wf.CustomWebUrl != "" {
+		return wf.CustomWebUrl
+	}
+
+	host, err := parseGithubHost(wf.GitApiUrl)
+	if err != nil {
+		return wf.GitApiUrl
+	}
+	return host.WebUrl
+}
+
+// tokenEnvVars are the environment (or workflow) variables, which hold the API token
+// for users, who cannot keep it in keychain, in order of precedence.
+var tokenEnvVars = []string{"GHPR_TOKEN", "GITHUB_TOKEN"}
+
+// GetToken retrieves the API token from user's keychain,
+// or from the token command, or from 1Password, if either is configured.
+// If keychain has no token, it is looked up in the environment.
+func (wf *GithubWorkflow) GetToken() (string, error) {
+	if wf.TokenCommand != "" {
+		token, err := newCommandTokenSource(wf.TokenCommand, wf.Keychain).Token()
+		if err != nil {
+			return "", err
+		}
+		return token.AccessToken, nil
+	}
+	if wf.TokenSource == tokenSourceOp {
+		return readOnePasswordToken(wf.TokenReference)
+	}
+
+	token, err := wf.Keychain.Get(wf.tokenKey())
+	if err != nil && err == kc.ErrNotFound {
+		if token, err := wf.migrateLegacyToken(); err == nil {
+			return token, nil
+		}
+		if token := tokenFromEnv(); token != "" {
+			return token, nil
+		}
+	}
+	return token, err
+}
+
+// tokenKey is the key of the API token in user's keychain, by the host of the GitHub
+// API (without its 'api.' subdomain), so that switching GIT_BASE_URL does not reuse
+// the token of another host, while changing only GIT_WEB_URL keeps the token.
+func (wf *GithubWorkflow) tokenKey() string {
+	host := apiHost(wf.GitApiUrl)
+	if host == "" {
+		return wfAuthTokenKey
+	}
+	return wfAuthTokenKey + "@" + host
+}
+
+// migrateLegacyToken moves the API token, which was saved in user's keychain
+// regardless of the host, under the key of the current host.
+func (wf *GithubWorkflow) migrateLegacyToken() (string, error) {
+	key := wf.tokenKey()
+	if key == wfAuthTokenKey {
+		return "", kc.ErrNotFound
+	}
+
+	token, err := wf.Keychain.Get(wfAuthTokenKey)
+	if err != nil {
+		return "", err
+	}
+
+	log.Println("Moving API token to keychain key", key)
+	if err = wf.Keychain.Set(key, token); err != nil {
+		return "", err
+	}
+
+	// the legacy token is kept, unless the moved one can be read back
+	if moved, err := wf.Keychain.Get(key); err != nil || moved != token {
+		log.Println("failed to read back moved API token:", err)
+		return token, nil
+	}
+	if err = wf.Keychain.Delete(wfAuthTokenKey); err != nil {
+		log.Println("failed to remove legacy API token:", err)
+	}
+	return token, nil
+}
+
+// tokenFromEnv returns the API token from the first of tokenEnvVars, which is set.
+func tokenFromEnv() string {
+	for _, key := range tokenEnvVars {
+		if token := os.Getenv(key); token != "" {
+			return token
+		}
+	}
+	return ""
+}
+
+// SetToken saves the API token of the GitHub host in user's keychain,
+// and invalidates workflow cache.
+// The token is rejected if it lacks the scopes, which the workflow needs.
+func (wf *GithubWorkflow) SetToken(token string) error {
+	if token == "" {
+		return errTokenEmpty
+	}
+	if wf.TokenSource == tokenSourceOp {
+		return &alfredError{"API token is kept in 1Password", "update it there, at " + wf.TokenReference}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+
+	if err := wf.checkTokenScopes(ctx, token); err != nil {
+		return err
+	}
+
+	// remove previously cached username and PRs
+	if err := wf.ClearCache(); err != nil {
+		return err
+	}
+
+	return wf.Keychain.Set(wf.tokenKey(), token)
+}
+
+// ExportPRs writes the list of cached pull requests in the given format,
+// or executes the template file with them, if it is provided.
+func (wf *GithubWorkflow) ExportPRs(w io.Writer, format, templateFile string) error {
+	var renderer Renderer
+	var err error
+	if templateFile != "" {
+		renderer, err = newTemplateRenderer(w, templateFile)
+	} else {
+		renderer, err = newRenderer(w, format)
+	}
+	if err != nil {
+		return err
+	}
+
+	prs, err := wf.loadPRViews(0)
+	if err != nil {
+		return err
+	}
+
+	return renderer.Render(prs)
+}
+
+// NewClient creates a GitHub client, authenticated with the API token from user's keychain.
+// If the token command is configured, the client mints a new token whenever
+// the current one is rejected by GitHub. Responses are cached in workflow cache,
+// so that unchanged ones do not count against the rate limit, which is observed as well.
+func (wf *GithubWorkflow) NewClient(ctx context.Context) (*github.Client, error) {
+	if wf.TokenCommand != "" {
+		source := newCommandTokenSource(wf.TokenCommand, wf.Keychain)
+		httpclient := &http.Client{Transport: &refreshingTransport{source: source, base: wf.rates}}
+		return ghpr.NewClientFromHTTP(wf.GitApiUrl, httpclient)
+	}
+
+	token, err := wf.GetToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// the cache sits below the authentication, like the transport of the token command
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: wf.rates})
+	return ghpr.NewClient(ctx, wf.GitApiUrl, token)
+}
+
+// httpCacheDir is the directory in workflow cache, where responses of GitHub API are kept.
+const httpCacheDir = "httpcache"
+
+// newHTTPCache creates the transport, which caches responses of GitHub API in workflow cache,
+// on top of the base transport (or the default one, if it is nil).
+func newHTTPCache(wf *aw.Workflow, base http.RoundTripper) *ghpr.CachingTransport {
+	return ghpr.NewCachingTransport(filepath.Join(wf.CacheDir(), httpCacheDir), base)
+}
+
+// DisplayPRs sends the list of pull requests to Alfred as feedback items,
+// presented according to the named view.
+func (wf *GithubWorkflow) DisplayPRs(viewName string, currentAttempt int) error {
+	view, err := wf.newFeedbackView(viewName)
+	if err != nil {
+		return err
+	}
+
+	// reading the token from 1Password is slow, and may prompt to sign in, so it is only
+	// read by background refreshes, whose failures are shown as the status of the list
+	if wf.TokenSource != tokenSourceOp {
+		if _, err = wf.GetToken(); err != nil {
+			return err
+		}
+	}
+
+	prs, err := wf.loadPRViews(wf.displayLimit())
+	if err != nil {
+		log.Println(err)
+	}
+	prs = wf.withoutSnoozed(prs)
+	if wf.MaxItems > 0 && len(prs) > wf.MaxItems {
+		prs = prs[:wf.MaxItems]
+	}
+	wf.markUnread(prs)
+
+	if wf.ShowSummary {
+		wf.showSummary(prs, view)
+	}
+	refreshing := wf.ShowStatus(len(prs), currentAttempt, view)
+
+	if err = (&AlfredRenderer{wf, view}).Render(prs); err != nil {
+		return err
+	}
+
+	if wf.MaxItems > 0 {
+		wf.showOverflow(len(prs))
+	}
+	if len(prs) == 0 && !refreshing {
+		wf.ShowEmptyState(view.Keys)
+	}
+
+	return nil
+}
+
+// FetchPRs searches GitHub for any pull requests that satisfy the user query,
+// and caches the metadata and review status for each PR.
+func (wf *GithubWorkflow) FetchPRs() error {
+	ctx, cancel := backgroundContext()
+	defer cancel()
+
+	client, err := wf.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer wf.storeRateLimit()
+
+	user, err := wf.loadOrFetchUser(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	needsMemberships := wf.QueryMyTeams || (wf.savedSearchUses("{org}") && len(wf.scopedOrgs()) == 0)
+	if needsMemberships && wf.state.MembershipsExpired(membershipsMaxAge) {
+		// without memberships, the teams from QUERY_BY_TEAMS are still searched
+		if err := wf.fetchMemberships(ctx, client); err != nil {
+			log.Println("failed to fetch memberships:", err)
+		}
+	}
+
+	syncStart := time.Now()
+	times, err := wf.state.LoadSyncTimes()
+	if err != nil {
+		log.Println("failed to load sync times:", err)
+	}
+	full := wf.fullSyncDue(times)
+
+	queries := wf.searchQueries(user)
+	if !full {
+		for i, query := range queries {
+			queries[i] = ghpr.DeltaQuery(query, times.LastSync.Add(-deltaOverlap))
+		}
+	}
+
+	fetched, err := ghpr.Search(ctx, client, queries)
+	if err != nil {
+		return err
+	}
+
+	// only MAX_ITEMS of them are listed, so the rest is counted as GitHub finds them
+	if combined := wf.combinedSearchQuery(user); wf.MaxItems > 0 && combined != "" {
+		if total, err := ghpr.Count(ctx, client, combined); err != nil {
+			log.Println("failed to count pull requests:", err)
+		} else if err = wf.prs.StoreSearchTotal(total); err != nil {
+			log.Println("failed to store count of pull requests:", err)
+		}
+	}
+
+	if wf.FetchReviews || wf.needsDetails() {
+		if rate := wf.lowRateLimit(len(fetched) * statusCallsPerPR); rate != nil {
+			log.Println("Skipping status update, rate limit is low:", rate)
+		} else {
+			defer func() {
+				if err := wf.LaunchBackgroundTask("--update_status"); err != nil {
+					log.Println("failed to launch update task:", err)
+				}
+			}()
+		}
+	}
+
+	if wf.HideBots {
+		fetched = withoutBots(fetched)
+	}
+	if len(wf.Languages) > 0 {
+		fetched = wf.filterByLanguage(ctx, client, fetched)
+	}
+
+	// the very first fetch does not report new pull requests
+	previous, prevErr := wf.prs.LoadPRs(0)
+
+	prs := fetched
+	if !full {
+		prs, fetched = ghpr.MergePRs(previous, fetched)
+	}
+
+	// labels change over time, so the merged pull requests are filtered as well
+	if len(wf.IncludeLabels) > 0 || len(wf.ExcludeLabels) > 0 {
+		prs = filterByLabels(prs, wf.IncludeLabels, wf.ExcludeLabels)
+		fetched = filterByLabels(fetched, wf.IncludeLabels, wf.ExcludeLabels)
+	}
+	// and so do the repositories, which may be archived since the pull requests were cached
+	if wf.ExcludeArchived {
+		archived := wf.archivedPRs(prs)
+		prs, fetched = withoutArchived(prs, archived), withoutArchived(fetched, archived)
+	}
+
+	// avatars are nice to have as well
+	if wf.ShowAvatars {
+		if err := wf.fetchAvatars(ctx, client, fetched); err != nil {
+			log.Println("failed to fetch avatars:", err)
+		}
+	}
+
+	// the version of GitHub Enterprise instance tells which features it supports
+	if _, err := wf.fetchInstanceVersion(ctx, client); err != nil {
+		log.Println("failed to fetch instance version:", err)
+	}
+
+	// branches are nice to have, so the refresh goes on without them
+	branches, err := wf.headBranches(ctx, client, fetched)
+	if err != nil {
+		log.Println("failed to fetch branches:", err)
+	} else {
+		if !full {
+			branches = wf.mergeBranches(prs, branches)
+		}
+		if err = wf.branches.StoreBranches(branches); err != nil {
+			return err
+		}
+	}
+
+	// for review queues, the age of pull requests may matter more than the last push
+	if wf.DateField == dateFieldCreated {
+		sortByCreated(prs)
+	}
+
+	if wf.CheckDescriptions {
+		if err = wf.state.StoreDescriptionHints(findPoorDescriptions(prs, wf.targetLogin(user), wf.DescriptionSections)); err != nil {
+			return err
+		}
+	}
+
+	if err = wf.prs.StorePRs(prs); err != nil {
+		return err
+	}
+
+	if full {
+		times.LastFullSync = syncStart
+	}
+	times.LastSync = syncStart
+	if err = wf.state.StoreSyncTimes(times); err != nil {
+		return err
+	}
+
+	if prevErr == nil {
+		if added := findNewPRs(previous, prs); len(added) > 0 {
+			wf.RunHooks(eventNewPRs, toPRViews(added))
+		}
+	}
+	wf.RunHooks(eventRefreshCompleted, toPRViews(prs))
+
+	if err = newHTTPCache(wf.Workflow, nil).PruneCache(httpCacheMaxAge); err != nil {
+		log.Println("failed to prune http cache:", err)
+	}
+
+	return wf.storeConfigSnapshot()
+}
+
+// syncTimes are the start times of the last successful refresh, and of the last
+// full one, i.e. the one which searched for all pull requests, not only updated ones.
+type syncTimes struct {
+	LastSync     time.Time `json:"last_sync"`
+	LastFullSync time.Time `json:"last_full_sync"`
+}
+
+// fullSyncDue reports whether the refresh should search for all pull requests:
+// always, unless DELTA_FETCH is set, and otherwise on the first refresh, once an hour
+// (so that pull requests which no longer match the searches are dropped), and
+// whenever the configuration changes.
+func (wf *GithubWorkflow) fullSyncDue(times *syncTimes) bool {
+	if !wf.DeltaFetch || times.LastSync.IsZero() || time.Since(times.LastFullSync) > fullSyncMaxAge {
+		return true
+	}
+
+	snapshot, err := wf.state.LoadConfigSnapshot()
+	return err != nil || !reflect.DeepEqual(snapshot.Environ, lookupConfigEnv())
+}
+
+// mergeBranches adds the fetched head branches to the cached ones,
+// keeping only the branches of the pull requests.
+func (wf *GithubWorkflow) mergeBranches(prs []*github.Issue, fetched map[int64]string) map[int64]string {
+	cached, err := wf.branches.LoadBranches()
+	if err != nil {
+		log.Println("failed to load branches:", err)
+	}
+
+	result := make(map[int64]string, len(prs))
+	for _, pr := range prs {
+		if branch, ok := fetched[pr.GetID()]; ok {
+			result[pr.GetID()] = branch
+		} else if branch, ok = cached[pr.GetID()]; ok {
+			result[pr.GetID()] = branch
+		}
+	}
+	return result
+}
+
+// loadOrFetchUser returns the cached info of the authenticated user,
+// or fetches it from GitHub, if it is not cached yet.
+func (wf *GithubWorkflow) loadOrFetchUser(ctx context.Context, client *github.Client) (*github.User, error) {
+	return wf.state.LoadOrStoreUser(func() (*github.User, error) {
+		u, _, err := client.Users.Get(ctx, "")
+		return u, err
+	})
+}
+
+// filterByLanguage keeps only pull requests in repositories, whose primary language
+// is one of the configured languages. Repository languages are cached, since they
+// rarely change; pull requests are kept, if the language cannot be determined.
+func (wf *GithubWorkflow) filterByLanguage(ctx context.Context, client *github.Client, prs []*github.Issue) []*github.Issue {
+	var repos []string
+	seen := make(map[string]bool)
+	for _, pr := range prs {
+		if repo := parseRepoFromUrl(pr.GetHTMLURL()); !seen[repo] {
+			seen[repo] = true
+			repos = append(repos, repo)
+		}
+	}
+
+	var mu sync.Mutex
+	languages := make(map[string]string, len(repos))
+
+	wg, repoCtx := errgroup.WithContext(ctx)
+	wg.SetLimit(repoFetchConcurrency)
+	for _, repo := range repos {
+		repo := repo
+		wg.Go(func() error {
+			owner, name, _ := strings.Cut(repo, "/")
+			language, err := wf.state.LoadOrStoreRepoLanguage(repo, repoLanguageMaxAge, func() (string, error) {
+				r, _, err := client.Repositories.Get(repoCtx, owner, name)
+				return r.GetLanguage(), err
+			})
+			if err != nil {
+				log.Printf("failed to get language of repo %s, error: %s", repo, err)
+				return nil
+			}
+
+			mu.Lock()
+			languages[repo] = language
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := wg.Wait(); err != nil {
+		log.Println("failed to get languages of repos:", err)
+	}
+
+	result := make([]*github.Issue, 0, len(prs))
+	for _, pr := range prs {
+		language := languages[parseRepoFromUrl(pr.GetHTMLURL())]
+		if language == "" || containsFold(wf.Languages, language) {
+			result = append(result, pr)
+		}
+	}
+	return result
+}
+
+// archivedPRs finds the pull requests, whose details (as of the last status update)
+// tell that their repository is archived.
+func (wf *GithubWorkflow) archivedPRs(prs []*github.Issue) map[int64]bool {
+	archived := make(map[int64]bool)
+	details, err := wf.details.LoadDetails()
+	if err != nil {
+		log.Println("failed to load details:", err)
+		return archived
+	}
+
+	for _, pr := range prs {
+		if d := details[pr.GetID()]; d != nil && d.Archived {
+			archived[pr.GetID()] = true
+		}
+	}
+	return archived
+}
+
+// prDetails holds the data of a pull request, which are not returned by the search.
+type prDetails struct {
+	BaseBranch         string   `json:"base_branch"`
+	HeadSHA            string   `json:"head_sha,omitempty"`
+	Archived           bool     `json:"archived,omitempty"`
+	RequestedReviewers []string `json:"requested_reviewers,omitempty"`
+	RequiredApprovals  int      `json:"required_approvals,omitempty"`
+	// times of comments, commits and reviews in the last activityDays days
+	Activity  []time.Time `json:"activity,omitempty"`
+	FetchedAt time.Time   `json:"fetched_at"`
+	diffSize
+}
+
+// fresh reports whether the details were fetched after the pull request was last updated.
+func (d *prDetails) fresh(updatedAt time.Time) bool {
+	return d != nil && d.FetchedAt.After(updatedAt)
+}
+
+// newPRDetails extracts the details from a pull request in a repository of the owner.
+// Teams requested to review are referred to as org/slug.
+func newPRDetails(pull *github.PullRequest, owner string) *prDetails {
+	var reviewers []string
+	for _, user := range pull.RequestedReviewers {
+		reviewers = append(reviewers, user.GetLogin())
+	}
+	for _, team := range pull.RequestedTeams {
+		reviewers = append(reviewers, teamName(team, owner))
+	}
+
+	return &prDetails{
+		BaseBranch:         pull.GetBase().GetRef(),
+		HeadSHA:            pull.GetHead().GetSHA(),
+		Archived:           pull.GetBase().GetRepo().GetArchived(),
+		RequestedReviewers: reviewers,
+		diffSize:           diffSize{pull.GetAdditions(), pull.GetDeletions(), pull.GetChangedFiles()},
+	}
+}
+
+// needsDetails reports whether the configured features show the details of pull requests,
+// which are fetched one by one.
+func (wf *GithubWorkflow) needsDetails() bool {
+	return wf.ShowTargetBranch || wf.ShowDiffSize || wf.ShowReviewers || wf.ShowActivity ||
+		wf.DetailLevel == detailVerbose || wf.GroupBy == groupByTurn || wf.ShowSummary ||
+		len(wf.Hooks) > 0 // for the checks of the latest commit
+}
+
+// FetchPRStatus gets the review status (if SHOW_REVIEWS is set) and the details of pull
+// requests (if any feature shows them) from GitHub. Both are fetched again only if the pull
+// request was updated since they were cached, for at most REVIEW_FETCH_CONCURRENCY pull
+// requests at the same time.
+func (wf *GithubWorkflow) FetchPRStatus() error {
+	ctx, cancel := backgroundContext()
+	defer cancel()
+
+	client, err := wf.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer wf.storeRateLimit()
+
+	prs, err := wf.prs.LoadPRs(0)
+	if err != nil {
+		return err
+	}
+
+	// the remaining calls are left for refreshes and actions
+	if rate := wf.lowRateLimit(len(prs) * statusCallsPerPR); rate != nil {
+		log.Println("Skipping status update, rate limit is low:", rate)
+		return nil
+	}
+
+	// too many simultaneous requests trip the secondary rate limit
+	concurrency := wf.ReviewConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultReviewConcurrency
+	}
+
+	summaries, err := wf.reviews.LoadReviews()
+	if err != nil {
+		log.Println("failed to load reviews:", err)
+		summaries = make(map[int64]*ghpr.ReviewSummary)
+	}
+	details, err := wf.details.LoadDetails()
+	if err != nil {
+		log.Println("failed to load details:", err)
+		details = make(map[int64]*prDetails)
+	}
+	var mu sync.Mutex
+	fetched := make(map[int64]*ghpr.ReviewSummary)
+	fetchedDetails := make(map[int64]*prDetails)
+
+	wg, groupCtx := errgroup.WithContext(ctx)
+	wg.SetLimit(concurrency)
+
+	for _, pr := range prs {
+		pr := pr
+		cached, cachedDetails := summaries[pr.GetID()], details[pr.GetID()]
+		wg.Go(func() error {
+			project := parseRepoFromUrl(*pr.HTMLURL)
+			owner, repo, _ := strings.Cut(project, "/")
+
+			if wf.needsDetails() && !cachedDetails.fresh(pr.GetUpdatedAt()) {
+				fetchedAt := time.Now()
+				pull, _, err := client.PullRequests.Get(groupCtx, owner, repo, *pr.Number)
+				if err != nil {
+					return err
+				}
+
+				d := newPRDetails(pull, owner)
+				d.FetchedAt = fetchedAt
+				if wf.FetchReviews {
+					d.RequiredApprovals = wf.requiredApprovals(groupCtx, client, project, d.BaseBranch)
+				}
+				if wf.ShowActivity {
+					// activity is nice to have, so the details are cached without it
+					since := time.Now().AddDate(0, 0, -activityDays)
+					if d.Activity, err = ghpr.Activity(groupCtx, client, owner, repo, *pr.Number, since); err != nil {
+						log.Printf("failed to fetch activity of PR %d, error: %s", *pr.ID, err)
+					}
+				}
+
+				mu.Lock()
+				fetchedDetails[pr.GetID()] = d
+				mu.Unlock()
+			}
+
+			if !wf.FetchReviews || cached.Fresh(pr.GetUpdatedAt()) {
+				return nil
+			}
+
+			fetchedAt := time.Now()
+			reviews, err := ghpr.ListReviews(groupCtx, client, owner, repo, *pr.Number)
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			fetched[pr.GetID()] = &ghpr.ReviewSummary{Reviews: reviews, FetchedAt: fetchedAt}
+			return nil
+		})
+	}
+
+	// the reviews and details fetched before a failure are kept nevertheless,
+	// along with the reviews updated by actions meanwhile
+	err = wg.Wait()
+	if storeErr := wf.reviews.MergeReviews(fetched); storeErr != nil && err == nil {
+		err = storeErr
+	}
+	if storeErr := wf.details.MergeDetails(fetchedDetails); storeErr != nil && err == nil {
+		err = storeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(wf.Hooks) > 0 {
+		if err = wf.reportFailedChecks(ctx, client, prs, concurrency); err != nil {
+			log.Println("failed to report failed checks:", err)
+		}
+	}
+
+	// the cache is compacted opportunistically, since the pull requests are known here
+	report, err := wf.collectGarbage(prs)
+	if err != nil {
+		log.Println("failed to compact cache:", err)
+	} else {
+		log.Println("Compacted cache:", report)
+	}
+	return nil
+}
+
+// pruneStatus removes the cached reviews and details of pull requests,
+// which are no longer found (since they were merged or closed, for example).
+func (wf *GithubWorkflow) pruneStatus(prs []*github.Issue) {
+	keep := make(map[int64]bool, len(prs))
+	for _, pr := range prs {
+		keep[pr.GetID()] = true
+	}
+
+	if err := wf.reviews.PruneReviews(keep); err != nil {
+		log.Println("failed to prune reviews:", err)
+	}
+	if err := wf.details.PruneDetails(keep); err != nil {
+		log.Println("failed to prune details:", err)
+	}
+}
+
+// requiredApprovals gets the number of approving reviews required by the protection
+// of the branch. Unprotected branches require no approvals, and the ones whose
+// protection cannot be read (which needs admin permissions, so GitHub answers with
+// 403 Forbidden, or 404 Not Found for private repositories) require an unknown number,
+// which is 0 as well: it is cached along with the others, so that it is not asked again.
+func (wf *GithubWorkflow) requiredApprovals(ctx context.Context, client *github.Client, repo, branch string) int {
+	owner, name, _ := strings.Cut(repo, "/")
+
+	count, err := wf.state.LoadOrStoreRequiredApprovals(repo, branch, branchProtectionMaxAge, func() (int, error) {
+		protection, _, err := client.Repositories.GetBranchProtection(ctx, owner, name, branch)
+
+		var errResp *github.ErrorResponse
+		switch {
+		case err == github.ErrBranchNotProtected:
+			return 0, nil
+		case errors.As(err, &errResp) && errResp.Response != nil &&
+			(errResp.Response.StatusCode == http.StatusForbidden || errResp.Response.StatusCode == http.StatusNotFound):
+			return 0, nil
+		case err != nil:
+			return 0, err
+		}
+
+		if reviews := protection.GetRequiredPullRequestReviews(); reviews != nil {
+			return reviews.RequiredApprovingReviewCount, nil
+		}
+		return 0, nil
+	})
+	if err != nil {
+		log.Printf("failed to get protection of branch %s in repo %s, error: %s", branch, repo, err)
+	}
+	return count
+}
+
+// fetchMemberships caches the organizations and teams of the user.
+func (wf *GithubWorkflow) fetchMemberships(ctx context.Context, client *github.Client) error {
+	memberships, err := ghpr.ListMemberships(ctx, client)
+	if err != nil {
+		return err
+	}
+	return wf.state.StoreMemberships(memberships)
+}
+
+// FetchWorkload counts open review requests of the members of the teams
+// from QUERY_BY_TEAMS and QUERY_BY_MY_TEAMS (except the current user), so that reviews can be
+// requested from the least loaded teammate.
+func (wf *GithubWorkflow) FetchWorkload() error {
+	ctx, cancel := backgroundContext()
+	defer cancel()
+
+	client, err := wf.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	user, err := wf.loadOrFetchUser(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	members := make(map[string]bool)
+	for _, team := range wf.teams() {
+		org, slug, _ := strings.Cut(team, "/")
+		opts := &github.TeamListTeamMembersOptions{ListOptions: github.ListOptions{PerPage: 100}}
+		for {
+			users, resp, err := client.Teams.ListTeamMembersBySlug(ctx, org, slug, opts)
+			if err != nil {
+				return err
+			}
+
+			for _, member := range users {
+				if member.GetLogin() != user.GetLogin() {
+					members[member.GetLogin()] = true
+				}
+			}
+			if resp.NextPage == 0 {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
+	}
+
+	var mu sync.Mutex
+	var searchErr error
+	workload := make(map[string]int, len(members))
+
+	// searches have a rate limit of their own, which is much lower
+	wg := new(errgroup.Group)
+	wg.SetLimit(searchConcurrency)
+	for login := range members {
+		login := login
+		wg.Go(func() error {
+			query := "type:pr is:open review-requested:" + login
+			result, _, err := client.Search.Issues(ctx, query, &github.SearchOptions{ListOptions: github.ListOptions{PerPage: 1}})
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				log.Printf("failed to count review requests of %s, error: %s", login, err)
+				searchErr = err
+				return nil
+			}
+			workload[login] = result.GetTotal()
+			return nil
+		})
+	}
+
+	// the workload of the members, whose searches failed, is unknown
+	if err = wg.Wait(); err != nil {
+		return err
+	}
+	if len(workload) == 0 && searchErr != nil {
+		return searchErr
+	}
+	return wf.state.StoreWorkload(workload)
+}
+
+// LaunchBackgroundTask starts a workflow task in the background (if it is not running already).
+// The task of a saved search runs for the saved search.
+func (wf *GithubWorkflow) LaunchBackgroundTask(task string, arg ...string) error {
+	log.Printf("Launching task '%s' in background...", wf.jobName(task))
+	cmdArgs := append([]string{task}, arg...)
+	if wf.savedSearch != "" {
+		cmdArgs = append(cmdArgs, "--saved", wf.savedSearch)
+	}
+	return wf.RunInBackground(wf.jobName(task), exec.Command(os.Args[0], cmdArgs...))
+}
+
+// LaunchUpdateTask (re)starts the 'update' task, counting the attempts.
+func (wf *GithubWorkflow) LaunchUpdateTask(currentAttempt int) {
+	wf.Var(fbCurrentAttemptKey, strconv.Itoa(currentAttempt+1))
+
+	if err := wf.LaunchBackgroundTask(taskUpdate); err != nil {
+		log.Println("failed to launch update task:", err)
+	}
+}
+
+// ShowNewVersions pulls workflow release info from GitHub
+// and caches it locally, if the check is due.
+func (wf *GithubWorkflow) ShowNewVersions(shouldDisplayPrompt bool) error {
+	if wf.UpdateCheckDue() {
+		if err := wf.LaunchBackgroundTask("--check"); err != nil {
+			return err
+		}
+	}
+
+	if shouldDisplayPrompt && wf.UpdateAvailable() {
+		wf.NewItem("Update available!").
+			Subtitle("press to install").
+			Arg("workflow:update").
+			Valid(true).
+			Icon(aw.IconWeb)
+	}
+
+	return nil
+}
+
+var workflow *GithubWorkflow
+
+// isAction reports whether the workflow is running an action command,
+// which notifies the user about its result instead of sending feedback items.
+func isAction() bool {
+	return cmdApprove || cmdAssignMe || cmdBroadenRoles || cmdExpandGroup || cmdHandoff || cmdNudge || cmdOpenAll || cmdOpenCommand || cmdOpenDoctor || cmdRefresh || cmdRefreshOrgs || cmdRequestReviewers || cmdSnooze || cmdStatsShare || cmdToggleDraft
+}
+
+// isCommandLine reports whether the output is written directly
+// to the terminal, rather than sent to Alfred.
+func isCommandLine() bool {
+	return cmdExport || cmdSnapshot || cmdSnapshotDiff || cmdBackup || cmdRestore || cmdCacheGC
+}
+
+// init defines command-line flags
+func init() {
+	flag.BoolVar(&cmdApprove, "approve", false, "approve selected pull request")
+	flag.BoolVar(&cmdAssignMe, "assign_me", false, "assign selected pull request to yourself")
+	flag.BoolVar(&cmdAuth, "auth", false, "set API token")
+	flag.BoolVar(&cmdBackup, "backup", false, "archive workflow state, except the token, to a file")
+	flag.BoolVar(&cmdBroadenRoles, "broaden_roles", false, "search pull requests by all roles")
+	flag.BoolVar(&cmdCacheGC, "cache_gc", false, "remove unused entries from workflow cache")
+	flag.BoolVar(&cmdCheck, "check", false, "check for workflow updates")
+	flag.BoolVar(&cmdChooseReviewers, "choose_reviewers", false, "display your pull requests to request reviews on")
+	flag.BoolVar(&cmdDisplay, "display", false, "display pull requests")
+	flag.BoolVar(&cmdDoctor, "doctor", false, "display workflow diagnostics")
+	flag.BoolVar(&cmdExpandGroup, "expand_group", false, "search pull requests of a group in Alfred")
+	flag.BoolVar(&cmdExport, "export", false, "export pull requests")
+	flag.BoolVar(&cmdHandoff, "handoff", false, "continue with pull request, given by its url, on the phone")
+	flag.BoolVar(&cmdHelpCommands, "help_commands", false, "display workflow commands")
+	flag.BoolVar(&cmdHelpKeys, "help_keys", false, "display modifier keys of items")
+	flag.BoolVar(&cmdInspect, "inspect", false, "display details of pull request given by its url")
+	flag.BoolVar(&cmdLogout, "logout", false, "remove API token from keychain, and reset workflow data")
+	flag.BoolVar(&cmdNudge, "nudge", false, "remind reviewers of selected pull request")
+	flag.BoolVar(&cmdOpenAll, "open_all", false, "open all pull requests, given by their urls")
+	flag.BoolVar(&cmdOpenCommand, "open_command", false, "open workflow command, given by its keyword, in Alfred")
+	flag.BoolVar(&cmdOpenDoctor, "open_doctor", false, "open diagnostics in Alfred")
+	flag.BoolVar(&cmdRateLimits, "ratelimit", false, "display remaining quota of GitHub API")
+	flag.BoolVar(&cmdRecent, "recent", false, "display your recently merged and closed pull requests")
+	flag.BoolVar(&cmdRefresh, "refresh", false, "refresh pull requests in background")
+	flag.BoolVar(&cmdRefreshOrgs, "refresh_orgs", false, "refresh cached organizations and teams of the user")
+	flag.BoolVar(&cmdRequestReviewers, "request_reviewers", false, "request reviews on selected pull request")
+	flag.BoolVar(&cmdRestore, "restore", false, "restore workflow state from a backup file")
+	flag.BoolVar(&cmdSnapshot, "snapshot", false, "archive pull requests to a timestamped snapshot")
+	flag.BoolVar(&cmdSnapshotDiff, "snapshot_diff", false, "compare two snapshots, given as arguments")
+	flag.BoolVar(&cmdSnooze, "snooze", false, "hide selected pull request for a few days")
+	flag.BoolVar(&cmdStatsShare, "stats_share", false, "copy summary of usage stats to clipboard")
+	flag.BoolVar(&cmdToggleDraft, "toggle_draft", false, "toggle draft state of selected pull request")
+	flag.BoolVar(&cmdUpdatePRs, "update", false, "update pull requests cache")
+	flag.BoolVar(&cmdUpdatePRStatus, "update_status", false, "update PR status cache")
+	flag.BoolVar(&cmdUpdateWorkload, "update_workload", false, "update review workload of teammates")
+	flag.BoolVar(&cmdWhoami, "whoami", false, "display user authenticated by API token")
+	flag.BoolVar(&restoreCommands, "restore_commands", false, "also restore TOKEN_COMMAND and HOOKS, which the workflow executes")
+	flag.IntVar(&attempt, "attempt", 0, "indicate # of attempts so far")
+	flag.IntVar(&maxAttempts, "max_attempts", 0, "indicate # of allowed attempts")
+	flag.StringVar(&format, "format", "json", "export format: json, markdown or count")
+	flag.StringVar(&query, "query", "", "command input")
+	flag.StringVar(&savedSearch, "saved", "", "name of saved search to display or refresh, instead of configured queries")
+	flag.StringVar(&templateFile, "template", "", "text/template file to export with, instead of format")
+	flag.StringVar(&view, "view", viewSorted, "view to display pull requests in: sorted,search")
+}
+
+// init creates and configures the workflow
+func init() {
+	if _, err := os.Stat(aw.IconWarning.Value); err != nil {
+		// substitute icon if it doesn't exist
+		aw.IconWarning = aw.IconError
+	}
+
+	workflow = newGithubWorkflow(
+		aw.New(update.GitHub("AndreyBozhko/go-alfred-prs")),
+		&workflowConfig{},
+	)
+}
+
+// run executes the workflow logic. It delegates to concrete
+// workflow methods, based on parsed command line arguments.
+func run() error {
+	// parse args and handle magic commands
+	workflow.Args()
+	flag.Parse()
+
+	// logging out must not depend on a valid configuration
+	if cmdLogout {
+		return workflow.Logout()
+	}
+
+	// cached data of other versions must not be misread
+	workflow.migrateCache()
+
+	// load workflow configurations, taking
+	// the fast path when displaying pull requests
+	if !cmdDisplay || !workflow.loadConfigSnapshot() {
+		if err := workflow.loadConfig(); err != nil {
+			return err
+		}
+	}
+
+	if savedSearch != "" {
+		if err := workflow.useSavedSearch(savedSearch); err != nil {
+			return err
+		}
+	}
+
+	workflow.recordUsage(flag.CommandLine)
+
+	// workflow logic
+	if cmdApprove {
+		return workflow.ApprovePR()
+	}
+	if cmdAssignMe {
+		return workflow.AssignMe()
+	}
+	if cmdBroadenRoles {
+		return workflow.BroadenRoles()
+	}
+	if cmdExpandGroup {
+		return workflow.ExpandGroup(query)
+	}
+	if cmdOpenAll {
+		return workflow.OpenAll(query)
+	}
+	if cmdNudge {
+		return workflow.Nudge()
+	}
+	if cmdHandoff {
+		return workflow.Handoff(query)
+	}
+	if cmdOpenCommand {
+		return workflow.OpenCommand(query)
+	}
+	if cmdOpenDoctor {
+		return workflow.OpenDoctor()
+	}
+	if cmdRefresh {
+		return workflow.Refresh()
+	}
+	if cmdRefreshOrgs {
+		return workflow.RefreshMemberships()
+	}
+	if cmdSnooze {
+		return workflow.Snooze()
+	}
+	if cmdStatsShare {
+		return workflow.ShareStats()
+	}
+	if cmdRequestReviewers {
+		return workflow.RequestReviewers(query)
+	}
+	if cmdToggleDraft {
+		return workflow.ToggleDraft()
+	}
+	if cmdAuth {
+		return workflow.SetToken(query)
+	}
+	if cmdCheck {
+		return workflow.CheckForUpdate()
+	}
+	if cmdChooseReviewers {
+		return workflow.ChooseReviewers(query)
+	}
+	if cmdDisplay {
+		// handle updates
+		if workflow.AllowUpdates {
+			shouldDisplayPrompt := query == ""
+			if err := workflow.ShowNewVersions(shouldDisplayPrompt); err != nil {
+				return err
+			}
+		}
+		return workflow.DisplayPRs(view, attempt)
+	}
+	if cmdExport {
+		return workflow.ExportPRs(os.Stdout, format, templateFile)
+	}
+	if cmdSnapshot {
+		return workflow.TakeSnapshot(os.Stdout)
+	}
+	if cmdSnapshotDiff {
+		return workflow.DiffSnapshots(os.Stdout, flag.Args())
+	}
+	if cmdBackup {
+		return workflow.Backup(os.Stdout, flag.Args())
+	}
+	if cmdRestore {
+		return workflow.Restore(os.Stdout, flag.Args(), restoreCommands)
+	}
+	if cmdCacheGC {
+		return workflow.CollectGarbage(os.Stdout)
+	}
+	if cmdInspect {
+		return workflow.Inspect(query)
+	}
+	if cmdDoctor {
+		return workflow.Doctor()
+	}
+	if cmdRateLimits {
+		return workflow.ShowRateLimits()
+	}
+	if cmdWhoami {
+		return workflow.ShowWhoami()
+	}
+	if cmdRecent {
+		return workflow.ShowRecent()
+	}
+	if cmdHelpCommands {
+		return workflow.ShowHelp()
+	}
+	if cmdHelpKeys {
+		return workflow.HelpKeys(view)
+	}
+	if cmdUpdatePRs {
+		return workflow.storeSyncResult(workflow.FetchPRs())
+	}
+	if cmdUpdatePRStatus {
+		return workflow.FetchPRStatus()
+	}
+	if cmdUpdateWorkload {
+		return workflow.FetchWorkload()
+	}
+
+	// fallback
+	println("Alfred workflow for GitHub pull requests\n")
+	flag.Usage()
+
+	return nil
+}
+
+func main() {
+	workflow.Run(func() {
+		if err := run(); err != nil {
+			workflow.HandleError(err)
+		}
+		workflow.SendResult()
+	})
+}
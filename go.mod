@@ -1,4 +1,4 @@
-module go-ghpr
+module github.com/AndreyBozhko/go-alfred-prs
 
 go 1.19
 
@@ -7,6 +7,7 @@ require (
 	github.com/google/go-github/v48 v48.2.0
 	github.com/stretchr/testify v1.8.1
 	go.deanishe.net/env v0.5.1
+	golang.org/x/net v0.5.0
 	golang.org/x/oauth2 v0.4.0
 	golang.org/x/sync v0.1.0
 )
@@ -20,7 +21,6 @@ require (
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	go.deanishe.net/fuzzy v1.0.0 // indirect
 	golang.org/x/crypto v0.1.0 // indirect
-	golang.org/x/net v0.5.0 // indirect
 	golang.org/x/text v0.6.0 // indirect
 	google.golang.org/appengine v1.6.7 // indirect
 	google.golang.org/protobuf v1.28.1 // indirect
@@ -0,0 +1,83 @@
+package ghpr
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/go-github/v48/github"
+)
+
+// maxTimelinePages caps the number of timeline pages fetched for a pull request.
+const maxTimelinePages = 3
+
+// Activity fetches the timeline of the pull request, and returns the times of
+// comments, commits and reviews since the given time, in chronological order.
+// The pages of events are walked backwards from the last one, until the events
+// are older than the given time, or maxTimelinePages pages are fetched, so activity
+// of very busy pull requests may be incomplete.
+func Activity(ctx context.Context, client *github.Client, owner, repo string, number int, since time.Time) ([]time.Time, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	first, resp, err := client.Issues.ListIssueTimeline(ctx, owner, repo, number, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// pages are collected newest first
+	pages := [][]*github.Timeline{first}
+	if resp.LastPage > 0 {
+		pages = nil
+		done := false
+		for page := resp.LastPage; page > 1 && !done && len(pages) < maxTimelinePages; page-- {
+			opts.Page = page
+			events, _, err := client.Issues.ListIssueTimeline(ctx, owner, repo, number, opts)
+			if err != nil {
+				return nil, err
+			}
+			pages = append(pages, events)
+			done = startsBefore(events, since)
+		}
+
+		if !done && len(pages) < maxTimelinePages {
+			pages = append(pages, first)
+		}
+	}
+
+	var result []time.Time
+	for i := len(pages) - 1; i >= 0; i-- {
+		for _, event := range pages[i] {
+			if at := eventTime(event); !at.IsZero() && !at.Before(since) {
+				result = append(result, at)
+			}
+		}
+	}
+	return result, nil
+}
+
+// eventTime returns the time of a comment, commit or review in the timeline,
+// or the zero time for other events.
+func eventTime(event *github.Timeline) time.Time {
+	switch event.GetEvent() {
+	case "commented":
+		return event.GetCreatedAt()
+	case "committed":
+		return event.GetCommitter().GetDate()
+	case "reviewed":
+		return event.GetSubmittedAt()
+	}
+	return time.Time{}
+}
+
+// startsBefore reports whether the first of the events, whose time is known,
+// happened before the given time.
+func startsBefore(events []*github.Timeline, since time.Time) bool {
+	for _, event := range events {
+		at := eventTime(event)
+		if at.IsZero() {
+			at = event.GetCreatedAt()
+		}
+		if !at.IsZero() {
+			return at.Before(since)
+		}
+	}
+	return false
+}
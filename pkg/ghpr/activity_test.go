@@ -0,0 +1,76 @@
+package ghpr
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActivity(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/org/repo/issues/1/timeline", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"event": "commented", "created_at": "2023-01-01T10:00:00Z"},
+			{"event": "committed", "committer": {"date": "2023-01-10T10:00:00Z"}},
+			{"event": "labeled", "created_at": "2023-01-11T10:00:00Z"},
+			{"event": "reviewed", "submitted_at": "2023-01-12T10:00:00Z"},
+			{"event": "commented", "created_at": "2023-01-13T10:00:00Z"}
+		]`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClient(context.Background(), server.URL, "token")
+	assert.Nil(t, err)
+
+	since := time.Date(2023, 1, 8, 0, 0, 0, 0, time.UTC)
+	activity, err := Activity(context.Background(), client, "org", "repo", 1, since)
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Time{
+		time.Date(2023, 1, 10, 10, 0, 0, 0, time.UTC),
+		time.Date(2023, 1, 12, 10, 0, 0, 0, time.UTC),
+		time.Date(2023, 1, 13, 10, 0, 0, 0, time.UTC),
+	}, activity)
+}
+
+func TestActivityLastPages(t *testing.T) {
+	// the timeline has 5 pages, one per day, and only the last two of them are recent
+	var requested []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/org/repo/issues/1/timeline", func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		if page == "" {
+			page = "1"
+		}
+		requested = append(requested, page)
+
+		if page != "5" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s?page=5>; rel="last"`, r.URL.Path))
+		}
+		w.Write([]byte(`[
+			{"event": "labeled", "created_at": "2023-01-0` + page + `T08:00:00Z"},
+			{"event": "commented", "created_at": "2023-01-0` + page + `T10:00:00Z"}
+		]`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClient(context.Background(), server.URL, "token")
+	assert.Nil(t, err)
+
+	since := time.Date(2023, 1, 4, 9, 0, 0, 0, time.UTC)
+	activity, err := Activity(context.Background(), client, "org", "repo", 1, since)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1", "5", "4"}, requested)
+	assert.Equal(t, []time.Time{
+		time.Date(2023, 1, 4, 10, 0, 0, 0, time.UTC),
+		time.Date(2023, 1, 5, 10, 0, 0, 0, time.UTC),
+	}, activity)
+}
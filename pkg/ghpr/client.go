@@ -0,0 +1,85 @@
+package ghpr
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/v48/github"
+	"golang.org/x/oauth2"
+)
+
+// NewClient creates a GitHub client which uses
+// provided url and API token to connect to GitHub.
+func NewClient(ctx context.Context, url, token string) (*github.Client, error) {
+	httpclient := oauth2.NewClient(ctx, oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: token},
+	))
+
+	return NewClientFromHTTP(url, httpclient)
+}
+
+// NewClientFromHTTP creates a GitHub client which uses
+// provided url and (already authenticated) http client to connect to GitHub.
+func NewClientFromHTTP(url string, httpclient *http.Client) (*github.Client, error) {
+	if url == "" {
+		return github.NewClient(httpclient), nil
+	}
+	return github.NewEnterpriseClient(url, url, httpclient)
+}
+
+// GraphqlURL returns GraphQL endpoint of the GitHub instance with the given REST API url.
+// GitHub Enterprise serves REST API under /api/v3/, and GraphQL API under /api/graphql.
+func GraphqlURL(apiUrl *url.URL) string {
+	u := *apiUrl
+	if strings.HasSuffix(u.Path, "/api/v3/") {
+		u.Path = strings.TrimSuffix(u.Path, "v3/") + "graphql"
+	} else {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/graphql"
+	}
+	return u.String()
+}
+
+// GraphQLError is the first error reported by GitHub GraphQL API.
+type GraphQLError struct {
+	Message string
+}
+
+func (e *GraphQLError) Error() string {
+	return "GitHub GraphQL request failed: " + e.Message
+}
+
+// graphqlResponse holds the data and errors returned by GitHub GraphQL API.
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// DoGraphQL executes a GraphQL query (or mutation) against GitHub API,
+// and unmarshals the response data into v, unless it is nil.
+func DoGraphQL(ctx context.Context, client *github.Client, query string, vars map[string]interface{}, v interface{}) error {
+	body := map[string]interface{}{"query": query, "variables": vars}
+
+	req, err := client.NewRequest("POST", GraphqlURL(client.BaseURL), body)
+	if err != nil {
+		return err
+	}
+
+	var resp graphqlResponse
+	if _, err = client.Do(ctx, req, &resp); err != nil {
+		return err
+	}
+
+	if len(resp.Errors) > 0 {
+		return &GraphQLError{resp.Errors[0].Message}
+	}
+
+	if v == nil || len(resp.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(resp.Data, v)
+}
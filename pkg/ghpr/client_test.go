@@ -0,0 +1,58 @@
+package ghpr
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphqlURL(t *testing.T) {
+	data := []struct {
+		apiUrl, expected string
+	}{
+		{"https://api.github.com/", "https://api.github.com/graphql"},
+		{"https://ghe.mycorp.com/api/v3/", "https://ghe.mycorp.com/api/graphql"},
+		{"http://127.0.0.1:8080/api/v3/", "http://127.0.0.1:8080/api/graphql"},
+	}
+
+	for _, testcase := range data {
+		u, err := url.Parse(testcase.apiUrl)
+		assert.Nil(t, err)
+		assert.Equal(t, testcase.expected, GraphqlURL(u))
+	}
+}
+
+func TestDoGraphQL(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/graphql", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "broken") {
+			w.Write([]byte(`{"errors": [{"message": "Field 'broken' doesn't exist"}]}`))
+			return
+		}
+		w.Write([]byte(`{"data": {"viewer": {"login": "octocat"}}}`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClient(context.Background(), server.URL, "token")
+	assert.Nil(t, err)
+
+	var data struct {
+		Viewer struct {
+			Login string `json:"login"`
+		} `json:"viewer"`
+	}
+	assert.Nil(t, DoGraphQL(context.Background(), client, "query { viewer { login } }", nil, &data))
+	assert.Equal(t, "octocat", data.Viewer.Login)
+
+	err = DoGraphQL(context.Background(), client, "query { broken }", nil, nil)
+	assert.Equal(t, &GraphQLError{"Field 'broken' doesn't exist"}, err)
+}
@@ -0,0 +1,20 @@
+// Package ghpr finds open GitHub pull requests of a user and summarizes their reviews.
+//
+// It is the GitHub-facing part of the go-ghpr Alfred workflow, and can be used
+// by other tools (dashboards, bots) on its own:
+//
+//	client, err := ghpr.NewClient(ctx, "", token)
+//	queries := ghpr.SearchQueries([]string{"author", "review-requested"}, nil, "octocat")
+//	prs, err := ghpr.Search(ctx, client, queries)
+//
+// An empty url connects to github.com, otherwise the url of the GitHub Enterprise
+// API is expected, like 'https://ghe.mycorp.com/api/v3/'.
+//
+// Pull requests found before can be refreshed with the results of DeltaQuery
+// queries, which MergePRs applies to them.
+//
+// Reviews are fetched with ListReviews, summarized with ReviewState, and can be
+// kept along with the time they were fetched in a ReviewSummary. Caching them
+// is up to the caller; package prcache keeps them (like the workflow does)
+// along with the pull requests.
+package ghpr
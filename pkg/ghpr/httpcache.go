@@ -0,0 +1,168 @@
+package ghpr
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CachingTransport makes conditional requests to GitHub API: responses with an ETag
+// are kept on disk, and the ETag is sent back with the next request for the same url.
+// GitHub answers 304 Not Modified if nothing has changed, which does not count against
+// the rate limit, and the cached response is returned instead.
+type CachingTransport struct {
+	// Dir is the directory with cached responses.
+	Dir string
+	// Base is the underlying transport, or http.DefaultTransport if nil.
+	Base http.RoundTripper
+}
+
+// NewCachingTransport creates a caching transport on top of base,
+// which keeps responses in the directory.
+func NewCachingTransport(dir string, base http.RoundTripper) *CachingTransport {
+	return &CachingTransport{Dir: dir, Base: base}
+}
+
+func (t *CachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.base().RoundTrip(req)
+	}
+
+	file := t.file(req)
+	cached := t.load(req, file)
+	if cached != nil {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.Header.Get("ETag"))
+	}
+
+	resp, err := t.base().RoundTrip(req)
+	if err != nil {
+		if cached != nil {
+			cached.Body.Close()
+		}
+		return nil, err
+	}
+
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+
+		// fresh headers (like the rate limit) take precedence over the cached ones
+		for key, values := range resp.Header {
+			cached.Header[key] = values
+		}
+		cached.Request = req
+
+		now := time.Now()
+		_ = os.Chtimes(file, now, now)
+		return cached, nil
+	}
+	if cached != nil {
+		cached.Body.Close()
+	}
+
+	if resp.StatusCode == http.StatusOK && resp.Header.Get("ETag") != "" {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		// the response is still good, even if it cannot be cached
+		_ = t.store(resp, body, file)
+	}
+	return resp, nil
+}
+
+// PruneCache removes cached responses, which were not used for longer than maxAge,
+// like those of search queries restricted to recent updates.
+func (t *CachingTransport) PruneCache(maxAge time.Duration) error {
+	entries, err := os.ReadDir(t.Dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) <= maxAge {
+			continue
+		}
+		if err = os.Remove(filepath.Join(t.Dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *CachingTransport) base() http.RoundTripper {
+	if t.Base == nil {
+		return http.DefaultTransport
+	}
+	return t.Base
+}
+
+// file returns the path of the cached response to the request. Requests for
+// different media types get different responses for the same url.
+func (t *CachingTransport) file(req *http.Request) string {
+	hash := sha256.Sum256([]byte(req.URL.String() + "\n" + req.Header.Get("Accept")))
+	return filepath.Join(t.Dir, hex.EncodeToString(hash[:]))
+}
+
+// load returns the cached response to the request, or nil if there is none.
+func (t *CachingTransport) load(req *http.Request, file string) *http.Response {
+	bts, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(bts)), req)
+	if err != nil || resp.Header.Get("ETag") == "" {
+		return nil
+	}
+	return resp
+}
+
+// store keeps the response with its (already read) body on disk.
+// The response is written to a temporary file first, so that concurrent
+// requests never read a partial response.
+func (t *CachingTransport) store(resp *http.Response, body []byte, file string) error {
+	// the body is kept as is, regardless of how it was transferred
+	plain := *resp
+	plain.ContentLength = int64(len(body))
+	plain.TransferEncoding = nil
+	plain.Body = io.NopCloser(bytes.NewReader(body))
+
+	dump, err := httputil.DumpResponse(&plain, true)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(t.Dir, 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(t.Dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(dump); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
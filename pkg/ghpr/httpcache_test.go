@@ -0,0 +1,110 @@
+package ghpr
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachingTransport(t *testing.T) {
+	var requests, notModified int
+	version := "v1"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		etag := fmt.Sprintf("%q", version)
+		w.Header().Set("X-RateLimit-Remaining", fmt.Sprint(5000-requests))
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		fmt.Fprintf(w, `{"version": %q}`, version)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	client := &http.Client{Transport: NewCachingTransport(dir, nil)}
+
+	get := func() (string, *http.Response) {
+		resp, err := client.Get(server.URL + "/repos/owner/repo")
+		assert.Nil(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		assert.Nil(t, err)
+		return string(body), resp
+	}
+
+	body, resp := get()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"version": "v1"}`, body)
+	assert.Equal(t, 0, notModified)
+
+	body, resp = get()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"version": "v1"}`, body)
+	assert.Equal(t, "4998", resp.Header.Get("X-RateLimit-Remaining"))
+	assert.Equal(t, 1, notModified)
+
+	version = "v2"
+	body, _ = get()
+	assert.Equal(t, `{"version": "v2"}`, body)
+	assert.Equal(t, 1, notModified)
+	assert.Equal(t, 3, requests)
+}
+
+func TestCachingTransportIgnoresUncacheable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("If-None-Match"))
+		if r.URL.Path == "/missing" {
+			w.Header().Set("ETag", `"missing"`)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("no etag"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	client := &http.Client{Transport: NewCachingTransport(dir, nil)}
+
+	for _, path := range []string{"/plain", "/plain", "/missing", "/missing"} {
+		resp, err := client.Get(server.URL + path)
+		assert.Nil(t, err)
+		resp.Body.Close()
+	}
+
+	entries, err := os.ReadDir(dir)
+	assert.True(t, err == nil || os.IsNotExist(err))
+	assert.Empty(t, entries)
+}
+
+func TestPruneCache(t *testing.T) {
+	dir := t.TempDir()
+	transport := NewCachingTransport(dir, nil)
+
+	old, recent := filepath.Join(dir, "old"), filepath.Join(dir, "recent")
+	assert.Nil(t, os.WriteFile(old, nil, 0600))
+	assert.Nil(t, os.WriteFile(recent, nil, 0600))
+
+	past := time.Now().Add(-2 * time.Hour)
+	assert.Nil(t, os.Chtimes(old, past, past))
+
+	assert.Nil(t, transport.PruneCache(time.Hour))
+
+	_, err := os.Stat(old)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(recent)
+	assert.Nil(t, err)
+
+	assert.Nil(t, NewCachingTransport(filepath.Join(dir, "missing"), nil).PruneCache(time.Hour))
+}
@@ -0,0 +1,53 @@
+package ghpr
+
+import (
+	"context"
+
+	"github.com/google/go-github/v48/github"
+)
+
+// Memberships are the organizations and teams (like org/team) of a user.
+type Memberships struct {
+	Orgs  []string `json:"orgs"`
+	Teams []string `json:"teams"`
+}
+
+// ListMemberships fetches the organizations and teams of the authenticated user,
+// page by page.
+func ListMemberships(ctx context.Context, client *github.Client) (*Memberships, error) {
+	result := &Memberships{}
+
+	orgOpts := &github.ListOptions{PerPage: 100}
+	for {
+		orgs, resp, err := client.Organizations.List(ctx, "", orgOpts)
+		if err != nil {
+			return nil, err
+		}
+		for _, org := range orgs {
+			result.Orgs = append(result.Orgs, org.GetLogin())
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		orgOpts.Page = resp.NextPage
+	}
+
+	teamOpts := &github.ListOptions{PerPage: 100}
+	for {
+		teams, resp, err := client.Teams.ListUserTeams(ctx, teamOpts)
+		if err != nil {
+			return nil, err
+		}
+		for _, team := range teams {
+			result.Teams = append(result.Teams, team.GetOrganization().GetLogin()+"/"+team.GetSlug())
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		teamOpts.Page = resp.NextPage
+	}
+
+	return result, nil
+}
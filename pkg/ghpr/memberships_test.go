@@ -0,0 +1,37 @@
+package ghpr
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListMemberships(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/user/orgs", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"login": "org"}, {"login": "acme"}]`))
+	})
+	// teams are split into two pages
+	mux.HandleFunc("/api/v3/user/teams", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s?page=2>; rel="next"`, r.URL.Path))
+			w.Write([]byte(`[{"slug": "team", "organization": {"login": "org"}}]`))
+			return
+		}
+		w.Write([]byte(`[{"slug": "infra", "organization": {"login": "acme"}}]`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClient(context.Background(), server.URL, "token")
+	assert.Nil(t, err)
+
+	memberships, err := ListMemberships(context.Background(), client)
+	assert.Nil(t, err)
+	assert.Equal(t, &Memberships{Orgs: []string{"org", "acme"}, Teams: []string{"org/team", "acme/infra"}}, memberships)
+}
@@ -0,0 +1,84 @@
+package ghpr
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryingTransport retries requests to GitHub API, which fail with a network error
+// or a transient server error (502 Bad Gateway, 503 Service Unavailable or 504 Gateway
+// Timeout), with capped exponential backoff and full jitter. Only requests, which
+// change nothing (GET and HEAD), are retried.
+type RetryingTransport struct {
+	// Base is the underlying transport, or http.DefaultTransport if nil.
+	Base http.RoundTripper
+	// MaxRetries is the number of retries after the first attempt.
+	MaxRetries int
+	// BaseDelay is the longest delay before the first retry, which doubles with every one.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay before any retry.
+	MaxDelay time.Duration
+}
+
+// NewRetryingTransport creates a retrying transport on top of base, which makes
+// up to 3 retries, waiting up to 500ms, 1s and 2s before them.
+func NewRetryingTransport(base http.RoundTripper) *RetryingTransport {
+	return &RetryingTransport{Base: base, MaxRetries: 3, BaseDelay: 500 * time.Millisecond, MaxDelay: 5 * time.Second}
+}
+
+func (t *RetryingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return base.RoundTrip(req)
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := base.RoundTrip(req)
+		if attempt >= t.MaxRetries || !isTransient(req.Context(), resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(t.delay(attempt))
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// delay returns a random delay before the retry, up to BaseDelay * 2^attempt, but no more than MaxDelay.
+func (t *RetryingTransport) delay(attempt int) time.Duration {
+	max := t.BaseDelay << attempt
+	if max <= 0 || max > t.MaxDelay {
+		max = t.MaxDelay
+	}
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
+
+// isTransient reports whether the request may succeed, if it is retried.
+func isTransient(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
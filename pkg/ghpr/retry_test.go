@@ -0,0 +1,62 @@
+package ghpr
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryingTransport(t *testing.T) {
+	var requests int
+	failures := 2
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= failures {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	transport := NewRetryingTransport(nil)
+	transport.BaseDelay, transport.MaxDelay = time.Millisecond, 5*time.Millisecond
+	client := &http.Client{Transport: transport}
+
+	// transient errors are retried
+	resp, err := client.Get(server.URL)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 3, requests)
+
+	// until retries are exhausted
+	requests, failures = 0, 10
+	resp, err = client.Get(server.URL)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, 4, requests)
+
+	// requests, which change something, are not retried
+	requests = 0
+	resp, err = client.Post(server.URL, "text/plain", strings.NewReader("body"))
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 1, requests)
+}
+
+func TestRetryingTransportDelay(t *testing.T) {
+	transport := NewRetryingTransport(nil)
+	for attempt := 0; attempt < 10; attempt++ {
+		delay := transport.delay(attempt)
+		assert.GreaterOrEqual(t, delay, time.Duration(0))
+		assert.Less(t, delay, transport.MaxDelay)
+		assert.Less(t, delay, transport.BaseDelay<<attempt)
+	}
+}
@@ -0,0 +1,142 @@
+package ghpr
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/go-github/v48/github"
+)
+
+// MaxReviews caps the number of reviews fetched for a pull request.
+const MaxReviews = 300
+
+// reviewsPerPage is the largest page size allowed by GitHub.
+const reviewsPerPage = 100
+
+// ReviewSummary holds the reviews of a pull request, and when they were fetched.
+type ReviewSummary struct {
+	Reviews   []*github.PullRequestReview `json:"reviews"`
+	FetchedAt time.Time                   `json:"fetched_at"`
+}
+
+// Fresh reports whether the reviews were fetched after the pull request was last updated.
+func (r *ReviewSummary) Fresh(updatedAt time.Time) bool {
+	return r != nil && r.FetchedAt.After(updatedAt)
+}
+
+// ListReviews fetches the reviews of the pull request, in chronological order, up to
+// MaxReviews. If there are more, the pages are walked backwards from the last one, so
+// that the newest reviews are kept, and a single extra (older) review is kept as well,
+// so that ReviewState can tell the reviews were capped.
+func ListReviews(ctx context.Context, client *github.Client, owner, repo string, number int) ([]*github.PullRequestReview, error) {
+	opts := &github.ListOptions{PerPage: reviewsPerPage}
+	first, resp, err := client.PullRequests.ListReviews(ctx, owner, repo, number, opts)
+	if err != nil {
+		return nil, err
+	}
+	if resp.LastPage == 0 {
+		return first, nil
+	}
+
+	// pages are collected newest first
+	var pages [][]*github.PullRequestReview
+	count := 0
+	for page := resp.LastPage; page > 1 && count <= MaxReviews; page-- {
+		opts.Page = page
+		reviews, _, err := client.PullRequests.ListReviews(ctx, owner, repo, number, opts)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, reviews)
+		count += len(reviews)
+	}
+	if count <= MaxReviews {
+		pages = append(pages, first)
+		count += len(first)
+	}
+
+	result := make([]*github.PullRequestReview, 0, count)
+	for i := len(pages) - 1; i >= 0; i-- {
+		result = append(result, pages[i]...)
+	}
+	if len(result) > MaxReviews+1 {
+		result = result[len(result)-MaxReviews-1:]
+	}
+	return result, nil
+}
+
+// ReviewState summarizes the reviews of a pull request in a single string:
+// the latest review of each reviewer is shown as ✅ (approved) or ❌ (changes
+// requested), while comments and dismissed reviews are ignored. If there are
+// more than MaxReviews reviews (i.e. they were capped), a + is added, since
+// the summary may be incomplete.
+func ReviewState(reviews []*github.PullRequestReview) string {
+	var result string
+
+	mapping := map[string]string{
+		"APPROVED":          "✅",
+		"CHANGES_REQUESTED": "❌",
+	}
+
+	for _, v := range latestReviews(reviews) {
+		result += mapping[*v.State]
+	}
+
+	if len(reviews) > MaxReviews {
+		result += "+"
+	}
+	return result
+}
+
+// Approvals counts the reviewers whose latest review approves the pull request.
+func Approvals(reviews []*github.PullRequestReview) int {
+	count := 0
+	for _, v := range latestReviews(reviews) {
+		if *v.State == "APPROVED" {
+			count++
+		}
+	}
+	return count
+}
+
+// latestReviews returns the latest review of each reviewer, ignoring comments.
+func latestReviews(reviews []*github.PullRequestReview) map[string]*github.PullRequestReview {
+	seen := make(map[string]*github.PullRequestReview)
+	for _, item := range reviews {
+		if *item.State == "COMMENTED" {
+			continue
+		}
+
+		v := seen[*item.User.Login]
+		if item.GetSubmittedAt().After(v.GetSubmittedAt()) {
+			seen[*item.User.Login] = item
+		}
+	}
+	return seen
+}
+
+// UserReviewState returns the state of the latest review by the user, like
+// APPROVED or CHANGES_REQUESTED. Comments are only reported as COMMENTED if
+// the user has not submitted any other review, and an empty string is
+// returned if the user has not reviewed the pull request at all.
+func UserReviewState(reviews []*github.PullRequestReview, login string) string {
+	var latest *github.PullRequestReview
+	commented := false
+	for _, item := range reviews {
+		if item.GetUser().GetLogin() != login {
+			continue
+		}
+		if item.GetState() == "COMMENTED" {
+			commented = true
+			continue
+		}
+		if item.GetSubmittedAt().After(latest.GetSubmittedAt()) {
+			latest = item
+		}
+	}
+
+	if latest == nil && commented {
+		return "COMMENTED"
+	}
+	return latest.GetState()
+}
@@ -0,0 +1,174 @@
+package ghpr
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v48/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReviewState(t *testing.T) {
+	review := func(upd time.Time, user, state string) *github.PullRequestReview {
+		return &github.PullRequestReview{
+			User:        &github.User{Login: &user},
+			State:       &state,
+			SubmittedAt: &upd,
+		}
+	}
+
+	data := []struct {
+		expected string
+		reviews  []*github.PullRequestReview
+	}{
+		{
+			"",
+			[]*github.PullRequestReview{
+				review(time.UnixMilli(1000), "user1", "COMMENTED"),
+				review(time.UnixMilli(2000), "user2", "COMMENTED"),
+			},
+		},
+		{
+			"",
+			[]*github.PullRequestReview{
+				review(time.UnixMilli(1000), "user1", "APPROVED"),
+				review(time.UnixMilli(2000), "user1", "DISMISSED"),
+			},
+		},
+		{
+			"✅",
+			[]*github.PullRequestReview{
+				review(time.UnixMilli(1000), "user1", "COMMENTED"),
+				review(time.UnixMilli(2000), "user2", "APPROVED"),
+			},
+		},
+		{
+			"✅✅",
+			[]*github.PullRequestReview{
+				review(time.UnixMilli(1000), "user1", "APPROVED"),
+				review(time.UnixMilli(2000), "user1", "COMMENTED"),
+				review(time.UnixMilli(3000), "user2", "APPROVED"),
+			},
+		},
+		{
+			"✅❌",
+			[]*github.PullRequestReview{
+				review(time.UnixMilli(1000), "user1", "APPROVED"),
+				review(time.UnixMilli(2000), "user1", "DISMISSED"),
+				review(time.UnixMilli(2000), "user2", "CHANGES_REQUESTED"),
+				review(time.UnixMilli(3000), "user1", "APPROVED"),
+			},
+		},
+		{
+			"❌",
+			[]*github.PullRequestReview{
+				review(time.UnixMilli(1000), "user1", "APPROVED"),
+				review(time.UnixMilli(2000), "user1", "COMMENTED"),
+				review(time.UnixMilli(3000), "user1", "CHANGES_REQUESTED"),
+			},
+		},
+	}
+
+	for _, testcase := range data {
+		actual := ReviewState(testcase.reviews)
+		assert.Equal(t, testcase.expected, sorted(actual))
+		assert.Equal(t, strings.Count(testcase.expected, "✅"), Approvals(testcase.reviews))
+	}
+}
+
+func sorted(input string) string {
+	result := []rune(input)
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return string(result)
+}
+
+func TestUserReviewState(t *testing.T) {
+	review := func(upd int64, user, state string) *github.PullRequestReview {
+		submitted := time.UnixMilli(upd)
+		return &github.PullRequestReview{
+			User:        &github.User{Login: &user},
+			State:       &state,
+			SubmittedAt: &submitted,
+		}
+	}
+
+	reviews := []*github.PullRequestReview{
+		review(1000, "me", "CHANGES_REQUESTED"),
+		review(2000, "me", "COMMENTED"),
+		review(3000, "me", "APPROVED"),
+		review(4000, "user1", "CHANGES_REQUESTED"),
+	}
+	assert.Equal(t, "APPROVED", UserReviewState(reviews, "me"))
+	assert.Equal(t, "CHANGES_REQUESTED", UserReviewState(reviews, "user1"))
+	assert.Equal(t, "", UserReviewState(reviews, "user2"))
+	assert.Equal(t, "COMMENTED", UserReviewState([]*github.PullRequestReview{review(1000, "me", "COMMENTED")}, "me"))
+	assert.Equal(t, "", UserReviewState(nil, "me"))
+}
+
+func TestReviewSummaryFresh(t *testing.T) {
+	fetchedAt := time.Now()
+	summary := &ReviewSummary{FetchedAt: fetchedAt}
+
+	assert.True(t, summary.Fresh(fetchedAt.Add(-time.Minute)))
+	assert.False(t, summary.Fresh(fetchedAt.Add(time.Minute)))
+	assert.False(t, (*ReviewSummary)(nil).Fresh(fetchedAt))
+}
+
+func TestListReviews(t *testing.T) {
+	// every page is full, with reviews numbered by their page
+	const lastPage = 6
+	var requested []int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/org/repo/pulls/1/reviews", func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		requested = append(requested, page)
+		assert.Equal(t, "100", r.URL.Query().Get("per_page"))
+
+		if page < lastPage {
+			w.Header().Set("Link", fmt.Sprintf(`<%s?page=%d>; rel="next", <%s?page=%d>; rel="last"`, r.URL.Path, page+1, r.URL.Path, lastPage))
+		}
+		w.Write([]byte("[" + strings.TrimSuffix(strings.Repeat(fmt.Sprintf(`{"id": %d, "state": "APPROVED", "user": {"login": "user%d"}, "submitted_at": "2022-01-0%dT00:00:00Z"},`, page, page, page), 100), ",") + "]"))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClient(context.Background(), server.URL, "token")
+	assert.Nil(t, err)
+
+	reviews, err := ListReviews(context.Background(), client, "org", "repo", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 6, 5, 4, 3}, requested)
+	assert.Equal(t, MaxReviews+1, len(reviews))
+	assert.Equal(t, int64(3), reviews[0].GetID())
+	assert.Equal(t, int64(6), reviews[MaxReviews].GetID())
+	assert.Equal(t, "✅✅✅✅+", ReviewState(reviews))
+}
+
+func TestListReviewsSinglePage(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/org/repo/pulls/1/reviews", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id": 1, "state": "APPROVED", "user": {"login": "user1"}, "submitted_at": "2022-01-01T00:00:00Z"}]`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClient(context.Background(), server.URL, "token")
+	assert.Nil(t, err)
+
+	reviews, err := ListReviews(context.Background(), client, "org", "repo", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(reviews))
+	assert.Equal(t, "✅", ReviewState(reviews))
+}
@@ -0,0 +1,168 @@
+package ghpr
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v48/github"
+	"golang.org/x/sync/errgroup"
+)
+
+// SearchQueries creates a GitHub search query for open pull requests
+// for each of the user roles and each of the teams.
+func SearchQueries(roles, teams []string, login string) []string {
+	result := make([]string, 0, len(roles)+len(teams))
+
+	for _, role := range roles {
+		result = append(result, fmt.Sprintf("type:pr is:open %s:%s", role, login))
+	}
+	for _, team := range teams {
+		result = append(result, fmt.Sprintf("type:pr is:open team-review-requested:%s", team))
+	}
+
+	return result
+}
+
+// searchConcurrency limits the searches run at the same time, since the search API
+// has a much lower rate limit than the rest of the API.
+const searchConcurrency = 4
+
+// Search runs the search queries concurrently (at most searchConcurrency at a time),
+// and returns the unique pull requests found, most recently updated first.
+func Search(ctx context.Context, client *github.Client, queries []string) ([]*github.Issue, error) {
+	wg, searchCtx := errgroup.WithContext(ctx)
+	wg.SetLimit(searchConcurrency)
+	results := make([]*github.IssuesSearchResult, len(queries))
+	for i, query := range queries {
+		i, query := i, query
+		wg.Go(func() error {
+			issues, _, err := client.Search.Issues(searchCtx, query, nil)
+			if err != nil {
+				return err
+			}
+			results[i] = issues
+			return nil
+		})
+	}
+
+	if err := wg.Wait(); err != nil {
+		return nil, err
+	}
+
+	var prs []*github.Issue
+	for _, issues := range results {
+		prs = append(prs, issues.Issues...)
+	}
+
+	return DeduplicateAndSort(prs), nil
+}
+
+// Count returns the number of pull requests matched by the search query on GitHub, which
+// may be more than Search returns, since only the first page of results is fetched.
+func Count(ctx context.Context, client *github.Client, query string) (int, error) {
+	opts := &github.SearchOptions{ListOptions: github.ListOptions{PerPage: 1}}
+	issues, _, err := client.Search.Issues(ctx, query, opts)
+	if err != nil {
+		return 0, err
+	}
+	return issues.GetTotal(), nil
+}
+
+// DeltaQuery narrows the search query down to pull requests updated since the time.
+// Closed pull requests are searched as well, so that they can be dropped from a cache.
+func DeltaQuery(query string, since time.Time) string {
+	return strings.Replace(query, "is:open ", "", 1) + " updated:>" + since.UTC().Format("2006-01-02T15:04:05Z")
+}
+
+// MergePRs updates the cached pull requests with the ones found by delta queries:
+// updated pull requests replace the cached ones, and closed ones are dropped.
+// It returns the merged pull requests, most recently updated first, along with
+// the open pull requests which were found.
+func MergePRs(cached, found []*github.Issue) (merged, open []*github.Issue) {
+	updated := make(map[int64]bool, len(found))
+	for _, pr := range found {
+		updated[pr.GetID()] = true
+		if pr.GetState() != "closed" {
+			open = append(open, pr)
+		}
+	}
+
+	merged = append(merged, open...)
+	for _, pr := range cached {
+		if !updated[pr.GetID()] {
+			merged = append(merged, pr)
+		}
+	}
+	return DeduplicateAndSort(merged), open
+}
+
+// DeduplicateAndSort returns unique GitHub issues from the slice, sorted by the update timestamp.
+func DeduplicateAndSort(prs []*github.Issue) []*github.Issue {
+	result := make([]*github.Issue, 0)
+
+	seen := make(map[int64]bool)
+	for _, item := range prs {
+		if _, ok := seen[*item.ID]; !ok {
+			seen[*item.ID] = true
+			result = append(result, item)
+		}
+	}
+
+	// ties are broken by ID, so that the order is stable across refreshes
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].UpdatedAt.Equal(*result[j].UpdatedAt) {
+			return *result[i].ID < *result[j].ID
+		}
+		return result[i].UpdatedAt.After(*result[j].UpdatedAt)
+	})
+
+	return result
+}
+
+// queryHeadBranches fetches head branches of pull requests by their node IDs.
+const queryHeadBranches = `query($ids: [ID!]!) { nodes(ids: $ids) { ... on PullRequest { id headRefName } } }`
+
+// maxGraphqlNodes is the max number of nodes which can be queried at once.
+const maxGraphqlNodes = 100
+
+// HeadBranches gets the head branch names of pull requests, keyed by PR ID.
+func HeadBranches(ctx context.Context, client *github.Client, prs []*github.Issue) (map[int64]string, error) {
+	ids := make(map[string]int64, len(prs))
+	nodeIds := make([]string, 0, len(prs))
+	for _, pr := range prs {
+		if pr.GetNodeID() != "" {
+			ids[pr.GetNodeID()] = pr.GetID()
+			nodeIds = append(nodeIds, pr.GetNodeID())
+		}
+	}
+
+	result := make(map[int64]string, len(nodeIds))
+	for start := 0; start < len(nodeIds); start += maxGraphqlNodes {
+		end := start + maxGraphqlNodes
+		if end > len(nodeIds) {
+			end = len(nodeIds)
+		}
+
+		var data struct {
+			Nodes []struct {
+				ID          string `json:"id"`
+				HeadRefName string `json:"headRefName"`
+			} `json:"nodes"`
+		}
+		vars := map[string]interface{}{"ids": nodeIds[start:end]}
+		if err := DoGraphQL(ctx, client, queryHeadBranches, vars, &data); err != nil {
+			return nil, err
+		}
+
+		for _, node := range data.Nodes {
+			if id, ok := ids[node.ID]; ok && node.HeadRefName != "" {
+				result[id] = node.HeadRefName
+			}
+		}
+	}
+
+	return result, nil
+}
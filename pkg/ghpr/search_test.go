@@ -0,0 +1,135 @@
+package ghpr
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v48/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchQueries(t *testing.T) {
+	actual := SearchQueries([]string{"author", "involves"}, []string{"org/team"}, "me")
+
+	assert.Equal(t, []string{
+		"type:pr is:open author:me",
+		"type:pr is:open involves:me",
+		"type:pr is:open team-review-requested:org/team",
+	}, actual)
+}
+
+func TestDeduplicateAndSort(t *testing.T) {
+
+	issue := func(id int64, upd time.Time) *github.Issue {
+		return &github.Issue{ID: &id, UpdatedAt: &upd}
+	}
+
+	issues := []*github.Issue{
+		issue(1, time.UnixMilli(1000)),
+		issue(2, time.UnixMilli(5000)),
+		issue(3, time.UnixMilli(3000)),
+		issue(2, time.UnixMilli(5000)),
+		issue(4, time.UnixMilli(2000)),
+		issue(9, time.UnixMilli(3000)),
+		issue(9, time.UnixMilli(3000)),
+		issue(5, time.UnixMilli(3000)),
+	}
+
+	expected := []*github.Issue{
+		issue(2, time.UnixMilli(5000)),
+		issue(3, time.UnixMilli(3000)),
+		issue(5, time.UnixMilli(3000)),
+		issue(9, time.UnixMilli(3000)),
+		issue(4, time.UnixMilli(2000)),
+		issue(1, time.UnixMilli(1000)),
+	}
+
+	actual := DeduplicateAndSort(issues)
+
+	assert.Equal(t, expected, actual)
+
+	assert.True(t, sort.SliceIsSorted(actual, func(i, j int) bool {
+		return actual[i].UpdatedAt.After(*actual[j].UpdatedAt)
+	}))
+}
+
+func TestDeltaQuery(t *testing.T) {
+	since := time.Date(2023, 1, 15, 11, 30, 0, 0, time.FixedZone("UTC+2", 2*60*60))
+	assert.Equal(t, "type:pr author:octocat updated:>2023-01-15T09:30:00Z", DeltaQuery("type:pr is:open author:octocat", since))
+}
+
+func TestMergePRs(t *testing.T) {
+	issue := func(id int64, state string, updated int64) *github.Issue {
+		upd := time.UnixMilli(updated)
+		return &github.Issue{ID: &id, State: &state, UpdatedAt: &upd}
+	}
+
+	cached := []*github.Issue{issue(1, "open", 3000), issue(2, "open", 2000), issue(3, "open", 1000)}
+	found := []*github.Issue{issue(3, "open", 5000), issue(2, "closed", 4000), issue(4, "open", 3500)}
+
+	merged, open := MergePRs(cached, found)
+
+	ids := make([]int64, len(merged))
+	for i, pr := range merged {
+		ids[i] = pr.GetID()
+	}
+	assert.Equal(t, []int64{3, 4, 1}, ids)
+	assert.Equal(t, []*github.Issue{found[0], found[2]}, open)
+}
+
+func TestSearch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/search/issues", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("q") {
+		case "type:pr is:open author:me":
+			w.Write([]byte(`{"items": [{"id": 1, "updated_at": "2022-01-01T00:00:00Z"}, {"id": 2, "updated_at": "2022-03-01T00:00:00Z"}]}`))
+		default:
+			w.Write([]byte(`{"items": [{"id": 2, "updated_at": "2022-03-01T00:00:00Z"}, {"id": 3, "updated_at": "2022-02-01T00:00:00Z"}]}`))
+		}
+	})
+	mux.HandleFunc("/api/graphql", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": {"nodes": [{"id": "PR_2", "headRefName": "feature"}]}}`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClient(context.Background(), server.URL, "token")
+	assert.Nil(t, err)
+
+	prs, err := Search(context.Background(), client, SearchQueries([]string{"author", "involves"}, nil, "me"))
+	assert.Nil(t, err)
+
+	ids := make([]int64, len(prs))
+	for i, pr := range prs {
+		ids[i] = pr.GetID()
+	}
+	assert.Equal(t, []int64{2, 3, 1}, ids)
+
+	prs[0].NodeID = github.String("PR_2")
+	branches, err := HeadBranches(context.Background(), client, prs)
+	assert.Nil(t, err)
+	assert.Equal(t, map[int64]string{2: "feature"}, branches)
+}
+
+func TestCount(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/search/issues", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "1", r.URL.Query().Get("per_page"))
+		w.Write([]byte(`{"total_count": 42, "items": [{"id": 1}]}`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClient(context.Background(), server.URL, "token")
+	assert.Nil(t, err)
+
+	count, err := Count(context.Background(), client, "type:pr is:open author:me")
+	assert.Nil(t, err)
+	assert.Equal(t, 42, count)
+}
@@ -0,0 +1,314 @@
+package prcache
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backend keeps the named entries of stores, as JSON.
+// Storing nil removes the entry.
+type Backend interface {
+	LoadJSON(name string, v interface{}) error
+	StoreJSON(name string, v interface{}) error
+	// Store saves the raw entry, or removes it if data is nil.
+	Store(name string, data []byte) error
+	// Open reads the raw entry, so that large entries can be decoded as a stream.
+	Open(name string) (io.ReadCloser, error)
+	Exists(name string) bool
+	Expired(name string, maxAge time.Duration) bool
+	Age(name string) (time.Duration, error)
+	// Names lists the entries with the prefix.
+	Names(prefix string) ([]string, error)
+	Remove(name string) error
+}
+
+// FileBackend keeps entries as files in a directory (like awgo cache does),
+// so that the workflow cache can be read by other tools.
+type FileBackend struct {
+	Dir string
+}
+
+// NewFileBackend creates a backend, which keeps its entries in dir.
+func NewFileBackend(dir string) *FileBackend {
+	return &FileBackend{Dir: dir}
+}
+
+func (b *FileBackend) path(name string) string {
+	return filepath.Join(b.Dir, name)
+}
+
+func (b *FileBackend) LoadJSON(name string, v interface{}) error {
+	data, err := os.ReadFile(b.path(name))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (b *FileBackend) StoreJSON(name string, v interface{}) error {
+	if v == nil {
+		return b.Remove(name)
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return b.Store(name, data)
+}
+
+// Store writes the entry to a temporary file first, and then renames it,
+// so that readers never see a partially written entry.
+func (b *FileBackend) Store(name string, data []byte) error {
+	if data == nil {
+		return b.Remove(name)
+	}
+
+	f, err := os.CreateTemp(b.Dir, name)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), b.path(name))
+}
+
+func (b *FileBackend) Open(name string) (io.ReadCloser, error) {
+	return os.Open(b.path(name))
+}
+
+func (b *FileBackend) Exists(name string) bool {
+	_, err := os.Stat(b.path(name))
+	return err == nil
+}
+
+func (b *FileBackend) Expired(name string, maxAge time.Duration) bool {
+	age, err := b.Age(name)
+	return err != nil || age > maxAge
+}
+
+func (b *FileBackend) Age(name string) (time.Duration, error) {
+	fi, err := os.Stat(b.path(name))
+	if err != nil {
+		return 0, err
+	}
+	return time.Since(fi.ModTime()), nil
+}
+
+func (b *FileBackend) Names(prefix string) ([]string, error) {
+	entries, err := os.ReadDir(b.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), prefix) {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+func (b *FileBackend) Remove(name string) error {
+	if err := os.Remove(b.path(name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// MemoryBackend keeps entries in memory, for tests and throwaway stores.
+type MemoryBackend struct {
+	mu      sync.RWMutex
+	entries map[string]*memoryEntry
+}
+
+type memoryEntry struct {
+	data     []byte
+	storedAt time.Time
+}
+
+// NewMemoryBackend creates an empty backend in memory.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{entries: make(map[string]*memoryEntry)}
+}
+
+func (b *MemoryBackend) entry(name string) (*memoryEntry, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	e, ok := b.entries[name]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return e, nil
+}
+
+func (b *MemoryBackend) LoadJSON(name string, v interface{}) error {
+	e, err := b.entry(name)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(e.data, v)
+}
+
+func (b *MemoryBackend) StoreJSON(name string, v interface{}) error {
+	if v == nil {
+		return b.Remove(name)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Store(name, data)
+}
+
+func (b *MemoryBackend) Store(name string, data []byte) error {
+	if data == nil {
+		return b.Remove(name)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries[name] = &memoryEntry{data: data, storedAt: time.Now()}
+	return nil
+}
+
+func (b *MemoryBackend) Open(name string) (io.ReadCloser, error) {
+	e, err := b.entry(name)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(e.data)), nil
+}
+
+func (b *MemoryBackend) Exists(name string) bool {
+	_, err := b.entry(name)
+	return err == nil
+}
+
+func (b *MemoryBackend) Expired(name string, maxAge time.Duration) bool {
+	age, err := b.Age(name)
+	return err != nil || age > maxAge
+}
+
+func (b *MemoryBackend) Age(name string) (time.Duration, error) {
+	e, err := b.entry(name)
+	if err != nil {
+		return 0, err
+	}
+	return time.Since(e.storedAt), nil
+}
+
+func (b *MemoryBackend) Names(prefix string) ([]string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var names []string
+	for name := range b.entries {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (b *MemoryBackend) Remove(name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.entries, name)
+	return nil
+}
+
+// gzipMagic are the first bytes of gzip-compressed data, which JSON never starts with.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// GzipBackend compresses the entries chosen by compress with gzip, on top
+// of another backend. Entries are read whether they are compressed or not,
+// so that the compression can be turned on and off at any time.
+type GzipBackend struct {
+	Backend
+	compress func(name string) bool
+}
+
+// NewGzipBackend creates a backend, which compresses the chosen entries of base.
+func NewGzipBackend(base Backend, compress func(name string) bool) *GzipBackend {
+	return &GzipBackend{Backend: base, compress: compress}
+}
+
+func (b *GzipBackend) StoreJSON(name string, v interface{}) error {
+	if v == nil || !b.compress(name) {
+		return b.Backend.StoreJSON(name, v)
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(v); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return b.Store(name, buf.Bytes())
+}
+
+func (b *GzipBackend) LoadJSON(name string, v interface{}) error {
+	r, err := b.Open(name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return json.NewDecoder(r).Decode(v)
+}
+
+func (b *GzipBackend) Open(name string) (io.ReadCloser, error) {
+	f, err := b.Backend.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(f)
+	if magic, err := r.Peek(len(gzipMagic)); err != nil || !bytes.Equal(magic, gzipMagic) {
+		return &readCloser{r, f}, nil
+	}
+
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &readCloser{zr, f}, nil
+}
+
+// readCloser reads (decompressed) data, and closes the underlying entry.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+var (
+	_ Backend = (*FileBackend)(nil)
+	_ Backend = (*MemoryBackend)(nil)
+	_ Backend = (*GzipBackend)(nil)
+)
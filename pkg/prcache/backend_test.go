@@ -0,0 +1,83 @@
+package prcache
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheBackends(t *testing.T) {
+	backends := map[string]Backend{
+		"file":   NewFileBackend(t.TempDir()),
+		"memory": NewMemoryBackend(),
+	}
+
+	for kind, backend := range backends {
+		t.Run(kind, func(t *testing.T) {
+			assert.False(t, backend.Exists("gh-a"))
+			assert.True(t, backend.Expired("gh-a", time.Hour))
+			assert.Error(t, backend.LoadJSON("gh-a", &[]int{}))
+			_, err := backend.Age("gh-a")
+			assert.Error(t, err)
+
+			assert.Nil(t, backend.StoreJSON("gh-a", []int{1, 2}))
+			assert.Nil(t, backend.StoreJSON("gh-b", "b"))
+			assert.Nil(t, backend.StoreJSON("other", "c"))
+
+			var numbers []int
+			assert.Nil(t, backend.LoadJSON("gh-a", &numbers))
+			assert.Equal(t, []int{1, 2}, numbers)
+			assert.False(t, backend.Expired("gh-a", time.Hour))
+
+			r, err := backend.Open("gh-b")
+			assert.Nil(t, err)
+			raw, err := io.ReadAll(r)
+			assert.Nil(t, err)
+			assert.Nil(t, r.Close())
+			assert.Equal(t, `"b"`, string(raw))
+
+			names, err := backend.Names("gh-")
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"gh-a", "gh-b"}, names)
+
+			assert.Nil(t, backend.StoreJSON("gh-a", nil))
+			assert.Nil(t, backend.Remove("gh-b"))
+			assert.Nil(t, backend.Remove("gh-missing"))
+			assert.False(t, backend.Exists("gh-a"))
+			assert.False(t, backend.Exists("gh-b"))
+			assert.True(t, backend.Exists("other"))
+		})
+	}
+}
+
+func TestGzipBackend(t *testing.T) {
+	base := NewMemoryBackend()
+	compress := true
+	backend := NewGzipBackend(base, func(name string) bool { return compress && name == "gh-big" })
+
+	assert.Nil(t, backend.StoreJSON("gh-big", []string{"a", "b"}))
+	assert.Nil(t, backend.StoreJSON("gh-small", []string{"c"}))
+
+	r, err := base.Open("gh-big")
+	assert.Nil(t, err)
+	raw, err := io.ReadAll(r)
+	assert.Nil(t, err)
+	assert.Equal(t, gzipMagic, raw[:2])
+
+	var big, small []string
+	assert.Nil(t, backend.LoadJSON("gh-big", &big))
+	assert.Nil(t, backend.LoadJSON("gh-small", &small))
+	assert.Equal(t, []string{"a", "b"}, big)
+	assert.Equal(t, []string{"c"}, small)
+
+	// entries compressed before are still read, once compression is off
+	compress = false
+	assert.Nil(t, backend.LoadJSON("gh-big", &big))
+	assert.Equal(t, []string{"a", "b"}, big)
+
+	assert.Nil(t, backend.StoreJSON("gh-big", []string{"d"}))
+	assert.Nil(t, base.LoadJSON("gh-big", &big))
+	assert.Equal(t, []string{"d"}, big)
+}
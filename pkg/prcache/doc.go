@@ -0,0 +1,18 @@
+// Package prcache caches the pull requests found with package ghpr, along with
+// their reviews, so that they can be listed without calling GitHub every time.
+//
+// It is the caching part of the go-ghpr Alfred workflow, and can be used by other
+// tools (dashboards, bots) on its own, or to read the cache of the workflow:
+//
+//	store := prcache.NewStore(prcache.NewFileBackend(dir), "")
+//	if store.PRsExpired(5 * time.Minute) {
+//		prs, err := ghpr.Search(ctx, client, queries)
+//		err = store.StorePRs(prs)
+//	}
+//	prs, err := store.LoadPRs(10)
+//	summaries, err := store.LoadReviews()
+//
+// Entries are kept as JSON in a Backend: files in a directory, memory, or either
+// of them with the largest entries compressed by a GzipBackend. Other entries can
+// be kept in the same Store with Load, Save, LoadOrStore and Update.
+package prcache
@@ -0,0 +1,299 @@
+package prcache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/AndreyBozhko/go-alfred-prs/pkg/ghpr"
+	"github.com/google/go-github/v48/github"
+)
+
+// Names of the entries of the pull request and review caches.
+const (
+	PullRequestsKey      = "gh-pull-requests"
+	PullRequestsCountKey = "gh-pull-requests-count"
+	SearchTotalKey       = "gh-search-total"
+	ReviewsKey           = "gh-reviews"
+)
+
+// Store keeps JSON entries in a backend. All names are prefixed with namespace,
+// and access to the same store from multiple goroutines is synchronized.
+type Store struct {
+	backend   Backend
+	namespace string
+	mu        sync.RWMutex
+}
+
+// NewStore creates a store which keeps its data in backend.
+func NewStore(backend Backend, namespace string) *Store {
+	return &Store{backend: backend, namespace: namespace}
+}
+
+func (s *Store) key(name string) string {
+	return s.namespace + name
+}
+
+// Load unmarshals the named entry into v.
+func (s *Store) Load(name string, v interface{}) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.backend.LoadJSON(s.key(name), v)
+}
+
+// Save marshals v and saves it under a name. Saving nil removes the entry.
+func (s *Store) Save(name string, v interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.backend.StoreJSON(s.key(name), v)
+}
+
+// Exists reports whether the named entry is stored.
+func (s *Store) Exists(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.backend.Exists(s.key(name))
+}
+
+// Expired reports whether the named entry is missing or older than maxAge.
+// Zero maxAge means that the entry never expires.
+func (s *Store) Expired(name string, maxAge time.Duration) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if maxAge == 0 {
+		return !s.backend.Exists(s.key(name))
+	}
+	return s.backend.Expired(s.key(name), maxAge)
+}
+
+// Age returns the time since the named entry was stored.
+func (s *Store) Age(name string) (time.Duration, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.backend.Age(s.key(name))
+}
+
+// LoadOrStore loads the named entry into v, unless it is expired, in which
+// case the data returned by reload are stored and loaded into v.
+// The lock is not held while reloading, so that slow reloads of different
+// entries can run concurrently.
+func (s *Store) LoadOrStore(name string, maxAge time.Duration, reload func() (interface{}, error), v interface{}) error {
+	if !s.Expired(name, maxAge) {
+		return s.Load(name, v)
+	}
+
+	data, err := reload()
+	if err != nil {
+		return err
+	}
+
+	if err = s.Save(name, data); err != nil {
+		return err
+	}
+	return s.Load(name, v)
+}
+
+// Update loads the named entry into v (which is left as is, if the entry is missing),
+// applies update to v, and saves it, all while the lock is held.
+func (s *Store) Update(name string, v interface{}, update func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.backend.Exists(s.key(name)) {
+		if err := s.backend.LoadJSON(s.key(name), v); err != nil {
+			return err
+		}
+	}
+
+	if err := update(); err != nil {
+		return err
+	}
+	return s.backend.StoreJSON(s.key(name), v)
+}
+
+// PruneExpired removes the entries with the prefix, which are older than maxAge.
+func (s *Store) PruneExpired(prefix string, maxAge time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names, err := s.backend.Names(s.key(prefix))
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if !s.backend.Expired(name, maxAge) {
+			continue
+		}
+		if err = s.backend.Remove(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadPRs loads the cached pull requests, up to limit (non-positive limit means no limit).
+// Only the pull requests which are returned are decoded.
+func (s *Store) LoadPRs(limit int) ([]*github.Issue, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	f, err := s.backend.Open(s.key(PullRequestsKey))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return decodeIssues(f, limit)
+}
+
+// StorePRs caches the pull requests, most recently updated first.
+func (s *Store) StorePRs(prs []*github.Issue) error {
+	if err := s.Save(PullRequestsKey, prs); err != nil {
+		return err
+	}
+	// the count is kept separately, so that it is known without loading all pull requests
+	return s.Save(PullRequestsCountKey, len(prs))
+}
+
+// CountPRs returns the number of cached pull requests.
+func (s *Store) CountPRs() (int, error) {
+	var count int
+	err := s.Load(PullRequestsCountKey, &count)
+	return count, err
+}
+
+// LoadSearchTotal returns the number of pull requests matched on GitHub, when last searched.
+func (s *Store) LoadSearchTotal() (int, error) {
+	var total int
+	err := s.Load(SearchTotalKey, &total)
+	return total, err
+}
+
+// StoreSearchTotal caches the number of pull requests matched on GitHub.
+func (s *Store) StoreSearchTotal(total int) error {
+	return s.Save(SearchTotalKey, total)
+}
+
+// UpdatePR applies update to the cached pull request with the ID.
+func (s *Store) UpdatePR(id int64, update func(pr *github.Issue)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var prs []*github.Issue
+	if err := s.backend.LoadJSON(s.key(PullRequestsKey), &prs); err != nil {
+		return err
+	}
+
+	for _, pr := range prs {
+		if pr.GetID() == id {
+			update(pr)
+		}
+	}
+
+	return s.backend.StoreJSON(s.key(PullRequestsKey), prs)
+}
+
+// PRsExpired reports whether the cached pull requests are missing or older than maxAge.
+func (s *Store) PRsExpired(maxAge time.Duration) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.backend.Expired(s.key(PullRequestsKey), maxAge)
+}
+
+// PRsAge returns the time since the pull requests were cached.
+func (s *Store) PRsAge() (time.Duration, error) {
+	return s.Age(PullRequestsKey)
+}
+
+// LoadReviews returns the cached reviews, keyed by PR ID.
+func (s *Store) LoadReviews() (map[int64]*ghpr.ReviewSummary, error) {
+	summaries := make(map[int64]*ghpr.ReviewSummary)
+	if !s.Exists(ReviewsKey) {
+		return summaries, nil
+	}
+
+	err := s.Load(ReviewsKey, &summaries)
+	return summaries, err
+}
+
+// StoreReviews replaces the cached reviews.
+func (s *Store) StoreReviews(summaries map[int64]*ghpr.ReviewSummary) error {
+	return s.Save(ReviewsKey, summaries)
+}
+
+// UpdateReviews caches the reviews of a single pull request, fetched just now.
+func (s *Store) UpdateReviews(id int64, reviews []*github.PullRequestReview) error {
+	summaries := make(map[int64]*ghpr.ReviewSummary)
+	return s.Update(ReviewsKey, &summaries, func() error {
+		summaries[id] = &ghpr.ReviewSummary{Reviews: reviews, FetchedAt: time.Now()}
+		return nil
+	})
+}
+
+// MergeReviews reloads the cached reviews, and replaces the ones of the fetched pull requests,
+// unless they were updated later on (by an approval, for example).
+func (s *Store) MergeReviews(fetched map[int64]*ghpr.ReviewSummary) error {
+	summaries := make(map[int64]*ghpr.ReviewSummary)
+	return s.Update(ReviewsKey, &summaries, func() error {
+		for id, summary := range fetched {
+			if cached, ok := summaries[id]; !ok || !cached.FetchedAt.After(summary.FetchedAt) {
+				summaries[id] = summary
+			}
+		}
+		return nil
+	})
+}
+
+// PruneReviews drops the reviews of pull requests which are not kept.
+func (s *Store) PruneReviews(keep map[int64]bool) error {
+	if !s.Exists(ReviewsKey) {
+		return nil
+	}
+
+	summaries := make(map[int64]*ghpr.ReviewSummary)
+	return s.Update(ReviewsKey, &summaries, func() error {
+		for id := range summaries {
+			if !keep[id] {
+				delete(summaries, id)
+			}
+		}
+		return nil
+	})
+}
+
+// decodeIssues reads a JSON array of GitHub issues from the stream one element at a time,
+// and stops after limit items are decoded (non-positive limit means no limit).
+func decodeIssues(r io.Reader, limit int) ([]*github.Issue, error) {
+	dec := json.NewDecoder(r)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok == nil {
+		return nil, nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("expected json array, got %v", tok)
+	}
+
+	var result []*github.Issue
+	for dec.More() && (limit <= 0 || len(result) < limit) {
+		var item *github.Issue
+		if err := dec.Decode(&item); err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+
+	return result, nil
+}
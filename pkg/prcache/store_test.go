@@ -0,0 +1,230 @@
+package prcache
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AndreyBozhko/go-alfred-prs/pkg/ghpr"
+	"github.com/google/go-github/v48/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreNamespaces(t *testing.T) {
+	backend := NewFileBackend(t.TempDir())
+	first, second := NewStore(backend, "first-"), NewStore(backend, "second-")
+
+	id := int64(1)
+	assert.Nil(t, first.StorePRs([]*github.Issue{{ID: &id}}))
+	assert.True(t, backend.Exists("first-"+PullRequestsKey))
+
+	prs, err := first.LoadPRs(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(prs))
+
+	count, err := first.CountPRs()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+
+	_, err = second.LoadPRs(0)
+	assert.Error(t, err)
+
+	assert.False(t, first.PRsExpired(time.Minute))
+	assert.True(t, second.PRsExpired(time.Minute))
+}
+
+func TestStoreLoadOrStore(t *testing.T) {
+	store := NewStore(NewMemoryBackend(), "")
+
+	reloads := 0
+	reload := func() (interface{}, error) {
+		reloads++
+		return reloads, nil
+	}
+
+	var value int
+	assert.Nil(t, store.LoadOrStore("gh-value", 0, reload, &value))
+	assert.Nil(t, store.LoadOrStore("gh-value", 0, reload, &value))
+	assert.Equal(t, 1, value)
+
+	// an expired entry is reloaded
+	assert.Nil(t, store.LoadOrStore("gh-value", time.Nanosecond, reload, &value))
+	assert.Equal(t, 2, value)
+
+	failed := errors.New("failed")
+	assert.Equal(t, failed, store.LoadOrStore("gh-other", 0, func() (interface{}, error) { return nil, failed }, &value))
+	assert.False(t, store.Exists("gh-other"))
+}
+
+func TestStoreUpdate(t *testing.T) {
+	store := NewStore(NewMemoryBackend(), "")
+
+	values := make(map[string]int)
+	assert.Nil(t, store.Update("gh-values", &values, func() error {
+		values["a"]++
+		return nil
+	}))
+
+	values = make(map[string]int)
+	assert.Nil(t, store.Update("gh-values", &values, func() error {
+		values["a"]++
+		return nil
+	}))
+	assert.Equal(t, map[string]int{"a": 2}, values)
+
+	// nothing is saved, if the update fails
+	failed := errors.New("failed")
+	assert.Equal(t, failed, store.Update("gh-values", &values, func() error {
+		values["a"]++
+		return failed
+	}))
+
+	var saved map[string]int
+	assert.Nil(t, store.Load("gh-values", &saved))
+	assert.Equal(t, map[string]int{"a": 2}, saved)
+}
+
+func TestStorePruneExpired(t *testing.T) {
+	backend := NewMemoryBackend()
+	store := NewStore(backend, "ns-")
+
+	assert.Nil(t, store.Save("gh-lang-a", "go"))
+	assert.Nil(t, backend.StoreJSON("gh-lang-b", "rust"))
+	time.Sleep(50 * time.Millisecond)
+	assert.Nil(t, store.Save("gh-lang-c", "java"))
+
+	assert.Nil(t, store.PruneExpired("gh-lang-", 25*time.Millisecond))
+	assert.False(t, store.Exists("gh-lang-a"))
+	assert.True(t, store.Exists("gh-lang-c"))
+	// entries of other namespaces are kept
+	assert.True(t, backend.Exists("gh-lang-b"))
+}
+
+func TestStoreReviews(t *testing.T) {
+	store := NewStore(NewMemoryBackend(), "")
+
+	summaries, err := store.LoadReviews()
+	assert.Nil(t, err)
+	assert.Empty(t, summaries)
+
+	fetchedAt := time.Now().Add(-time.Hour).Truncate(time.Second).UTC()
+	assert.Nil(t, store.StoreReviews(map[int64]*ghpr.ReviewSummary{
+		1: {Reviews: []*github.PullRequestReview{{State: github.String("COMMENTED")}}, FetchedAt: fetchedAt},
+	}))
+
+	assert.Nil(t, store.UpdateReviews(2, []*github.PullRequestReview{{State: github.String("APPROVED")}}))
+
+	summaries, err = store.LoadReviews()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(summaries))
+	assert.Equal(t, "COMMENTED", summaries[1].Reviews[0].GetState())
+	assert.Equal(t, fetchedAt, summaries[1].FetchedAt)
+	assert.Equal(t, "APPROVED", summaries[2].Reviews[0].GetState())
+
+	assert.True(t, summaries[1].Fresh(fetchedAt.Add(-time.Minute)))
+	assert.False(t, summaries[1].Fresh(fetchedAt.Add(time.Minute)))
+	assert.False(t, summaries[3].Fresh(fetchedAt))
+}
+
+func TestStoreMergeReviews(t *testing.T) {
+	store := NewStore(NewMemoryBackend(), "")
+
+	// the status pass started before the approval
+	fetchedAt := time.Now().Add(-time.Minute)
+	assert.Nil(t, store.UpdateReviews(1, []*github.PullRequestReview{{State: github.String("APPROVED")}}))
+	assert.Nil(t, store.UpdateReviews(3, nil))
+
+	assert.Nil(t, store.MergeReviews(map[int64]*ghpr.ReviewSummary{
+		1: {Reviews: []*github.PullRequestReview{{State: github.String("COMMENTED")}}, FetchedAt: fetchedAt},
+		2: {Reviews: []*github.PullRequestReview{{State: github.String("CHANGES_REQUESTED")}}, FetchedAt: fetchedAt},
+	}))
+
+	summaries, err := store.LoadReviews()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(summaries))
+	assert.Equal(t, "APPROVED", summaries[1].Reviews[0].GetState())
+	assert.Equal(t, "CHANGES_REQUESTED", summaries[2].Reviews[0].GetState())
+}
+
+func TestStorePruneReviews(t *testing.T) {
+	backend := NewMemoryBackend()
+	store := NewStore(backend, "")
+
+	assert.Nil(t, store.PruneReviews(map[int64]bool{1: true}))
+	assert.False(t, backend.Exists(ReviewsKey))
+
+	assert.Nil(t, store.UpdateReviews(1, nil))
+	assert.Nil(t, store.UpdateReviews(2, nil))
+	assert.Nil(t, store.PruneReviews(map[int64]bool{1: true}))
+
+	summaries, err := store.LoadReviews()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(summaries))
+	assert.NotNil(t, summaries[1])
+}
+
+func TestStoreUpdatePR(t *testing.T) {
+	store := NewStore(NewMemoryBackend(), "")
+
+	assert.Error(t, store.UpdatePR(1, func(*github.Issue) {}))
+
+	first, second := int64(1), int64(2)
+	assert.Nil(t, store.StorePRs([]*github.Issue{{ID: &first}, {ID: &second}}))
+
+	assert.Nil(t, store.UpdatePR(second, func(pr *github.Issue) {
+		pr.Title = github.String("updated")
+	}))
+
+	prs, err := store.LoadPRs(0)
+	assert.Nil(t, err)
+	assert.Equal(t, "", prs[0].GetTitle())
+	assert.Equal(t, "updated", prs[1].GetTitle())
+}
+
+func TestStoreSearchTotal(t *testing.T) {
+	store := NewStore(NewMemoryBackend(), "")
+
+	_, err := store.LoadSearchTotal()
+	assert.Error(t, err)
+
+	assert.Nil(t, store.StoreSearchTotal(42))
+
+	total, err := store.LoadSearchTotal()
+	assert.Nil(t, err)
+	assert.Equal(t, 42, total)
+}
+
+func TestDecodeIssues(t *testing.T) {
+	input := `[{"id": 1, "number": 78}, {"id": 2, "number": 67}, {"id": 3, "number": 89}]`
+
+	data := []struct {
+		limit    int
+		expected []int64
+	}{
+		{0, []int64{1, 2, 3}},
+		{-1, []int64{1, 2, 3}},
+		{1, []int64{1}},
+		{2, []int64{1, 2}},
+		{5, []int64{1, 2, 3}},
+	}
+
+	for _, testcase := range data {
+		actual, err := decodeIssues(strings.NewReader(input), testcase.limit)
+		assert.Nil(t, err)
+
+		ids := make([]int64, len(actual))
+		for i, item := range actual {
+			ids[i] = *item.ID
+		}
+
+		assert.Equal(t, testcase.expected, ids)
+	}
+}
+
+func TestDecodeIssuesError(t *testing.T) {
+	for _, input := range []string{``, `{"id": 1}`, `[{"id": "one"}]`} {
+		_, err := decodeIssues(strings.NewReader(input), 0)
+		assert.Error(t, err)
+	}
+}